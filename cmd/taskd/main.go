@@ -1,24 +1,73 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandeepkv93/taskd/internal/cli"
+	"github.com/sandeepkv93/taskd/internal/clock"
 	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
 	"github.com/sandeepkv93/taskd/internal/update"
 )
 
 func main() {
-	cfg := update.RuntimeConfigFromEnv(update.DefaultRuntimeConfig())
+	cfg, err := update.RuntimeConfigFromEnv(update.DefaultRuntimeConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskd failed: %v\n", err)
+		os.Exit(1)
+	}
 
-	reminderEngine := scheduler.NewEngine(cfg.SchedulerBuffer)
+	// Subcommands open the database only once their arguments parse, so
+	// help and usage errors work without touching it.
+	closeRepo := func() {}
+	open := func() (storage.Repository, error) {
+		repo, closeFn, err := openRepository(cfg.DatabasePath)
+		if err != nil {
+			return nil, err
+		}
+		closeRepo = closeFn
+		return repo, nil
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		err := serve(cfg, open, os.Args[2:], os.Stderr)
+		closeRepo()
+		if code := cli.ExitCode(err); code != 0 {
+			fmt.Fprintf(os.Stderr, "taskd: %v\n", err)
+			os.Exit(code)
+		}
+		return
+	}
+	if len(os.Args) > 1 {
+		runner := cli.NewLazyRunner(open, os.Stdout, os.Stderr, clock.Real{}).WithAvailableMinutes(cfg.ProductivityAvailableMins)
+		err := runner.Run(context.Background(), os.Args[1:])
+		closeRepo()
+		if code := cli.ExitCode(err); code != 0 {
+			fmt.Fprintf(os.Stderr, "taskd: %v\n", err)
+			os.Exit(code)
+		}
+		return
+	}
+
+	repo, closeRepo, err := openRepository(cfg.DatabasePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskd failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer closeRepo()
+
+	reminderEngine := scheduler.NewEngine(cfg.SchedulerBuffer, scheduler.WithDeliveryPolicy(cfg.DeliveryPolicy))
 	reminderEngine.Start()
 	defer reminderEngine.Stop()
 
-	program := tea.NewProgram(update.NewModelWithConfig(
+	program := tea.NewProgram(update.NewModelWithRepository(
 		reminderEngine,
 		update.ExecDesktopNotifier{},
+		repo,
 		cfg,
 	))
 	if _, err := program.Run(); err != nil {
@@ -26,3 +75,27 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func openRepository(path string) (*storage.SQLiteRepository, func(), error) {
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, nil, fmt.Errorf("create database dir: %w", err)
+		}
+	}
+	// Other taskd processes may share the file; wait for their locks
+	// instead of failing with SQLITE_BUSY.
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := storage.MigrateUp(db); err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("migrate database: %w", err)
+	}
+	repo, err := storage.NewSQLiteRepository(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+	return repo, func() { _ = repo.Close() }, nil
+}
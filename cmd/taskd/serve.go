@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/cli"
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/server"
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/update"
+)
+
+// serve runs the HTTP API until SIGINT or SIGTERM.
+func serve(cfg update.RuntimeConfig, open func() (storage.Repository, error), args []string, errOut io.Writer) error {
+	fs := flag.NewFlagSet("taskd serve", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	listen := fs.String("listen", cfg.APIListen, "address to listen on")
+	token := fs.String("token", cfg.APIToken, "bearer token clients must send (default: generated)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", cli.ErrUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: serve takes no arguments", cli.ErrUsage)
+	}
+	if *token == "" {
+		generated, err := newToken()
+		if err != nil {
+			return err
+		}
+		*token = generated
+		fmt.Fprintf(errOut, "taskd: generated API token %s (set TASKD_API_TOKEN to keep one)\n", *token)
+	}
+	repo, err := open()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	engine := scheduler.NewEngine(cfg.SchedulerBuffer, scheduler.WithDeliveryPolicy(scheduler.DeliveryDrop))
+	engine.Start()
+	defer engine.Stop()
+
+	srv := server.New(repo, engine, *token, clock.Real{})
+	if err := srv.Restore(ctx); err != nil {
+		return err
+	}
+	go srv.Run(ctx)
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	httpServer := &http.Server{
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Request contexts derive from ctx so event streams end on shutdown.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	fmt.Fprintf(errOut, "taskd: serving on http://%s\n", ln.Addr())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- httpServer.Serve(ln) }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,490 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/commands"
+	"github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/when"
+)
+
+// ErrUsage marks errors caused by how taskd was invoked; they exit with 2.
+var ErrUsage = errors.New("usage")
+
+const usage = `usage: taskd [command] [flags]
+
+Without a command taskd starts the terminal UI.
+
+commands:
+  add <title> [--due when] [--at when] [--every rule] [--tag t]... [--priority p] [--energy e]
+  list [--state s[,s]] [--tag t] [--priority p] [--search q] [--limit n]
+  done <id>
+  snooze <id> <when>
+  show <id>
+  reminders [--task id] [--all]
+  today [--bucket Scheduled|Anytime|Overdue]
+  debt
+  suggest [--minutes n] [--limit n]
+  serve [--listen addr] [--token t]
+  help
+
+Every command accepts --format text|json|ndjson (--json is short for
+--format json). JSON output follows the taskd/v1 schema.
+`
+
+// Runner executes headless subcommands against a repository.
+type Runner struct {
+	repo             storage.Repository
+	open             func() (storage.Repository, error)
+	out              io.Writer
+	err              io.Writer
+	clock            clock.Clock
+	availableMinutes int
+}
+
+func NewRunner(repo storage.Repository, out, errOut io.Writer, c clock.Clock) *Runner {
+	return &Runner{repo: repo.WithSource(storage.SourceCLI), out: out, err: errOut, clock: clock.OrReal(c), availableMinutes: 60}
+}
+
+// NewLazyRunner is NewRunner for a repository that open provides on first
+// use, so help and usage errors never open or migrate the database.
+func NewLazyRunner(open func() (storage.Repository, error), out, errOut io.Writer, c clock.Clock) *Runner {
+	return &Runner{open: open, out: out, err: errOut, clock: clock.OrReal(c), availableMinutes: 60}
+}
+
+// WithAvailableMinutes sets the default window for suggest and the Today
+// snapshot, normally TASKD_PRODUCTIVITY_AVAILABLE_MINUTES.
+func (r *Runner) WithAvailableMinutes(minutes int) *Runner {
+	if minutes > 0 {
+		r.availableMinutes = minutes
+	}
+	return r
+}
+
+// Run dispatches args[0] to its subcommand.
+func (r *Runner) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", ErrUsage)
+	}
+	name, rest := args[0], args[1:]
+	switch name {
+	case "add":
+		return r.add(ctx, rest)
+	case "list", "ls":
+		return r.list(ctx, rest)
+	case "done":
+		return r.done(ctx, rest)
+	case "snooze":
+		return r.snooze(ctx, rest)
+	case "show":
+		return r.show(ctx, rest)
+	case "reminders":
+		return r.reminders(ctx, rest)
+	case "today":
+		return r.today(ctx, rest)
+	case "debt":
+		return r.debt(ctx, rest)
+	case "suggest":
+		return r.suggest(ctx, rest)
+	case "help", "-h", "--help":
+		_, err := io.WriteString(r.out, usage)
+		return err
+	default:
+		fmt.Fprint(r.err, usage)
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, name)
+	}
+}
+
+// ExitCode maps a Run error to a process exit status.
+func ExitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, ErrUsage):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (r *Runner) add(ctx context.Context, args []string) error {
+	fs := r.flagSet("add")
+	due := fs.String("due", "", "due date, e.g. fri or 2026-03-01")
+	at := fs.String("at", "", "scheduled time, e.g. tomorrow 9am")
+	every := fs.String("every", "", "recurrence: weekday, daily, weekly, month-end, Nd, Nw or an RRULE")
+	priority := fs.String("priority", "", "Low, Medium, High or Critical")
+	energy := fs.String("energy", "", "Deep, Light, Social or Low")
+	var tags stringList
+	fs.Var(&tags, "tag", "tag to attach (repeatable)")
+	outputFormat := formatFlags(fs)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+
+	// The title accepts the same inline syntax as quick-add; flags win.
+	draftArgs, err := commands.ParseQuickAdd(strings.Join(positional, " "))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+		if tag != "" && !contains(draftArgs.Tags, tag) {
+			draftArgs.Tags = append(draftArgs.Tags, tag)
+		}
+	}
+	setIf(&draftArgs.Due, *due)
+	setIf(&draftArgs.At, *at)
+	setIf(&draftArgs.Every, *every)
+	if *priority != "" {
+		p := model.Priority(titleCase(*priority))
+		if !p.IsValid() {
+			return fmt.Errorf("%w: invalid --priority %q", ErrUsage, *priority)
+		}
+		draftArgs.Priority = string(p)
+	}
+	if *energy != "" {
+		e := model.Energy(titleCase(*energy))
+		if !e.IsValid() {
+			return fmt.Errorf("%w: invalid --energy %q", ErrUsage, *energy)
+		}
+		draftArgs.Energy = string(e)
+	}
+
+	now := r.clock.Now()
+	draft, err := draftArgs.Resolve(now)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	repo, err := r.repository()
+	if err != nil {
+		return err
+	}
+	task, err := storage.CreateTaskWithRecurrence(ctx, repo, draft.Task("", now), draft.Recurrence, now)
+	if err != nil {
+		return err
+	}
+	if f != formatText {
+		return r.writeRecord(f, taskToJSON(task))
+	}
+	_, err = fmt.Fprintf(r.out, "created %s: %s\n", task.ID, task.Title)
+	return err
+}
+
+func (r *Runner) list(ctx context.Context, args []string) error {
+	fs := r.flagSet("list")
+	state := fs.String("state", "", "comma-separated states, or all (default: open tasks)")
+	tag := fs.String("tag", "", "only tasks with this tag")
+	priority := fs.String("priority", "", "only tasks with this priority")
+	search := fs.String("search", "", "substring of title or description")
+	limit := fs.Int("limit", 0, "maximum number of tasks")
+	outputFormat := formatFlags(fs)
+	if positional, err := parseInterspersed(fs, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return fmt.Errorf("%w: list takes no arguments", ErrUsage)
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+
+	filter := storage.TaskListFilter{
+		Tag:    *tag,
+		Search: *search,
+		Limit:  *limit,
+		Sort:   storage.TaskSortCreatedAsc,
+	}
+	switch s := strings.TrimSpace(*state); s {
+	case "":
+		filter.States = []string{string(model.TaskStateInbox), string(model.TaskStatePlanned), string(model.TaskStateSnoozed)}
+	case "all":
+	default:
+		for _, part := range strings.Split(s, ",") {
+			st := model.TaskState(titleCase(part))
+			if !st.IsValid() {
+				return fmt.Errorf("%w: invalid --state %q", ErrUsage, part)
+			}
+			filter.States = append(filter.States, string(st))
+		}
+	}
+	if *priority != "" {
+		filter.Priorities = []string{titleCase(*priority)}
+	}
+
+	repo, err := r.repository()
+	if err != nil {
+		return err
+	}
+	tasks, err := repo.ListTasks(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("list tasks: %w", err)
+	}
+	if f != formatText {
+		items := make([]any, 0, len(tasks))
+		for _, task := range tasks {
+			items = append(items, taskToJSON(task))
+		}
+		return r.writeList(f, KindTask, items)
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATE\tPRIORITY\tSCHEDULED\tDUE\tTITLE")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", task.ID, task.State, task.Priority,
+			r.formatTime(task.ScheduledAt), r.formatTime(task.DueAt), task.Title)
+	}
+	return tw.Flush()
+}
+
+func (r *Runner) done(ctx context.Context, args []string) error {
+	fs := r.flagSet("done")
+	outputFormat := formatFlags(fs)
+	id, _, err := parseID(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	repo, err := r.repository()
+	if err != nil {
+		return err
+	}
+	task, next, err := storage.CompleteTask(ctx, repo, id, r.clock.Now())
+	if err != nil {
+		return err
+	}
+	if f != formatText {
+		return r.writeRecord(f, taskToJSON(task))
+	}
+	if _, err := fmt.Fprintf(r.out, "done %s: %s\n", task.ID, task.Title); err != nil {
+		return err
+	}
+	if next != nil {
+		at := next.ScheduledAt
+		if at == nil {
+			at = next.DueAt
+		}
+		_, err = fmt.Fprintf(r.out, "next %s: %s\n", next.ID, r.formatTime(at))
+	}
+	return err
+}
+
+func (r *Runner) snooze(ctx context.Context, args []string) error {
+	fs := r.flagSet("snooze")
+	outputFormat := formatFlags(fs)
+	id, rest, err := parseID(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return fmt.Errorf("%w: snooze requires <id> and <when>", ErrUsage)
+	}
+	until, err := when.Parse(strings.Join(rest, " "), r.clock.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	label := until.Format("2006-01-02 15:04")
+	task, err := r.updateTask(ctx, id, func(task *storage.Task) {
+		at := until.UTC()
+		task.State = string(model.TaskStateSnoozed)
+		task.ScheduledAt = &at
+		task.CompletedAt = nil
+	})
+	if err != nil {
+		return err
+	}
+	if f != formatText {
+		return r.writeRecord(f, taskToJSON(task))
+	}
+	_, err = fmt.Fprintf(r.out, "snoozed %s until %s\n", task.ID, label)
+	return err
+}
+
+func (r *Runner) show(ctx context.Context, args []string) error {
+	fs := r.flagSet("show")
+	outputFormat := formatFlags(fs)
+	id, _, err := parseID(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	repo, err := r.repository()
+	if err != nil {
+		return err
+	}
+	task, err := repo.GetTask(ctx, id)
+	if err != nil {
+		return fmt.Errorf("load task %s: %w", id, err)
+	}
+	if f != formatText {
+		return r.writeRecord(f, taskToJSON(task))
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "id:\t%s\n", task.ID)
+	fmt.Fprintf(tw, "title:\t%s\n", task.Title)
+	fmt.Fprintf(tw, "state:\t%s\n", task.State)
+	fmt.Fprintf(tw, "priority:\t%s\n", task.Priority)
+	fmt.Fprintf(tw, "energy:\t%s\n", task.Energy)
+	fmt.Fprintf(tw, "tags:\t%s\n", strings.Join(task.Tags, ", "))
+	fmt.Fprintf(tw, "scheduled:\t%s\n", r.formatTime(task.ScheduledAt))
+	fmt.Fprintf(tw, "due:\t%s\n", r.formatTime(task.DueAt))
+	fmt.Fprintf(tw, "created:\t%s\n", r.formatTime(&task.CreatedAt))
+	fmt.Fprintf(tw, "completed:\t%s\n", r.formatTime(task.CompletedAt))
+	if task.Description != "" {
+		fmt.Fprintf(tw, "notes:\t%s\n", task.Description)
+	}
+	return tw.Flush()
+}
+
+func (r *Runner) updateTask(ctx context.Context, id string, mutate func(*storage.Task)) (storage.Task, error) {
+	var task storage.Task
+	repo, err := r.repository()
+	if err != nil {
+		return task, err
+	}
+	err = repo.WithTx(ctx, func(tx storage.Repository) error {
+		var err error
+		task, err = tx.GetTask(ctx, id)
+		if err != nil {
+			return fmt.Errorf("load task %s: %w", id, err)
+		}
+		mutate(&task)
+		if err := tx.UpdateTask(ctx, task); err != nil {
+			return fmt.Errorf("update task %s: %w", id, err)
+		}
+		return nil
+	})
+	return task, err
+}
+
+// repository returns the runner's repository, opening it on first use.
+func (r *Runner) repository() (storage.Repository, error) {
+	if r.repo == nil {
+		repo, err := r.open()
+		if err != nil {
+			return nil, err
+		}
+		r.repo = repo.WithSource(storage.SourceCLI)
+	}
+	return r.repo, nil
+}
+
+func (r *Runner) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("taskd "+name, flag.ContinueOnError)
+	fs.SetOutput(r.err)
+	return fs
+}
+
+// formatFlags registers --format and its --json shorthand on fs.
+func formatFlags(fs *flag.FlagSet) func() (format, error) {
+	raw := fs.String("format", string(formatText), "output format: text, json or ndjson")
+	asJSON := fs.Bool("json", false, "shorthand for --format json")
+	return func() (format, error) {
+		if *asJSON {
+			return formatJSON, nil
+		}
+		return parseFormat(*raw)
+	}
+}
+
+func (r *Runner) formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.In(r.clock.Now().Location()).Format("2006-01-02 15:04")
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, which flag.FlagSet alone does not allow.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0, len(args))
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %v", ErrUsage, err)
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// flag drops a "--" terminator; everything after it is positional.
+		if i := len(args) - len(rest); i > 0 && args[i-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		args = rest
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseID reads the leading task ID. want is the number of further
+// positional arguments allowed; -1 allows any.
+func parseID(fs *flag.FlagSet, args []string, want int) (string, []string, error) {
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(positional) == 0 {
+		return "", nil, fmt.Errorf("%w: %s requires a task id", ErrUsage, strings.TrimPrefix(fs.Name(), "taskd "))
+	}
+	if want >= 0 && len(positional)-1 > want {
+		return "", nil, fmt.Errorf("%w: unexpected arguments %q", ErrUsage, positional[1:])
+	}
+	return positional[0], positional[1:], nil
+}
+
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func setIf(dst *string, v string) {
+	if v = strings.TrimSpace(v); v != "" {
+		*dst = v
+	}
+}
+
+func titleCase(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func contains(items []string, want string) bool {
+	for _, item := range items {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
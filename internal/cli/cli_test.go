@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func setupRunner(t *testing.T) (*Runner, *storage.SQLiteRepository, *bytes.Buffer) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "taskd-cli.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo, err := storage.NewSQLiteRepository(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	out := &bytes.Buffer{}
+	fake := clock.NewFake(time.Date(2026, 2, 11, 14, 0, 0, 0, time.UTC))
+	return NewRunner(repo, out, &bytes.Buffer{}, fake), repo, out
+}
+
+func TestAddListDoneSnoozeShow(t *testing.T) {
+	r, repo, out := setupRunner(t)
+	ctx := context.Background()
+
+	if err := r.Run(ctx, []string{"add", "pay rent #home", "--due", "fri", "--tag", "finance", "--priority", "high", "--json"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	var created taskJSON
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		t.Fatalf("decode add output %q: %v", out.String(), err)
+	}
+	if created.Schema != SchemaVersion || created.Kind != KindTask {
+		t.Fatalf("expected a self-describing task record, got %+v", created.header)
+	}
+	if created.Title != "pay rent" || created.Priority != "High" || strings.Join(created.Tags, ",") != "home,finance" {
+		t.Fatalf("unexpected created task: %+v", created)
+	}
+	if created.DueAt == nil || !created.DueAt.Equal(time.Date(2026, 2, 13, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected due_at: %v", created.DueAt)
+	}
+	if err := r.Run(ctx, []string{"add", "water plants", "--every", "3d"}); err != nil {
+		t.Fatalf("add recurring: %v", err)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"done", created.ID}); err != nil {
+		t.Fatalf("done: %v", err)
+	}
+	stored, err := repo.GetTask(ctx, created.ID)
+	if err != nil || stored.State != "Done" || stored.CompletedAt == nil {
+		t.Fatalf("expected task done, got %#v, %v", stored, err)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"list", "--json"}); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var list struct {
+		Schema string     `json:"schema"`
+		Kind   string     `json:"kind"`
+		Items  []taskJSON `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+		t.Fatalf("decode list output: %v", err)
+	}
+	open := list.Items
+	if list.Schema != SchemaVersion || list.Kind != "task_list" || len(open) != 1 || open[0].Title != "water plants" {
+		t.Fatalf("expected only the open task, got %+v", list)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"snooze", open[0].ID, "2h"}); err != nil {
+		t.Fatalf("snooze: %v", err)
+	}
+	stored, err = repo.GetTask(ctx, open[0].ID)
+	if err != nil || stored.State != "Snoozed" || stored.ScheduledAt == nil || !stored.ScheduledAt.Equal(time.Date(2026, 2, 11, 16, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected task snoozed two hours, got %#v, %v", stored, err)
+	}
+	if stored.Description != "" {
+		t.Fatalf("expected snooze to leave the notes alone, got %q", stored.Description)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"list", "--state", "done,snoozed"}); err != nil {
+		t.Fatalf("list states: %v", err)
+	}
+	if !strings.Contains(out.String(), "pay rent") || !strings.Contains(out.String(), "water plants") {
+		t.Fatalf("unexpected list output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"show", created.ID}); err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	if !strings.Contains(out.String(), "state:      Done") || !strings.Contains(out.String(), "2026-02-13 09:00") {
+		t.Fatalf("unexpected show output:\n%s", out.String())
+	}
+}
+
+func TestRunReportsUsageAndMissingTasks(t *testing.T) {
+	r, _, _ := setupRunner(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"frobnicate"}, 2},
+		{[]string{"add"}, 2},
+		{[]string{"add", "call bank", "--due", "next fri"}, 2},
+		{[]string{"list", "--state", "Someday"}, 2},
+		{[]string{"snooze", "task-1"}, 2},
+		{[]string{"done"}, 2},
+		{[]string{"show", "task-missing"}, 1},
+		{[]string{"add", "-h"}, 0},
+		{[]string{"list", "--format", "yaml"}, 2},
+		{[]string{"today", "--bucket", "later"}, 2},
+		{[]string{"suggest", "--minutes", "0"}, 2},
+	}
+	for _, tc := range cases {
+		if got := ExitCode(r.Run(ctx, tc.args)); got != tc.code {
+			t.Fatalf("taskd %s exit = %d, want %d", strings.Join(tc.args, " "), got, tc.code)
+		}
+	}
+	if err := r.Run(ctx, []string{"show", "task-missing"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestHelpAndUsageNeverOpenTheDatabase(t *testing.T) {
+	ctx := context.Background()
+	opened := false
+	r := NewLazyRunner(func() (storage.Repository, error) {
+		opened = true
+		return nil, errors.New("database unavailable")
+	}, &bytes.Buffer{}, &bytes.Buffer{}, nil)
+
+	for _, args := range [][]string{{"help"}, {"add", "-h"}, {"list", "--bogus"}, {"done"}, {"today", "--bucket", "later"}} {
+		if code := ExitCode(r.Run(ctx, args)); code == 1 {
+			t.Fatalf("taskd %s failed like a runtime error", strings.Join(args, " "))
+		}
+	}
+	if opened {
+		t.Fatal("expected help and usage errors to leave the database closed")
+	}
+	if err := r.Run(ctx, []string{"list"}); err == nil || !opened {
+		t.Fatalf("expected list to open the database, got %v", err)
+	}
+}
+
+func TestDoubleDashEndsFlags(t *testing.T) {
+	r, _, out := setupRunner(t)
+	ctx := context.Background()
+
+	if err := r.Run(ctx, []string{"add", "--priority", "high", "--", "fix", "--json", "-h", "parsing"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, ": fix --json -h parsing\n") {
+		t.Fatalf("expected the words after -- in the title, got %q", got)
+	}
+}
+
+func TestDoneCreatesNextOccurrence(t *testing.T) {
+	r, repo, out := setupRunner(t)
+	ctx := context.Background()
+
+	if err := r.Run(ctx, []string{"add", "water plants #home", "--every", "3d", "--json"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	var created taskJSON
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		t.Fatalf("decode add output %q: %v", out.String(), err)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"done", created.ID}); err != nil {
+		t.Fatalf("done: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "next task-") || !strings.HasSuffix(lines[1], ": 2026-02-14 14:00") {
+		t.Fatalf("expected the next occurrence three days on:\n%s", out.String())
+	}
+	nextID := strings.TrimSuffix(strings.TrimPrefix(lines[1], "next "), ": 2026-02-14 14:00")
+	next, err := repo.GetTask(ctx, nextID)
+	if err != nil || next.Title != "water plants" || next.State != "Planned" || strings.Join(next.Tags, ",") != "home" {
+		t.Fatalf("expected a planned copy of the task, got %#v, %v", next, err)
+	}
+	rules, err := repo.ListRecurrences(ctx, storage.RecurrenceListFilter{TaskID: nextID})
+	if err != nil || len(rules) != 1 || rules[0].NextAt == nil || !rules[0].NextAt.Equal(*next.ScheduledAt) {
+		t.Fatalf("expected the rule to follow the next occurrence, got %+v, %v", rules, err)
+	}
+	if done, _ := repo.GetTask(ctx, created.ID); done.State != "Done" {
+		t.Fatalf("expected the completed instance to stay done, got %#v", done)
+	}
+}
@@ -0,0 +1,3 @@
+// Package cli implements taskd's headless subcommands for scripts, git hooks
+// and cron.
+package cli
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/update"
+)
+
+// SchemaVersion names the layout of every JSON and NDJSON object taskd
+// prints. Fields may be added within a version; renaming, removing or
+// retyping one bumps it. docs/JSON_SCHEMA.md describes each kind.
+const SchemaVersion = "taskd/v1"
+
+// Record kinds, carried in the "kind" field of every object.
+const (
+	KindTask         = "task"
+	KindReminder     = "reminder"
+	KindTodayItem    = "today_item"
+	KindTemporalDebt = "temporal_debt"
+	KindSuggestion   = "suggestion"
+)
+
+type format string
+
+const (
+	formatText   format = "text"
+	formatJSON   format = "json"
+	formatNDJSON format = "ndjson"
+)
+
+func parseFormat(raw string) (format, error) {
+	switch f := format(raw); f {
+	case formatText, formatJSON, formatNDJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: invalid --format %q (use text, json or ndjson)", ErrUsage, raw)
+	}
+}
+
+// header makes every record self-describing, including NDJSON lines read in
+// isolation.
+type header struct {
+	Schema string `json:"schema"`
+	Kind   string `json:"kind"`
+}
+
+func newHeader(kind string) header { return header{Schema: SchemaVersion, Kind: kind} }
+
+// listJSON wraps the records of a list command in --format json.
+type listJSON struct {
+	Schema      string    `json:"schema"`
+	Kind        string    `json:"kind"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Items       []any     `json:"items"`
+}
+
+type taskJSON struct {
+	header
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	State       string     `json:"state"`
+	Priority    string     `json:"priority"`
+	Energy      string     `json:"energy"`
+	Tags        []string   `json:"tags"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func taskToJSON(task storage.Task) taskJSON {
+	return taskJSON{
+		header:      newHeader(KindTask),
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		State:       task.State,
+		Priority:    task.Priority,
+		Energy:      task.Energy,
+		Tags:        nonNil(task.Tags),
+		ScheduledAt: task.ScheduledAt,
+		DueAt:       task.DueAt,
+		CreatedAt:   task.CreatedAt,
+		CompletedAt: task.CompletedAt,
+	}
+}
+
+type reminderJSON struct {
+	header
+	ID         string     `json:"id"`
+	TaskID     string     `json:"task_id"`
+	TaskTitle  string     `json:"task_title,omitempty"`
+	Type       string     `json:"type"`
+	TriggerAt  time.Time  `json:"trigger_at"`
+	RepeatRule string     `json:"repeat_rule,omitempty"`
+	LastFired  *time.Time `json:"last_fired,omitempty"`
+	Enabled    bool       `json:"enabled"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func reminderToJSON(rem storage.Reminder, title string) reminderJSON {
+	return reminderJSON{
+		header:     newHeader(KindReminder),
+		ID:         rem.ID,
+		TaskID:     rem.TaskID,
+		TaskTitle:  title,
+		Type:       rem.Type,
+		TriggerAt:  rem.TriggerAt,
+		RepeatRule: rem.RepeatRule,
+		LastFired:  rem.LastFired,
+		Enabled:    rem.Enabled,
+		CreatedAt:  rem.CreatedAt,
+	}
+}
+
+// todayItemJSON is a Today row with the timestamps of its task rather than
+// the "15:04" and "tomorrow" labels the TUI renders.
+type todayItemJSON struct {
+	header
+	Bucket      string     `json:"bucket"`
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	State       string     `json:"state"`
+	Priority    string     `json:"priority"`
+	Energy      string     `json:"energy"`
+	Tags        []string   `json:"tags"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+}
+
+func todayItemToJSON(item update.TodayItem, task storage.Task) todayItemJSON {
+	return todayItemJSON{
+		header:      newHeader(KindTodayItem),
+		Bucket:      string(item.Bucket),
+		ID:          item.ID,
+		Title:       item.Title,
+		State:       task.State,
+		Priority:    item.Priority,
+		Energy:      task.Energy,
+		Tags:        nonNil(item.Tags),
+		ScheduledAt: task.ScheduledAt,
+		DueAt:       task.DueAt,
+		Notes:       item.Notes,
+	}
+}
+
+type temporalDebtJSON struct {
+	header
+	Score int    `json:"score"`
+	Max   int    `json:"max"`
+	Label string `json:"label"`
+}
+
+type suggestionJSON struct {
+	header
+	TaskID        string `json:"task_id"`
+	Title         string `json:"title"`
+	Reason        string `json:"reason"`
+	Energy        string `json:"energy"`
+	Minutes       int    `json:"minutes"`
+	WindowMinutes int    `json:"window_minutes"`
+}
+
+func suggestionToJSON(s update.Suggestion, window int) suggestionJSON {
+	return suggestionJSON{
+		header:        newHeader(KindSuggestion),
+		TaskID:        s.TaskID,
+		Title:         s.Title,
+		Reason:        s.Reason,
+		Energy:        s.Energy,
+		Minutes:       s.Minutes,
+		WindowMinutes: window,
+	}
+}
+
+// writeRecord prints a single record as indented JSON or one NDJSON line.
+func (r *Runner) writeRecord(f format, v any) error {
+	enc := json.NewEncoder(r.out)
+	if f == formatJSON {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// writeList prints records wrapped in a listJSON for json, or one per line
+// for ndjson.
+func (r *Runner) writeList(f format, kind string, items []any) error {
+	if f == formatNDJSON {
+		enc := json.NewEncoder(r.out)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if items == nil {
+		items = []any{}
+	}
+	return r.writeRecord(f, listJSON{
+		Schema:      SchemaVersion,
+		Kind:        kind + "_list",
+		GeneratedAt: r.clock.Now().UTC(),
+		Items:       items,
+	})
+}
+
+func nonNil(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/update"
+)
+
+// maxTemporalDebt is the ceiling update applies to the temporal debt score.
+const maxTemporalDebt = 10
+
+func (r *Runner) reminders(ctx context.Context, args []string) error {
+	fs := r.flagSet("reminders")
+	taskID := fs.String("task", "", "only reminders for this task")
+	all := fs.Bool("all", false, "include disabled reminders")
+	outputFormat := formatFlags(fs)
+	if positional, err := parseInterspersed(fs, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return fmt.Errorf("%w: reminders takes no arguments", ErrUsage)
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+
+	filter := storage.ReminderListFilter{TaskID: *taskID}
+	if !*all {
+		enabled := true
+		filter.Enabled = &enabled
+	}
+	repo, err := r.repository()
+	if err != nil {
+		return err
+	}
+	reminders, err := repo.ListReminders(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("list reminders: %w", err)
+	}
+	tasks, err := r.tasksByID(ctx)
+	if err != nil {
+		return err
+	}
+	if f != formatText {
+		items := make([]any, 0, len(reminders))
+		for _, rem := range reminders {
+			items = append(items, reminderToJSON(rem, tasks[rem.TaskID].Title))
+		}
+		return r.writeList(f, KindReminder, items)
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tTRIGGER\tENABLED\tTASK")
+	for _, rem := range reminders {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\n", rem.ID, rem.Type, r.formatTime(&rem.TriggerAt), rem.Enabled, tasks[rem.TaskID].Title)
+	}
+	return tw.Flush()
+}
+
+func (r *Runner) today(ctx context.Context, args []string) error {
+	fs := r.flagSet("today")
+	bucket := fs.String("bucket", "", "only Scheduled, Anytime or Overdue")
+	outputFormat := formatFlags(fs)
+	if positional, err := parseInterspersed(fs, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return fmt.Errorf("%w: today takes no arguments", ErrUsage)
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	want := update.TodayBucket(titleCase(*bucket))
+	switch want {
+	case "", update.TodayBucketScheduled, update.TodayBucketAnytime, update.TodayBucketOverdue:
+	default:
+		return fmt.Errorf("%w: invalid --bucket %q", ErrUsage, *bucket)
+	}
+
+	snap, err := r.snapshot(r.availableMinutes, 0)
+	if err != nil {
+		return err
+	}
+	tasks, err := r.tasksByID(ctx)
+	if err != nil {
+		return err
+	}
+	items := make([]update.TodayItem, 0, len(snap.Today))
+	for _, item := range snap.Today {
+		if want == "" || item.Bucket == want {
+			items = append(items, item)
+		}
+	}
+	if f != formatText {
+		records := make([]any, 0, len(items))
+		for _, item := range items {
+			records = append(records, todayItemToJSON(item, tasks[item.ID]))
+		}
+		return r.writeList(f, KindTodayItem, records)
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "BUCKET\tID\tPRIORITY\tSCHEDULED\tDUE\tTITLE")
+	for _, item := range items {
+		task := tasks[item.ID]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", item.Bucket, item.ID, item.Priority,
+			r.formatTime(task.ScheduledAt), r.formatTime(task.DueAt), item.Title)
+	}
+	return tw.Flush()
+}
+
+func (r *Runner) debt(_ context.Context, args []string) error {
+	fs := r.flagSet("debt")
+	outputFormat := formatFlags(fs)
+	if positional, err := parseInterspersed(fs, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return fmt.Errorf("%w: debt takes no arguments", ErrUsage)
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	snap, err := r.snapshot(r.availableMinutes, 0)
+	if err != nil {
+		return err
+	}
+	if f != formatText {
+		return r.writeRecord(f, temporalDebtJSON{
+			header: newHeader(KindTemporalDebt),
+			Score:  snap.Signals.TemporalDebtScore,
+			Max:    maxTemporalDebt,
+			Label:  snap.Signals.TemporalDebtLabel,
+		})
+	}
+	_, err = fmt.Fprintf(r.out, "temporal debt: %d/%d (%s)\n", snap.Signals.TemporalDebtScore, maxTemporalDebt, snap.Signals.TemporalDebtLabel)
+	return err
+}
+
+func (r *Runner) suggest(_ context.Context, args []string) error {
+	fs := r.flagSet("suggest")
+	minutes := fs.Int("minutes", r.availableMinutes, "length of the available window")
+	limit := fs.Int("limit", 3, "maximum number of suggestions")
+	outputFormat := formatFlags(fs)
+	if positional, err := parseInterspersed(fs, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return fmt.Errorf("%w: suggest takes no arguments", ErrUsage)
+	}
+	f, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if *minutes <= 0 || *limit <= 0 {
+		return fmt.Errorf("%w: --minutes and --limit must be positive", ErrUsage)
+	}
+	snap, err := r.snapshot(*minutes, *limit)
+	if err != nil {
+		return err
+	}
+	suggestions := snap.Signals.Suggestions
+	if f != formatText {
+		items := make([]any, 0, len(suggestions))
+		for _, s := range suggestions {
+			items = append(items, suggestionToJSON(s, *minutes))
+		}
+		return r.writeList(f, KindSuggestion, items)
+	}
+	if len(suggestions) == 0 {
+		_, err := fmt.Fprintf(r.out, "nothing fits a %d-minute window\n", *minutes)
+		return err
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tENERGY\tMINUTES\tTITLE\tREASON")
+	for _, s := range suggestions {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", s.TaskID, s.Energy, s.Minutes, s.Title, s.Reason)
+	}
+	return tw.Flush()
+}
+
+func (r *Runner) snapshot(availableMinutes, limit int) (update.Snapshot, error) {
+	repo, err := r.repository()
+	if err != nil {
+		return update.Snapshot{}, err
+	}
+	snap, err := update.LoadSnapshot(repo, r.clock.Now(), availableMinutes, limit)
+	if err != nil {
+		return update.Snapshot{}, fmt.Errorf("load today: %w", err)
+	}
+	return snap, nil
+}
+
+func (r *Runner) tasksByID(ctx context.Context) (map[string]storage.Task, error) {
+	repo, err := r.repository()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := repo.ListTasks(ctx, storage.TaskListFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list tasks: %w", err)
+	}
+	byID := make(map[string]storage.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	return byID, nil
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func seedTodayTasks(t *testing.T, repo *storage.SQLiteRepository) {
+	t.Helper()
+	ctx := context.Background()
+	at := func(day, hour int) *time.Time {
+		v := time.Date(2026, 2, day, hour, 0, 0, 0, time.UTC)
+		return &v
+	}
+	created := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+	tasks := []storage.Task{
+		{ID: "task-over", Title: "file taxes", State: "Planned", Priority: "Critical", Energy: "Low", DueAt: at(10, 9), CreatedAt: created},
+		{ID: "task-sched", Title: "team meeting", State: "Planned", Priority: "Medium", Energy: "Social", ScheduledAt: at(11, 16), CreatedAt: created.Add(time.Minute)},
+		{ID: "task-any", Title: "write docs", State: "Planned", Priority: "Low", Energy: "Light", Tags: []string{"work"}, CreatedAt: created.Add(2 * time.Minute)},
+		{ID: "task-inbox", Title: "idea", State: "Inbox", Priority: "Low", Energy: "Deep", CreatedAt: created.Add(3 * time.Minute)},
+	}
+	for _, task := range tasks {
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("create %s: %v", task.ID, err)
+		}
+	}
+	reminders := []storage.Reminder{
+		{ID: "rem-on", TaskID: "task-sched", TriggerAt: *at(11, 15), Type: "Hard", Enabled: true, CreatedAt: created},
+		{ID: "rem-off", TaskID: "task-any", TriggerAt: *at(12, 9), Type: "Soft", Enabled: false, CreatedAt: created},
+	}
+	for _, rem := range reminders {
+		if err := repo.CreateReminder(ctx, rem); err != nil {
+			t.Fatalf("create %s: %v", rem.ID, err)
+		}
+	}
+}
+
+// decodeNDJSON decodes each line into a generic object and checks the header.
+func decodeNDJSON(t *testing.T, raw string, kind string) []map[string]any {
+	t.Helper()
+	out := make([]map[string]any, 0)
+	sc := bufio.NewScanner(strings.NewReader(raw))
+	for sc.Scan() {
+		var rec map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("decode line %q: %v", sc.Text(), err)
+		}
+		if rec["schema"] != SchemaVersion || rec["kind"] != kind {
+			t.Fatalf("unexpected record header: %v", rec)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestTodayMatchesTUIBuckets(t *testing.T) {
+	r, repo, out := setupRunner(t)
+	seedTodayTasks(t, repo)
+
+	if err := r.Run(context.Background(), []string{"today", "--format", "ndjson"}); err != nil {
+		t.Fatalf("today: %v", err)
+	}
+	records := decodeNDJSON(t, out.String(), KindTodayItem)
+	got := make([]string, 0, len(records))
+	for _, rec := range records {
+		got = append(got, rec["bucket"].(string)+":"+rec["id"].(string))
+	}
+	want := "Scheduled:task-sched,Anytime:task-any,Overdue:task-over"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("today buckets = %v, want %s", got, want)
+	}
+	if records[0]["scheduled_at"] != "2026-02-11T16:00:00Z" || records[2]["due_at"] != "2026-02-10T09:00:00Z" {
+		t.Fatalf("expected RFC 3339 timestamps, got %v and %v", records[0], records[2])
+	}
+
+	out.Reset()
+	if err := r.Run(context.Background(), []string{"today", "--bucket", "overdue", "--json"}); err != nil {
+		t.Fatalf("today overdue: %v", err)
+	}
+	var list struct {
+		Kind        string          `json:"kind"`
+		GeneratedAt time.Time       `json:"generated_at"`
+		Items       []todayItemJSON `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+		t.Fatalf("decode today: %v", err)
+	}
+	if list.Kind != "today_item_list" || len(list.Items) != 1 || list.Items[0].ID != "task-over" {
+		t.Fatalf("unexpected overdue list: %+v", list)
+	}
+	if !list.GeneratedAt.Equal(time.Date(2026, 2, 11, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("generated_at should come from the clock, got %v", list.GeneratedAt)
+	}
+}
+
+func TestDebtAndSuggestions(t *testing.T) {
+	r, repo, out := setupRunner(t)
+	seedTodayTasks(t, repo)
+	ctx := context.Background()
+
+	if err := r.Run(ctx, []string{"debt", "--json"}); err != nil {
+		t.Fatalf("debt: %v", err)
+	}
+	var debt temporalDebtJSON
+	if err := json.Unmarshal(out.Bytes(), &debt); err != nil {
+		t.Fatalf("decode debt: %v", err)
+	}
+	// Overdue (+2) and critical while overdue (+1).
+	if debt.Kind != KindTemporalDebt || debt.Score != 3 || debt.Max != 10 || debt.Label != "low" {
+		t.Fatalf("unexpected debt: %+v", debt)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"suggest", "--minutes", "30", "--format", "ndjson"}); err != nil {
+		t.Fatalf("suggest: %v", err)
+	}
+	records := decodeNDJSON(t, out.String(), KindSuggestion)
+	if len(records) != 2 || records[0]["task_id"] != "task-any" || records[1]["task_id"] != "task-over" {
+		t.Fatalf("unexpected suggestions: %v", records)
+	}
+	if records[1]["reason"] != "overdue and still feasible now" || records[1]["window_minutes"] != float64(30) {
+		t.Fatalf("unexpected overdue suggestion: %v", records[1])
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"suggest", "--limit", "1"}); err != nil {
+		t.Fatalf("suggest text: %v", err)
+	}
+	if !strings.Contains(out.String(), "team meeting") || strings.Contains(out.String(), "write docs") {
+		t.Fatalf("unexpected suggest output:\n%s", out.String())
+	}
+}
+
+func TestRemindersListing(t *testing.T) {
+	r, repo, out := setupRunner(t)
+	seedTodayTasks(t, repo)
+	ctx := context.Background()
+
+	if err := r.Run(ctx, []string{"reminders", "--format", "ndjson"}); err != nil {
+		t.Fatalf("reminders: %v", err)
+	}
+	records := decodeNDJSON(t, out.String(), KindReminder)
+	if len(records) != 1 || records[0]["id"] != "rem-on" || records[0]["task_title"] != "team meeting" {
+		t.Fatalf("expected only the enabled reminder, got %v", records)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"reminders", "--all", "--task", "task-any", "--json"}); err != nil {
+		t.Fatalf("reminders all: %v", err)
+	}
+	var list struct {
+		Items []reminderJSON `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+		t.Fatalf("decode reminders: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != "rem-off" || list.Items[0].Enabled {
+		t.Fatalf("unexpected reminders: %+v", list.Items)
+	}
+
+	out.Reset()
+	if err := r.Run(ctx, []string{"reminders", "--task", "task-inbox", "--json"}); err != nil {
+		t.Fatalf("reminders empty: %v", err)
+	}
+	if !strings.Contains(out.String(), `"items": []`) {
+		t.Fatalf("empty lists should encode as [], got:\n%s", out.String())
+	}
+}
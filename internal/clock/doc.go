@@ -0,0 +1,3 @@
+// Package clock abstracts wall-clock time so timing code can be driven by a
+// fake clock in tests.
+package clock
@@ -0,0 +1,59 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeFiresTimersInOrderOnAdvance(t *testing.T) {
+	start := time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	early := c.NewTimer(time.Minute)
+	late := c.NewTimer(2 * time.Minute)
+	stopped := c.NewTimer(30 * time.Second)
+	if !stopped.Stop() || c.Timers() != 2 {
+		t.Fatalf("expected stop to disarm timer, %d timers left", c.Timers())
+	}
+
+	c.Advance(90 * time.Second)
+	select {
+	case at := <-early.C():
+		if !at.Equal(start.Add(90 * time.Second)) {
+			t.Fatalf("unexpected fire time %s", at)
+		}
+	default:
+		t.Fatal("expected early timer to fire")
+	}
+	select {
+	case <-late.C():
+		t.Fatal("late timer fired too soon")
+	case <-stopped.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	late.Reset(time.Second)
+	c.Advance(time.Second)
+	if _, ok := <-late.C(); !ok || c.Timers() != 0 {
+		t.Fatalf("expected reset timer to fire, %d timers left", c.Timers())
+	}
+	if got := c.Now(); !got.Equal(start.Add(91 * time.Second)) {
+		t.Fatalf("unexpected now %s", got)
+	}
+}
+
+func TestFakeBlockUntilWaitsForTimer(t *testing.T) {
+	c := NewFake(time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC))
+	done := make(chan struct{})
+	go func() {
+		<-c.After(time.Hour)
+		close(done)
+	}()
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("After channel did not fire")
+	}
+}
@@ -12,6 +12,9 @@ const (
 	TypeSnooze     Type = "snooze"
 	TypeShow       Type = "show"
 	TypeReschedule Type = "reschedule"
+	TypeFind       Type = "find"
+	TypeUndo       Type = "undo"
+	TypeRedo       Type = "redo"
 )
 
 type ErrorCode string
@@ -33,7 +36,15 @@ func (e *CommandError) Error() string {
 }
 
 type AddArgs struct {
-	Title string
+	Title    string
+	Tags     []string
+	Priority string
+	Energy   string
+	// Due, At and Every hold the raw due:, at: and every: phrases; they are
+	// resolved by the handler, which knows the clock and timezone.
+	Due   string
+	At    string
+	Every string
 }
 
 type SnoozeArgs struct {
@@ -49,6 +60,13 @@ type ShowArgs struct {
 type RescheduleArgs struct {
 	Target string
 	When   string
+	// Due sets the due date instead of the scheduled time
+	// ("reschedule selected due fri").
+	Due bool
+}
+
+type FindArgs struct {
+	Query string
 }
 
 type Command struct {
@@ -58,6 +76,7 @@ type Command struct {
 	Snooze     *SnoozeArgs
 	Show       *ShowArgs
 	Reschedule *RescheduleArgs
+	Find       *FindArgs
 }
 
 func Parse(input string) (Command, error) {
@@ -85,6 +104,13 @@ func Parse(input string) (Command, error) {
 		return parseShow(input, args)
 	case TypeReschedule:
 		return parseReschedule(input, args)
+	case TypeFind:
+		return parseFind(input, args)
+	case TypeUndo, TypeRedo:
+		if len(args) > 0 {
+			return Command{}, &CommandError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("%s takes no arguments", head)}
+		}
+		return Command{Type: Type(head), Raw: input}, nil
 	default:
 		return Command{}, &CommandError{Code: ErrCodeUnknownCommand, Message: fmt.Sprintf("unsupported command: %s", head)}
 	}
@@ -94,11 +120,14 @@ func parseAdd(raw string, args []string) (Command, error) {
 	if len(args) == 0 {
 		return Command{}, &CommandError{Code: ErrCodeInvalidArgument, Message: "add requires a title"}
 	}
-	title := strings.TrimSpace(strings.Join(args, " "))
-	if title == "" {
+	add, err := ParseQuickAdd(strings.Join(args, " "))
+	if err != nil {
+		return Command{}, err
+	}
+	if add.Title == "" {
 		return Command{}, &CommandError{Code: ErrCodeInvalidArgument, Message: "add requires a title"}
 	}
-	return Command{Type: TypeAdd, Raw: raw, Add: &AddArgs{Title: title}}, nil
+	return Command{Type: TypeAdd, Raw: raw, Add: &add}, nil
 }
 
 func parseSnooze(raw string, args []string) (Command, error) {
@@ -126,5 +155,20 @@ func parseReschedule(raw string, args []string) (Command, error) {
 	if len(args) < 2 {
 		return Command{}, &CommandError{Code: ErrCodeInvalidArgument, Message: "reschedule requires target and time"}
 	}
-	return Command{Type: TypeReschedule, Raw: raw, Reschedule: &RescheduleArgs{Target: strings.ToLower(args[0]), When: strings.Join(args[1:], " ")}}, nil
+	due := strings.EqualFold(args[1], "due")
+	when := args[1:]
+	if due {
+		when = args[2:]
+	}
+	if len(when) == 0 {
+		return Command{}, &CommandError{Code: ErrCodeInvalidArgument, Message: "reschedule requires target and time"}
+	}
+	return Command{Type: TypeReschedule, Raw: raw, Reschedule: &RescheduleArgs{Target: strings.ToLower(args[0]), When: strings.Join(when, " "), Due: due}}, nil
+}
+
+func parseFind(raw string, args []string) (Command, error) {
+	if len(args) == 0 {
+		return Command{}, &CommandError{Code: ErrCodeInvalidArgument, Message: "find requires a query"}
+	}
+	return Command{Type: TypeFind, Raw: raw, Find: &FindArgs{Query: strings.Join(args, " ")}}, nil
 }
@@ -2,7 +2,11 @@ package commands
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/model"
 )
 
 func TestParseSupportedCommands(t *testing.T) {
@@ -14,6 +18,9 @@ func TestParseSupportedCommands(t *testing.T) {
 		{"snooze overdue 2 days", TypeSnooze},
 		{"show tasks tag:finance", TypeShow},
 		{"reschedule selected next monday", TypeReschedule},
+		{"find quarterly invoice", TypeFind},
+		{"/undo", TypeUndo},
+		{"REDO", TypeRedo},
 	}
 
 	for _, tc := range cases {
@@ -76,3 +83,84 @@ func TestExecuteMissingHandler(t *testing.T) {
 		t.Fatalf("expected missing handler error, got %v", err)
 	}
 }
+
+func TestParseRescheduleDue(t *testing.T) {
+	cmd, err := Parse("reschedule selected due fri 17:30")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if !cmd.Reschedule.Due || cmd.Reschedule.When != "fri 17:30" {
+		t.Fatalf("unexpected reschedule args: %+v", cmd.Reschedule)
+	}
+	if _, err := Parse("reschedule selected due"); err == nil {
+		t.Fatal("expected error when due has no time")
+	}
+}
+
+func TestParseQuickAddExtractsMetadata(t *testing.T) {
+	got, err := ParseQuickAdd(`pay rent #Finance #home !! @deep due:"next monday" at:9am every:weekday to @alice`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if got.Title != "pay rent to @alice" {
+		t.Fatalf("unexpected title: %q", got.Title)
+	}
+	if strings.Join(got.Tags, ",") != "finance,home" || got.Priority != "High" || got.Energy != "Deep" {
+		t.Fatalf("unexpected metadata: %+v", got)
+	}
+	if got.Due != "next monday" || got.At != "9am" || got.Every != "weekday" {
+		t.Fatalf("unexpected phrases: %+v", got)
+	}
+
+	for _, in := range []string{"call mom !urgent", "call mom due:"} {
+		var ce *CommandError
+		if _, err := ParseQuickAdd(in); !errors.As(err, &ce) || ce.Code != ErrCodeInvalidArgument {
+			t.Fatalf("ParseQuickAdd(%q) error = %v, want invalid argument", in, err)
+		}
+	}
+
+	cmd, err := Parse("/add pay rent tomorrow !low")
+	if err != nil || cmd.Add.Title != "pay rent tomorrow" || cmd.Add.Priority != "Low" {
+		t.Fatalf("unexpected add command: %+v, %v", cmd.Add, err)
+	}
+	if _, err := Parse("/add #finance !!"); err == nil {
+		t.Fatal("expected error for add without a title")
+	}
+}
+
+func TestQuickAddEveryAcceptsRRule(t *testing.T) {
+	now := time.Date(2026, 2, 9, 8, 0, 0, 0, time.UTC)
+	args, err := ParseQuickAdd("board review at:9am every:FREQ=MONTHLY;BYDAY=2TU;COUNT=10")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	draft, err := args.Resolve(now)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	rule := draft.Recurrence
+	if rule == nil || rule.Type != model.RecurrenceRRule || rule.RRule != "FREQ=MONTHLY;BYDAY=2TU;COUNT=10" {
+		t.Fatalf("unexpected recurrence: %+v", rule)
+	}
+	next, err := rule.NextAfter(now, nil)
+	if err != nil || !next.Equal(time.Date(2026, 2, 10, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the second Tuesday at 09:00, got %s, %v", next, err)
+	}
+	if !strings.Contains(draft.Preview(), "every: FREQ=MONTHLY;BYDAY=2TU;COUNT=10") {
+		t.Fatalf("unexpected preview: %s", draft.Preview())
+	}
+
+	args.Every = "FREQ=MONTHLY;BYDAY=2XX"
+	var ce *CommandError
+	if _, err := args.Resolve(now); !errors.As(err, &ce) || ce.Code != ErrCodeInvalidArgument {
+		t.Fatalf("expected an invalid argument for a bad rule, got %v", err)
+	}
+}
+
+func TestParseUndoRejectsArguments(t *testing.T) {
+	_, err := Parse("undo twice")
+	var ce *CommandError
+	if !errors.As(err, &ce) || ce.Code != ErrCodeInvalidArgument {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/when"
+)
+
+// Draft is an AddArgs with its due:, at: and every: phrases resolved.
+type Draft struct {
+	Args        AddArgs
+	DueAt       *time.Time
+	ScheduledAt *time.Time
+	Recurrence  *model.RecurrenceRule
+}
+
+// Resolve interprets the phrases in a relative to now, in now's location.
+func (a AddArgs) Resolve(now time.Time) (Draft, error) {
+	d := Draft{Args: a}
+	if strings.TrimSpace(a.Title) == "" {
+		return d, &CommandError{Code: ErrCodeInvalidArgument, Message: "add requires a title"}
+	}
+	if a.Due != "" {
+		at, err := when.Parse(a.Due, now)
+		if err != nil {
+			return d, &CommandError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("due: %v", err)}
+		}
+		d.DueAt = &at
+	}
+	if a.At != "" {
+		at, err := when.Parse(a.At, now)
+		if err != nil {
+			return d, &CommandError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("at: %v", err)}
+		}
+		d.ScheduledAt = &at
+	}
+	if a.Every != "" {
+		anchor := now
+		switch {
+		case d.ScheduledAt != nil:
+			anchor = *d.ScheduledAt
+		case d.DueAt != nil:
+			anchor = *d.DueAt
+		}
+		rule, err := recurrenceFromEvery(a.Every, anchor)
+		if err != nil {
+			return d, &CommandError{Code: ErrCodeInvalidArgument, Message: err.Error()}
+		}
+		d.Recurrence = &rule
+	}
+	return d, nil
+}
+
+// recurrenceFromEvery builds the rule for an every: value: a keyword, or an
+// RRULE such as "FREQ=MONTHLY;BYDAY=2TU" with an optional "RRULE:" prefix.
+func recurrenceFromEvery(raw string, anchor time.Time) (model.RecurrenceRule, error) {
+	upper := strings.ToUpper(strings.TrimSpace(raw))
+	if strings.HasPrefix(upper, "FREQ=") || strings.HasPrefix(upper, "RRULE:") {
+		rule := model.RecurrenceRule{Type: model.RecurrenceRRule, Interval: 1, Anchor: anchor, RRule: strings.TrimSpace(raw)}
+		if err := rule.Validate(); err != nil {
+			return model.RecurrenceRule{}, err
+		}
+		return rule, nil
+	}
+	ruleType, interval, err := RecurrenceFromKeyword(raw)
+	if err != nil {
+		return model.RecurrenceRule{}, err
+	}
+	return model.RecurrenceRule{Type: ruleType, Interval: interval, Anchor: anchor}, nil
+}
+
+// RecurrenceFromKeyword maps every: values such as "weekday", "daily", "3d"
+// or "2weeks" to a recurrence type and interval.
+func RecurrenceFromKeyword(raw string) (model.RecurrenceType, int, error) {
+	keyword := strings.ToLower(strings.TrimSpace(raw))
+	switch keyword {
+	case "weekday", "weekdays":
+		return model.RecurrenceEveryWeekday, 1, nil
+	case "day", "daily":
+		return model.RecurrenceEveryNDays, 1, nil
+	case "week", "weekly":
+		return model.RecurrenceEveryNWeeks, 1, nil
+	case "month-end", "monthend", "eom":
+		return model.RecurrenceLastDayOfMonth, 1, nil
+	}
+	digits := strings.TrimRightFunc(keyword, func(r rune) bool { return r < '0' || r > '9' })
+	n, err := strconv.Atoi(digits)
+	if err == nil && n > 0 {
+		switch strings.TrimPrefix(keyword, digits) {
+		case "d", "day", "days":
+			return model.RecurrenceEveryNDays, n, nil
+		case "w", "wk", "week", "weeks":
+			return model.RecurrenceEveryNWeeks, n, nil
+		}
+	}
+	return "", 0, fmt.Errorf("%w: every:%s (use weekday, daily, weekly, month-end, Nd, Nw or an RRULE such as FREQ=MONTHLY;BYDAY=2TU)", model.ErrInvalidRecurrenceType, raw)
+}
+
+// Task builds the new Inbox task described by the draft.
+func (d Draft) Task(id string, now time.Time) model.Task {
+	task := model.Task{
+		ID:          id,
+		Title:       strings.TrimSpace(d.Args.Title),
+		State:       model.TaskStateInbox,
+		Priority:    model.DefaultPriority,
+		Energy:      model.DefaultEnergy,
+		Tags:        d.Args.Tags,
+		ScheduledAt: utcPtr(d.ScheduledAt),
+		DueAt:       utcPtr(d.DueAt),
+		CreatedAt:   now.UTC(),
+	}
+	if d.Args.Priority != "" {
+		task.Priority = model.Priority(d.Args.Priority)
+	}
+	if d.Args.Energy != "" {
+		task.Energy = model.Energy(d.Args.Energy)
+	}
+	return task
+}
+
+// Preview renders the parsed fields on one line, as shown under add>.
+func (d Draft) Preview() string {
+	parts := []string{fmt.Sprintf("title: %s", strings.TrimSpace(d.Args.Title))}
+	if len(d.Args.Tags) > 0 {
+		parts = append(parts, "tags: "+strings.Join(d.Args.Tags, ","))
+	}
+	if d.Args.Priority != "" {
+		parts = append(parts, "priority: "+d.Args.Priority)
+	}
+	if d.Args.Energy != "" {
+		parts = append(parts, "energy: "+d.Args.Energy)
+	}
+	if d.DueAt != nil {
+		parts = append(parts, "due: "+d.DueAt.Format("Mon 2006-01-02 15:04"))
+	}
+	if d.ScheduledAt != nil {
+		parts = append(parts, "at: "+d.ScheduledAt.Format("Mon 2006-01-02 15:04"))
+	}
+	if d.Recurrence != nil {
+		every := string(d.Recurrence.Type)
+		if d.Recurrence.RRule != "" {
+			every = d.Recurrence.RRule
+		} else if d.Recurrence.Interval > 1 {
+			every = fmt.Sprintf("%s x%d", every, d.Recurrence.Interval)
+		}
+		parts = append(parts, "every: "+every)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.UTC()
+	return &v
+}
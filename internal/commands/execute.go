@@ -11,6 +11,9 @@ type Handlers struct {
 	Snooze     func(SnoozeArgs) (Result, error)
 	Show       func(ShowArgs) (Result, error)
 	Reschedule func(RescheduleArgs) (Result, error)
+	Find       func(FindArgs) (Result, error)
+	Undo       func() (Result, error)
+	Redo       func() (Result, error)
 }
 
 func Execute(cmd Command, handlers Handlers) (Result, error) {
@@ -35,6 +38,21 @@ func Execute(cmd Command, handlers Handlers) (Result, error) {
 			return Result{}, &CommandError{Code: ErrCodeHandlerMissing, Message: "reschedule handler not configured"}
 		}
 		return handlers.Reschedule(*cmd.Reschedule)
+	case TypeFind:
+		if handlers.Find == nil {
+			return Result{}, &CommandError{Code: ErrCodeHandlerMissing, Message: "find handler not configured"}
+		}
+		return handlers.Find(*cmd.Find)
+	case TypeUndo:
+		if handlers.Undo == nil {
+			return Result{}, &CommandError{Code: ErrCodeHandlerMissing, Message: "undo handler not configured"}
+		}
+		return handlers.Undo()
+	case TypeRedo:
+		if handlers.Redo == nil {
+			return Result{}, &CommandError{Code: ErrCodeHandlerMissing, Message: "redo handler not configured"}
+		}
+		return handlers.Redo()
 	default:
 		return Result{}, &CommandError{Code: ErrCodeUnknownCommand, Message: fmt.Sprintf("unknown command type: %s", cmd.Type)}
 	}
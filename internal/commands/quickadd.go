@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+var priorityTokens = map[string]string{
+	"!low":      "Low",
+	"!med":      "Medium",
+	"!medium":   "Medium",
+	"!high":     "High",
+	"!!":        "High",
+	"!crit":     "Critical",
+	"!critical": "Critical",
+	"!!!":       "Critical",
+}
+
+var energyTokens = map[string]string{
+	"@deep":   "Deep",
+	"@light":  "Light",
+	"@social": "Social",
+	"@low":    "Low",
+}
+
+// ParseQuickAdd splits quick-add text into a title and inline metadata:
+// #tag, !high or !!, @deep, due:<when>, at:<when> and every:<rule>. Values
+// containing spaces can be quoted (due:"next monday"). Words that only look
+// like metadata, such as an unknown @name, stay in the title.
+func ParseQuickAdd(text string) (AddArgs, error) {
+	var args AddArgs
+	title := make([]string, 0)
+	tokens := strings.Fields(text)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		lower := strings.ToLower(tok)
+		switch {
+		case strings.HasPrefix(tok, "#") && len(tok) > 1:
+			tag := strings.ToLower(tok[1:])
+			if !containsString(args.Tags, tag) {
+				args.Tags = append(args.Tags, tag)
+			}
+		case priorityTokens[lower] != "":
+			args.Priority = priorityTokens[lower]
+		case strings.HasPrefix(lower, "!") && strings.Trim(lower, "!") != "":
+			return AddArgs{}, &CommandError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("unknown priority %q (use !low, !medium, !high, !critical or !!)", tok)}
+		case energyTokens[lower] != "":
+			args.Energy = energyTokens[lower]
+		default:
+			key, value, ok := metadataField(tok)
+			if !ok {
+				title = append(title, tok)
+				continue
+			}
+			if strings.HasPrefix(value, `"`) {
+				value, i = quotedValue(tokens, i, value)
+			}
+			if value == "" {
+				return AddArgs{}, &CommandError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf("%s: needs a value", key)}
+			}
+			switch key {
+			case "due":
+				args.Due = value
+			case "at":
+				args.At = value
+			case "every":
+				args.Every = value
+			}
+		}
+	}
+	args.Title = strings.Join(title, " ")
+	return args, nil
+}
+
+func metadataField(tok string) (string, string, bool) {
+	key, value, ok := strings.Cut(tok, ":")
+	if !ok {
+		return "", "", false
+	}
+	key = strings.ToLower(key)
+	switch key {
+	case "due", "at", "every":
+		return key, value, true
+	default:
+		return "", "", false
+	}
+}
+
+// quotedValue joins tokens from i until the closing quote and returns the
+// unquoted value with the index of the last token consumed.
+func quotedValue(tokens []string, i int, first string) (string, int) {
+	parts := []string{strings.TrimPrefix(first, `"`)}
+	for !strings.HasSuffix(parts[len(parts)-1], `"`) && i+1 < len(tokens) {
+		i++
+		parts = append(parts, tokens[i])
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.Join(parts, " "), `"`)), i
+}
+
+func containsString(items []string, want string) bool {
+	for _, item := range items {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
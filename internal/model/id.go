@@ -0,0 +1,22 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPriority = PriorityMedium
+	DefaultEnergy   = EnergyLight
+)
+
+// NewID returns prefix followed by a random hex suffix, e.g. "task-1a2b3c4d5e6f".
+func NewID(prefix string) string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%s-%x", prefix, time.Now().UnixNano())
+	}
+	return prefix + "-" + hex.EncodeToString(buf)
+}
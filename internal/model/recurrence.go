@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -15,25 +16,58 @@ const (
 	RecurrenceEveryNWeeks    RecurrenceType = "every_n_weeks"
 	RecurrenceLastDayOfMonth RecurrenceType = "last_day_of_month"
 	RecurrenceAfterComplete  RecurrenceType = "after_completion"
+	// RecurrenceRRule follows the RFC 5545 lines in RecurrenceRule.RRule.
+	RecurrenceRRule RecurrenceType = "rrule"
 )
 
 var (
 	ErrInvalidRecurrenceType = errors.New("model: invalid recurrence type")
 	ErrInvalidInterval       = errors.New("model: invalid recurrence interval")
 	ErrCompletionRequired    = errors.New("model: completion time required for after_completion recurrence")
+	ErrNotExpressible        = errors.New("model: recurrence cannot be written as an rrule")
+	ErrInvalidRecurrenceEnd  = errors.New("model: invalid recurrence end")
+	ErrInvalidException      = errors.New("model: invalid recurrence exception")
+	ErrNoOccurrence          = errors.New("model: no occurrence on that day")
 )
 
+// ExceptionKind is how a RecurrenceException changes its series.
+type ExceptionKind string
+
+const (
+	ExceptionSkip  ExceptionKind = "skip"
+	ExceptionMove  ExceptionKind = "move"
+	ExceptionPause ExceptionKind = "pause"
+)
+
+// RecurrenceException changes part of a series without ending it. A skip
+// drops the occurrence At and a move reschedules it to To; a pause drops
+// every occurrence from At up to, but not including, To.
+type RecurrenceException struct {
+	Kind ExceptionKind
+	At   time.Time
+	To   time.Time
+}
+
 type RecurrenceRule struct {
 	Type            RecurrenceType
 	Interval        int
 	Anchor          time.Time
 	Weekdays        []time.Weekday
 	AfterCompleteIn time.Duration
+	// RRule holds the RRULE, RDATE and EXDATE lines of an rrule recurrence;
+	// Anchor is its DTSTART.
+	RRule string
+	// Until ends the series at its last occurrence on or before Until, and
+	// Count after that many occurrences from Anchor; skipped and paused
+	// occurrences still count. Neither set means the series never ends.
+	Until      time.Time
+	Count      int
+	Exceptions []RecurrenceException
 }
 
 func (r RecurrenceRule) Validate() error {
 	switch r.Type {
-	case RecurrenceEveryWeekday, RecurrenceEveryNDays, RecurrenceEveryNWeeks, RecurrenceLastDayOfMonth, RecurrenceAfterComplete:
+	case RecurrenceEveryWeekday, RecurrenceEveryNDays, RecurrenceEveryNWeeks, RecurrenceLastDayOfMonth, RecurrenceAfterComplete, RecurrenceRRule:
 	default:
 		return fmt.Errorf("%w: %q", ErrInvalidRecurrenceType, r.Type)
 	}
@@ -43,9 +77,29 @@ func (r RecurrenceRule) Validate() error {
 	if r.Interval <= 0 {
 		return fmt.Errorf("%w: %d", ErrInvalidInterval, r.Interval)
 	}
+	if r.Type == RecurrenceRRule {
+		if strings.TrimSpace(r.RRule) == "" {
+			return fmt.Errorf("%w: rrule recurrence without RRULE", ErrInvalidRRule)
+		}
+		set, err := ParseRecurrenceSet(r.RRule, r.Anchor)
+		if err != nil {
+			return err
+		}
+		if !set.occurs() {
+			return fmt.Errorf("%w: %q never occurs", ErrInvalidRRule, r.RRule)
+		}
+	} else if r.RRule != "" {
+		return fmt.Errorf("%w: RRULE given for %s recurrence", ErrInvalidRRule, r.Type)
+	}
+	if err := r.validateEnd(); err != nil {
+		return err
+	}
 	if r.Type == RecurrenceEveryWeekday && len(r.Weekdays) > 0 {
 		s := make([]int, 0, len(r.Weekdays))
 		for _, d := range r.Weekdays {
+			if d < time.Sunday || d > time.Saturday {
+				return fmt.Errorf("model: invalid weekday %d in recurrence", d)
+			}
 			s = append(s, int(d))
 		}
 		sort.Ints(s)
@@ -58,128 +112,304 @@ func (r RecurrenceRule) Validate() error {
 	return nil
 }
 
+// NextAfter returns the first occurrence strictly after from, with the
+// rule's end and exceptions applied. Rules are evaluated on wall-clock time
+// in Anchor's location, so a daily 09:00 rule stays at 09:00 across DST
+// changes, and the result is in that location. ErrRecurrenceEnded means the
+// series has no further occurrence.
 func (r RecurrenceRule) NextAfter(from time.Time, completedAt *time.Time) (time.Time, error) {
 	if err := r.Validate(); err != nil {
 		return time.Time{}, err
 	}
-	base := from.UTC()
-	if base.Before(r.Anchor.UTC()) {
-		base = r.Anchor.UTC().Add(-time.Nanosecond)
+	if r.Type == RecurrenceAfterComplete {
+		return r.nextAfterCompletion(completedAt)
 	}
 
-	switch r.Type {
-	case RecurrenceEveryWeekday:
-		return r.nextWeekday(base), nil
-	case RecurrenceEveryNDays:
-		return r.nextEveryNDays(base), nil
-	case RecurrenceEveryNWeeks:
-		return r.nextEveryNWeeks(base), nil
-	case RecurrenceLastDayOfMonth:
-		return r.nextLastDayOfMonth(base), nil
-	case RecurrenceAfterComplete:
-		if completedAt == nil || completedAt.IsZero() {
-			return time.Time{}, ErrCompletionRequired
-		}
-		wait := r.AfterCompleteIn
-		if wait <= 0 {
-			wait = time.Duration(r.Interval) * 24 * time.Hour
-		}
-		return completedAt.UTC().Add(wait), nil
-	default:
-		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidRecurrenceType, r.Type)
+	set, err := r.Set()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var next time.Time
+	found := false
+	r.occurrences(set, from, func(at time.Time) bool {
+		next, found = at, true
+		return false
+	})
+	if !found {
+		return time.Time{}, ErrRecurrenceEnded
 	}
+	return next, nil
 }
 
-func (r RecurrenceRule) Preview(from time.Time, completedAt *time.Time, count int) ([]time.Time, error) {
-	if count <= 0 {
-		return []time.Time{}, nil
+// OccurrenceOn returns the occurrence the schedule puts on day's date in the
+// rule's location, before exceptions, for naming one to skip or move.
+func (r RecurrenceRule) OccurrenceOn(day time.Time) (time.Time, error) {
+	if err := r.Validate(); err != nil {
+		return time.Time{}, err
 	}
-	out := make([]time.Time, 0, count)
-	cursor := from
-	for i := 0; i < count; i++ {
-		next, err := r.NextAfter(cursor, completedAt)
-		if err != nil {
-			return nil, err
+	if r.Type == RecurrenceAfterComplete {
+		return time.Time{}, fmt.Errorf("%w: after_completion has no fixed occurrences", ErrNoOccurrence)
+	}
+	set, err := r.Set()
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc := r.Anchor.Location()
+	y, m, d := day.In(loc).Date()
+	start := civilTime(civilDate(y, m, d), 0, 0, 0, 0, loc)
+	end := civilTime(civilDate(y, m, d+1), 0, 0, 0, 0, loc)
+	var at time.Time
+	r.scheduled(set, start.Add(-time.Nanosecond), func(got time.Time) bool {
+		if got.Before(end) {
+			at = got
 		}
-		out = append(out, next)
-		cursor = next.Add(time.Nanosecond)
+		return false
+	})
+	if at.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrNoOccurrence, day.In(loc).Format("2006-01-02"))
 	}
-	return out, nil
+	return at, nil
 }
 
-func (r RecurrenceRule) nextWeekday(from time.Time) time.Time {
-	allowed := r.allowedWeekdays()
-	probe := withAnchorClock(from.AddDate(0, 0, 1), r.Anchor)
-	for {
-		if allowed[probe.Weekday()] {
-			return probe
+func (r RecurrenceRule) nextAfterCompletion(completedAt *time.Time) (time.Time, error) {
+	if completedAt == nil || completedAt.IsZero() {
+		return time.Time{}, ErrCompletionRequired
+	}
+	loc := r.Anchor.Location()
+	done := completedAt.In(loc)
+	next := done.Add(r.AfterCompleteIn)
+	if r.AfterCompleteIn <= 0 {
+		y, m, d := done.Date()
+		next = civilTime(civilDate(y, m, d+r.Interval), done.Hour(), done.Minute(), done.Second(), done.Nanosecond(), loc)
+	}
+	// A pause pushes the occurrence to the same time on the first free day.
+	for end := r.pauseEnd(next); !end.IsZero(); end = r.pauseEnd(next) {
+		y, m, d := end.In(loc).Date()
+		next = civilTime(civilDate(y, m, d), next.Hour(), next.Minute(), next.Second(), next.Nanosecond(), loc)
+		if next.Before(end) {
+			next = civilTime(civilDate(y, m, d+1), next.Hour(), next.Minute(), next.Second(), next.Nanosecond(), loc)
 		}
-		probe = probe.AddDate(0, 0, 1)
 	}
+	if !r.Until.IsZero() && next.After(r.Until) {
+		return time.Time{}, ErrRecurrenceEnded
+	}
+	return next, nil
 }
 
-func (r RecurrenceRule) allowedWeekdays() map[time.Weekday]bool {
-	if len(r.Weekdays) > 0 {
-		m := make(map[time.Weekday]bool, len(r.Weekdays))
-		for _, w := range r.Weekdays {
-			m[w] = true
+// scheduled calls fn with each occurrence of set after t, in order, until fn
+// returns false or the series reaches Until or Count.
+func (r RecurrenceRule) scheduled(set RecurrenceSet, t time.Time, fn func(time.Time) bool) {
+	from, n := t, 0
+	if r.Count > 0 {
+		from = r.Anchor.Add(-time.Nanosecond)
+	}
+	set.Each(from, func(at time.Time) bool {
+		if !r.Until.IsZero() && at.After(r.Until) {
+			return false
+		}
+		if n++; r.Count > 0 && n > r.Count {
+			return false
+		}
+		return !at.After(t) || fn(at)
+	})
+}
+
+// occurrences calls fn with each occurrence after t with exceptions
+// applied, in order, until fn returns false or the series ends. Without a
+// Count nothing before a pause's end needs counting, so the walk jumps
+// straight past it.
+func (r RecurrenceRule) occurrences(set RecurrenceSet, t time.Time, fn func(time.Time) bool) {
+	// A moved occurrence happens at its new time wherever that falls.
+	var moved []time.Time
+	for _, ex := range r.Exceptions {
+		if ex.Kind == ExceptionMove && ex.To.After(t) && r.isScheduled(set, ex.At) {
+			moved = append(moved, ex.To.In(r.Anchor.Location()))
+		}
+	}
+	sort.Slice(moved, func(i, j int) bool { return moved[i].Before(moved[j]) })
+
+	var last time.Time
+	emit := func(at time.Time) bool {
+		if !last.IsZero() && !at.After(last) {
+			return true
 		}
-		return m
+		last = at
+		return fn(at)
 	}
-	return map[time.Weekday]bool{
-		time.Monday:    true,
-		time.Tuesday:   true,
-		time.Wednesday: true,
-		time.Thursday:  true,
-		time.Friday:    true,
+	cursor, done := t, false
+	for !done {
+		var resume time.Time
+		r.scheduled(set, cursor, func(at time.Time) bool {
+			for len(moved) > 0 && !moved[0].After(at) {
+				if !emit(moved[0]) {
+					done = true
+					return false
+				}
+				moved = moved[1:]
+			}
+			if end := r.pauseEnd(at); !end.IsZero() && r.Count == 0 {
+				resume = end
+				return false
+			}
+			if r.excepted(at) {
+				return true
+			}
+			if !emit(at) {
+				done = true
+				return false
+			}
+			return true
+		})
+		if resume.IsZero() {
+			break
+		}
+		cursor = resume.Add(-time.Nanosecond)
+	}
+	for _, at := range moved {
+		if done || !emit(at) {
+			return
+		}
 	}
 }
 
-func (r RecurrenceRule) nextEveryNDays(from time.Time) time.Time {
-	anchor := r.Anchor.UTC()
-	interval := time.Duration(r.Interval) * 24 * time.Hour
-	if from.Before(anchor) {
-		return anchor
+// pauseEnd returns the latest end of the pauses covering at, or the zero
+// time when none does.
+func (r RecurrenceRule) pauseEnd(at time.Time) time.Time {
+	var end time.Time
+	for _, ex := range r.Exceptions {
+		if ex.Kind == ExceptionPause && !at.Before(ex.At) && at.Before(ex.To) && ex.To.After(end) {
+			end = ex.To
+		}
 	}
-	elapsed := from.Sub(anchor)
-	steps := int(elapsed / interval)
-	next := anchor.Add(time.Duration(steps+1) * interval)
-	return withAnchorClock(next, anchor)
+	return end
 }
 
-func (r RecurrenceRule) nextEveryNWeeks(from time.Time) time.Time {
-	anchor := r.Anchor.UTC()
-	intervalDays := r.Interval * 7
-	interval := time.Duration(intervalDays) * 24 * time.Hour
-	if from.Before(anchor) {
-		return anchor
-	}
-	elapsed := from.Sub(anchor)
-	steps := int(elapsed / interval)
-	next := anchor.Add(time.Duration(steps+1) * interval)
-	return withAnchorClock(next, anchor)
+func (r RecurrenceRule) isScheduled(set RecurrenceSet, at time.Time) bool {
+	ok := false
+	r.scheduled(set, at.Add(-time.Nanosecond), func(got time.Time) bool {
+		ok = got.Equal(at)
+		return false
+	})
+	return ok
 }
 
-func (r RecurrenceRule) nextLastDayOfMonth(from time.Time) time.Time {
-	anchor := r.Anchor.UTC()
-	y, m, _ := from.Date()
-	loc := anchor.Location()
+// excepted reports whether an exception drops the scheduled occurrence at.
+func (r RecurrenceRule) excepted(at time.Time) bool {
+	for _, ex := range r.Exceptions {
+		switch ex.Kind {
+		case ExceptionSkip, ExceptionMove:
+			if ex.At.Equal(at) {
+				return true
+			}
+		case ExceptionPause:
+			if !at.Before(ex.At) && at.Before(ex.To) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	candidate := lastDayAt(y, m, anchor, loc)
-	if !candidate.After(from) {
-		nextMonth := time.Date(y, m, 1, 0, 0, 0, 0, loc).AddDate(0, 1, 0)
-		yy, mm, _ := nextMonth.Date()
-		candidate = lastDayAt(yy, mm, anchor, loc)
+func (r RecurrenceRule) validateEnd() error {
+	switch {
+	case r.Count < 0:
+		return fmt.Errorf("%w: count %d", ErrInvalidRecurrenceEnd, r.Count)
+	case r.Count > 0 && !r.Until.IsZero():
+		return fmt.Errorf("%w: until and count cannot both be set", ErrInvalidRecurrenceEnd)
+	case r.Count > 0 && r.Type == RecurrenceAfterComplete:
+		return fmt.Errorf("%w: after_completion cannot end after a count", ErrInvalidRecurrenceEnd)
 	}
-	return candidate
+	moved := make(map[int64]bool, len(r.Exceptions))
+	for _, ex := range r.Exceptions {
+		if ex.At.IsZero() {
+			return fmt.Errorf("%w: %s without a time", ErrInvalidException, ex.Kind)
+		}
+		switch ex.Kind {
+		case ExceptionSkip, ExceptionMove:
+			if r.Type == RecurrenceAfterComplete {
+				return fmt.Errorf("%w: after_completion has no fixed occurrence to %s", ErrInvalidException, ex.Kind)
+			}
+			if (ex.Kind == ExceptionMove) == ex.To.IsZero() {
+				return fmt.Errorf("%w: only a move has a new time", ErrInvalidException)
+			}
+			if moved[ex.At.UnixNano()] {
+				return fmt.Errorf("%w: %s changed twice", ErrInvalidException, ex.At.Format(time.RFC3339))
+			}
+			moved[ex.At.UnixNano()] = true
+		case ExceptionPause:
+			if !ex.To.After(ex.At) {
+				return fmt.Errorf("%w: pause must end after it starts", ErrInvalidException)
+			}
+		default:
+			return fmt.Errorf("%w: kind %q", ErrInvalidException, ex.Kind)
+		}
+	}
+	return nil
 }
 
-func lastDayAt(y int, m time.Month, anchor time.Time, loc *time.Location) time.Time {
-	firstNextMonth := time.Date(y, m, 1, anchor.Hour(), anchor.Minute(), anchor.Second(), anchor.Nanosecond(), loc).AddDate(0, 1, 0)
-	return firstNextMonth.AddDate(0, 0, -1)
+// Preview returns up to count occurrences after from, as successive calls
+// to NextAfter would, stopping early when the series ends; an
+// after_completion rule has only the one occurrence its completion sets.
+// The set is built and walked once, so a long or paused series stays cheap.
+func (r RecurrenceRule) Preview(from time.Time, completedAt *time.Time, count int) ([]time.Time, error) {
+	if count <= 0 {
+		return []time.Time{}, nil
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	out := make([]time.Time, 0, count)
+	if r.Type == RecurrenceAfterComplete {
+		next, err := r.nextAfterCompletion(completedAt)
+		if errors.Is(err, ErrRecurrenceEnded) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return append(out, next), nil
+	}
+	set, err := r.Set()
+	if err != nil {
+		return nil, err
+	}
+	r.occurrences(set, from, func(at time.Time) bool {
+		out = append(out, at)
+		return len(out) < count
+	})
+	return out, nil
 }
 
-func withAnchorClock(date time.Time, anchor time.Time) time.Time {
-	y, m, d := date.Date()
-	return time.Date(y, m, d, anchor.Hour(), anchor.Minute(), anchor.Second(), anchor.Nanosecond(), anchor.Location())
+// Set returns the rule's schedule as an RFC 5545 recurrence set starting at
+// Anchor, before Until, Count and Exceptions; NextAfter expands it.
+// after_completion rules depend on when a task is done and have none.
+func (r RecurrenceRule) Set() (RecurrenceSet, error) {
+	if err := r.Validate(); err != nil {
+		return RecurrenceSet{}, err
+	}
+	set := RecurrenceSet{Start: r.Anchor}
+	rule := RRule{Interval: 1, WeekStart: time.Monday}
+	switch r.Type {
+	case RecurrenceRRule:
+		return ParseRecurrenceSet(r.RRule, r.Anchor)
+	case RecurrenceEveryWeekday:
+		rule.Freq = FreqWeekly
+		days := r.Weekdays
+		if len(days) == 0 {
+			days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		}
+		for _, d := range days {
+			rule.ByDay = append(rule.ByDay, WeekdayNum{Day: d})
+		}
+	case RecurrenceEveryNDays:
+		rule.Freq, rule.Interval = FreqDaily, r.Interval
+	case RecurrenceEveryNWeeks:
+		rule.Freq, rule.Interval = FreqWeekly, r.Interval
+	case RecurrenceLastDayOfMonth:
+		rule.Freq, rule.ByMonthDay = FreqMonthly, []int{-1}
+	default:
+		return RecurrenceSet{}, fmt.Errorf("%w: %s", ErrNotExpressible, r.Type)
+	}
+	set.RRules = []RRule{rule}
+	return set, nil
 }
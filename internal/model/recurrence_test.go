@@ -120,3 +120,268 @@ func TestRecurrenceAfterCompletionRequiresCompletedAt(t *testing.T) {
 		t.Fatalf("expected ErrCompletionRequired, got %v", err)
 	}
 }
+
+func TestRecurrenceKeepsWallClockAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	auckland := mustLoadLocation(t, "Pacific/Auckland")
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+	cases := []struct {
+		name string
+		rule RecurrenceRule
+		want []string
+	}{
+		{
+			name: "daily across spring forward",
+			rule: RecurrenceRule{Type: RecurrenceEveryNDays, Interval: 1, Anchor: time.Date(2026, 3, 6, 9, 0, 0, 0, newYork)},
+			want: []string{"2026-03-07 09:00 EST", "2026-03-08 09:00 EDT", "2026-03-09 09:00 EDT"},
+		},
+		{
+			name: "daily across fall back",
+			rule: RecurrenceRule{Type: RecurrenceEveryNDays, Interval: 1, Anchor: time.Date(2026, 10, 30, 9, 0, 0, 0, newYork)},
+			want: []string{"2026-10-31 09:00 EDT", "2026-11-01 09:00 EST", "2026-11-02 09:00 EST"},
+		},
+		{
+			name: "skipped time moves past the gap",
+			rule: RecurrenceRule{Type: RecurrenceEveryNDays, Interval: 1, Anchor: time.Date(2026, 3, 6, 2, 30, 0, 0, newYork)},
+			want: []string{"2026-03-07 02:30 EST", "2026-03-08 03:30 EDT", "2026-03-09 02:30 EDT"},
+		},
+		{
+			name: "repeated time happens once",
+			rule: RecurrenceRule{Type: RecurrenceEveryNDays, Interval: 1, Anchor: time.Date(2026, 10, 30, 1, 30, 0, 0, newYork)},
+			want: []string{"2026-10-31 01:30 EDT", "2026-11-01 01:30 EDT", "2026-11-02 01:30 EST"},
+		},
+		{
+			name: "weekly across spring forward",
+			rule: RecurrenceRule{Type: RecurrenceEveryNWeeks, Interval: 1, Anchor: time.Date(2026, 3, 2, 9, 0, 0, 0, newYork)},
+			want: []string{"2026-03-09 09:00 EDT", "2026-03-16 09:00 EDT", "2026-03-23 09:00 EDT"},
+		},
+		{
+			name: "weekdays follow the local calendar",
+			rule: RecurrenceRule{Type: RecurrenceEveryWeekday, Interval: 1, Anchor: time.Date(2026, 4, 2, 8, 0, 0, 0, auckland)},
+			want: []string{"2026-04-03 08:00 NZDT", "2026-04-06 08:00 NZST", "2026-04-07 08:00 NZST"},
+		},
+		{
+			name: "month end in a zone ahead of UTC",
+			rule: RecurrenceRule{Type: RecurrenceLastDayOfMonth, Interval: 1, Anchor: time.Date(2026, 1, 31, 4, 0, 0, 0, kolkata)},
+			want: []string{"2026-02-28 04:00 IST", "2026-03-31 04:00 IST", "2026-04-30 04:00 IST"},
+		},
+		{
+			name: "rrule across fall back",
+			rule: RecurrenceRule{Type: RecurrenceRRule, Interval: 1, Anchor: time.Date(2026, 10, 25, 9, 0, 0, 0, newYork), RRule: "FREQ=WEEKLY;BYDAY=SU"},
+			want: []string{"2026-11-01 09:00 EST", "2026-11-08 09:00 EST", "2026-11-15 09:00 EST"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.rule.Preview(tc.rule.Anchor, nil, len(tc.want))
+			if err != nil {
+				t.Fatalf("preview: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %d occurrences, got %v", len(tc.want), got)
+			}
+			for i, at := range got {
+				if s := at.Format("2006-01-02 15:04 MST"); s != tc.want[i] {
+					t.Fatalf("occurrence %d = %s, want %s", i, s, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAfterCompletionCountsLocalDays(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	anchor := time.Date(2026, 3, 1, 9, 0, 0, 0, newYork)
+	done := time.Date(2026, 3, 7, 20, 0, 0, 0, newYork)
+
+	days := RecurrenceRule{Type: RecurrenceAfterComplete, Interval: 1, Anchor: anchor}
+	next, err := days.NextAfter(done, &done)
+	if err != nil || next.Format("2006-01-02 15:04 MST") != "2026-03-08 20:00 EDT" {
+		t.Fatalf("expected a calendar day later, got %s, %v", next.Format(time.RFC3339), err)
+	}
+	hours := RecurrenceRule{Type: RecurrenceAfterComplete, Interval: 1, Anchor: anchor, AfterCompleteIn: 24 * time.Hour}
+	next, err = hours.NextAfter(done, &done)
+	if err != nil || next.Format("2006-01-02 15:04 MST") != "2026-03-08 21:00 EDT" {
+		t.Fatalf("expected exactly 24 hours later, got %s, %v", next.Format(time.RFC3339), err)
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestRecurrenceEndsAndExceptions(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	day := func(d, h, m int) time.Time { return time.Date(2026, 3, d, h, m, 0, 0, newYork) }
+	daily := RecurrenceRule{Type: RecurrenceEveryNDays, Interval: 1, Anchor: day(2, 9, 0)}
+	with := func(mutate func(*RecurrenceRule)) RecurrenceRule {
+		rule := daily
+		mutate(&rule)
+		return rule
+	}
+	cases := []struct {
+		name string
+		rule RecurrenceRule
+		want []string
+	}{
+		{"until a date", with(func(r *RecurrenceRule) { r.Until = day(4, 23, 59) }), []string{"03-02 09:00", "03-03 09:00", "03-04 09:00"}},
+		{"after three", with(func(r *RecurrenceRule) { r.Count = 3 }), []string{"03-02 09:00", "03-03 09:00", "03-04 09:00"}},
+		{"skip one", with(func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionSkip, At: day(3, 9, 0)}}
+		}), []string{"03-02 09:00", "03-04 09:00", "03-05 09:00"}},
+		{"move one later in its day", with(func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionMove, At: day(3, 9, 0), To: day(3, 14, 0)}}
+		}), []string{"03-02 09:00", "03-03 14:00", "03-04 09:00"}},
+		{"move one past others", with(func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionMove, At: day(3, 9, 0), To: day(5, 8, 0)}}
+		}), []string{"03-02 09:00", "03-04 09:00", "03-05 08:00", "03-05 09:00"}},
+		{"move one earlier", with(func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionMove, At: day(4, 9, 0), To: day(3, 7, 0)}}
+		}), []string{"03-02 09:00", "03-03 07:00", "03-03 09:00", "03-05 09:00"}},
+		{"pause for a vacation", with(func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionPause, At: day(3, 0, 0), To: day(6, 0, 0)}}
+		}), []string{"03-02 09:00", "03-06 09:00", "03-07 09:00"}},
+		{"skipped occurrences count", with(func(r *RecurrenceRule) {
+			r.Count = 3
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionSkip, At: day(3, 9, 0)}}
+		}), []string{"03-02 09:00", "03-04 09:00"}},
+		{"moved occurrence stays in the count", with(func(r *RecurrenceRule) {
+			r.Count = 2
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionMove, At: day(3, 9, 0), To: day(10, 9, 0)}}
+		}), []string{"03-02 09:00", "03-10 09:00"}},
+		{"moving a time off the schedule does nothing", with(func(r *RecurrenceRule) {
+			r.Count = 2
+			r.Exceptions = []RecurrenceException{
+				{Kind: ExceptionMove, At: day(3, 10, 0), To: day(3, 12, 0)},
+				{Kind: ExceptionMove, At: day(5, 9, 0), To: day(3, 13, 0)},
+			}
+		}), []string{"03-02 09:00", "03-03 09:00"}},
+		{"rrule skip", RecurrenceRule{Type: RecurrenceRRule, Interval: 1, Anchor: day(3, 9, 0), RRule: "FREQ=WEEKLY;BYDAY=TU",
+			Exceptions: []RecurrenceException{{Kind: ExceptionSkip, At: day(10, 9, 0)}}}, []string{"03-03 09:00", "03-17 09:00", "03-24 09:00"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.rule.Preview(tc.rule.Anchor.Add(-time.Minute), nil, len(tc.want)+2)
+			if err != nil {
+				t.Fatalf("preview: %v", err)
+			}
+			if ends := tc.rule.Count > 0 || !tc.rule.Until.IsZero(); ends && len(got) != len(tc.want) {
+				t.Fatalf("expected the series to end after %v, got %v", tc.want, got)
+			}
+			for i, want := range tc.want {
+				if i >= len(got) || got[i].Format("01-02 15:04") != want {
+					t.Fatalf("occurrences %v, want %v first", got, tc.want)
+				}
+			}
+		})
+	}
+
+	ended := with(func(r *RecurrenceRule) { r.Count = 3 })
+	if _, err := ended.NextAfter(day(4, 9, 0), nil); !errors.Is(err, ErrRecurrenceEnded) {
+		t.Fatalf("expected ErrRecurrenceEnded after the third, got %v", err)
+	}
+}
+
+func TestPreviewWalksLongSeriesOnce(t *testing.T) {
+	anchor := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	paused := RecurrenceRule{Type: RecurrenceEveryNDays, Interval: 1, Anchor: anchor,
+		Exceptions: []RecurrenceException{{Kind: ExceptionPause, At: anchor.AddDate(0, 0, 1), To: anchor.AddDate(300, 0, 0)}}}
+	started := time.Now()
+	got, err := paused.Preview(anchor.Add(-time.Minute), nil, 3)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if len(got) != 3 || !got[0].Equal(anchor) || !got[1].Equal(anchor.AddDate(300, 0, 0)) || !got[2].Equal(anchor.AddDate(300, 0, 1)) {
+		t.Fatalf("expected the pause to be skipped, got %v", got)
+	}
+	if took := time.Since(started); took > time.Second {
+		t.Fatalf("previewing across a 300-year pause took %v", took)
+	}
+
+	counted := RecurrenceRule{Type: RecurrenceRRule, Interval: 1, Anchor: anchor, RRule: "FREQ=DAILY;COUNT=5000", Count: 5000,
+		Exceptions: []RecurrenceException{{Kind: ExceptionMove, At: anchor.AddDate(0, 0, 1), To: anchor.AddDate(0, 0, 2).Add(time.Hour)}}}
+	got, err = counted.Preview(anchor.Add(-time.Minute), nil, 6000)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if len(got) != 5000 || !got[1].Equal(anchor.AddDate(0, 0, 2)) || !got[2].Equal(anchor.AddDate(0, 0, 2).Add(time.Hour)) || !got[4999].Equal(anchor.AddDate(0, 0, 4999)) {
+		t.Fatalf("expected 5000 occurrences with the move in place, got %d: %v", len(got), got[:3])
+	}
+	for i, at := range got[:50] {
+		next, err := counted.NextAfter(at, nil)
+		if err != nil || !next.Equal(got[i+1]) {
+			t.Fatalf("NextAfter(%v) = %v, %v; preview has %v", at, next, err, got[i+1])
+		}
+	}
+}
+
+func TestAfterCompletionHonoursPauseAndUntil(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	day := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, newYork) }
+	done := day(4, 20)
+	rule := RecurrenceRule{Type: RecurrenceAfterComplete, Interval: 1, Anchor: day(1, 9),
+		Exceptions: []RecurrenceException{{Kind: ExceptionPause, At: day(5, 0), To: day(8, 0)}}}
+	next, err := rule.NextAfter(done, &done)
+	if err != nil || !next.Equal(day(8, 20)) {
+		t.Fatalf("expected the pause to push the occurrence to 03-08 20:00, got %v, %v", next, err)
+	}
+	rule.Until = day(7, 23)
+	if _, err := rule.NextAfter(done, &done); !errors.Is(err, ErrRecurrenceEnded) {
+		t.Fatalf("expected the series to end at until, got %v", err)
+	}
+}
+
+func TestRecurrenceEndsAndExceptionsAreValidated(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	daily := RecurrenceRule{Type: RecurrenceEveryNDays, Interval: 1, Anchor: at}
+	after := RecurrenceRule{Type: RecurrenceAfterComplete, Interval: 1, Anchor: at}
+	cases := []struct {
+		name   string
+		rule   RecurrenceRule
+		mutate func(*RecurrenceRule)
+		want   error
+	}{
+		{"negative count", daily, func(r *RecurrenceRule) { r.Count = -1 }, ErrInvalidRecurrenceEnd},
+		{"until and count", daily, func(r *RecurrenceRule) { r.Count, r.Until = 2, at.AddDate(0, 1, 0) }, ErrInvalidRecurrenceEnd},
+		{"count after completion", after, func(r *RecurrenceRule) { r.Count = 2 }, ErrInvalidRecurrenceEnd},
+		{"exception without a time", daily, func(r *RecurrenceRule) { r.Exceptions = []RecurrenceException{{Kind: ExceptionSkip}} }, ErrInvalidException},
+		{"skip with a new time", daily, func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionSkip, At: at, To: at.Add(time.Hour)}}
+		}, ErrInvalidException},
+		{"move without a new time", daily, func(r *RecurrenceRule) { r.Exceptions = []RecurrenceException{{Kind: ExceptionMove, At: at}} }, ErrInvalidException},
+		{"backwards pause", daily, func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionPause, At: at, To: at}}
+		}, ErrInvalidException},
+		{"unknown kind", daily, func(r *RecurrenceRule) { r.Exceptions = []RecurrenceException{{Kind: "cancel", At: at}} }, ErrInvalidException},
+		{"occurrence changed twice", daily, func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: ExceptionSkip, At: at}, {Kind: ExceptionMove, At: at, To: at.Add(time.Hour)}}
+		}, ErrInvalidException},
+		{"skip after completion", after, func(r *RecurrenceRule) { r.Exceptions = []RecurrenceException{{Kind: ExceptionSkip, At: at}} }, ErrInvalidException},
+	}
+	for _, tc := range cases {
+		rule := tc.rule
+		tc.mutate(&rule)
+		if err := rule.Validate(); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestRecurrenceOccurrenceOn(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	rule := RecurrenceRule{Type: RecurrenceRRule, Interval: 1, Anchor: time.Date(2026, 3, 3, 9, 0, 0, 0, newYork), RRule: "FREQ=WEEKLY;BYDAY=TU",
+		Exceptions: []RecurrenceException{{Kind: ExceptionSkip, At: time.Date(2026, 3, 10, 9, 0, 0, 0, newYork)}}}
+	// 01:00 UTC on the 11th is still the 10th in New York.
+	at, err := rule.OccurrenceOn(time.Date(2026, 3, 11, 1, 0, 0, 0, time.UTC))
+	if err != nil || !at.Equal(time.Date(2026, 3, 10, 9, 0, 0, 0, newYork)) {
+		t.Fatalf("expected the skipped Tuesday's occurrence, got %v, %v", at, err)
+	}
+	if _, err := rule.OccurrenceOn(time.Date(2026, 3, 11, 12, 0, 0, 0, newYork)); !errors.Is(err, ErrNoOccurrence) {
+		t.Fatalf("expected ErrNoOccurrence on a Wednesday, got %v", err)
+	}
+}
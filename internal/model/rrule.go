@@ -0,0 +1,655 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ of an RFC 5545 recurrence rule. Sub-daily
+// frequencies are not supported.
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+var (
+	ErrInvalidRRule = errors.New("model: invalid rrule")
+	// ErrRecurrenceEnded is returned by NextAfter once COUNT or UNTIL leave no
+	// later occurrence.
+	ErrRecurrenceEnded = errors.New("model: recurrence has no further occurrences")
+)
+
+// rruleHorizon bounds the search for the next occurrence. The Gregorian
+// calendar repeats every 400 years, so a rule with nothing in that span
+// never matches again.
+const rruleHorizon = 400
+
+var rruleDays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum is a BYDAY entry such as MO or -1FR. N is zero when the entry
+// has no ordinal.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return rruleDays[w.Day]
+	}
+	return strconv.Itoa(w.N) + rruleDays[w.Day]
+}
+
+// RRule is an RFC 5545 RRULE value. Absent parts are zero; ParseRRule sets
+// Interval to 1 and WeekStart to Monday when the rule leaves them out.
+type RRule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	WeekStart  time.Weekday
+	ByMonth    []int
+	ByWeekNo   []int
+	ByYearDay  []int
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+	ByHour     []int
+	ByMinute   []int
+	BySecond   []int
+	BySetPos   []int
+}
+
+// ParseRRule reads an RRULE value such as "FREQ=MONTHLY;BYDAY=2TU", with or
+// without the "RRULE:" prefix. A floating UNTIL is read in loc; a date-only
+// UNTIL includes the whole of that day.
+func ParseRRule(s string, loc *time.Location) (RRule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	r := RRule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return RRule{}, fmt.Errorf("%w: %q is not NAME=VALUE", ErrInvalidRRule, part)
+		}
+		if seen[name] {
+			return RRule{}, fmt.Errorf("%w: %s given twice", ErrInvalidRRule, name)
+		}
+		seen[name] = true
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value, loc)
+		case "WKST":
+			var day WeekdayNum
+			day, err = parseWeekdayNum(value)
+			if err == nil && day.N != 0 {
+				err = fmt.Errorf("ordinal in WKST")
+			}
+			r.WeekStart = day.Day
+		case "BYMONTH":
+			r.ByMonth, err = parseInts(value)
+		case "BYWEEKNO":
+			r.ByWeekNo, err = parseInts(value)
+		case "BYYEARDAY":
+			r.ByYearDay, err = parseInts(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(value)
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				var day WeekdayNum
+				if day, err = parseWeekdayNum(item); err != nil {
+					break
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		case "BYHOUR":
+			r.ByHour, err = parseInts(value)
+		case "BYMINUTE":
+			r.ByMinute, err = parseInts(value)
+		case "BYSECOND":
+			r.BySecond, err = parseInts(value)
+		case "BYSETPOS":
+			r.BySetPos, err = parseInts(value)
+		default:
+			return RRule{}, fmt.Errorf("%w: unknown part %s", ErrInvalidRRule, name)
+		}
+		if err != nil {
+			return RRule{}, fmt.Errorf("%w: %s=%s: %v", ErrInvalidRRule, name, value, err)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return RRule{}, err
+	}
+	return r, nil
+}
+
+// Validate checks the value ranges and part combinations RFC 5545 allows.
+func (r RRule) Validate() error {
+	switch r.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+	case "":
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	default:
+		return fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRRule, r.Freq)
+	}
+	switch {
+	case r.Interval <= 0:
+		return fmt.Errorf("%w: INTERVAL must be positive", ErrInvalidRRule)
+	case r.Count < 0:
+		return fmt.Errorf("%w: COUNT must be positive", ErrInvalidRRule)
+	case r.Count > 0 && !r.Until.IsZero():
+		return fmt.Errorf("%w: COUNT and UNTIL are exclusive", ErrInvalidRRule)
+	case r.WeekStart < time.Sunday || r.WeekStart > time.Saturday:
+		return fmt.Errorf("%w: invalid WKST", ErrInvalidRRule)
+	case len(r.ByWeekNo) > 0 && r.Freq != FreqYearly:
+		return fmt.Errorf("%w: BYWEEKNO needs FREQ=YEARLY", ErrInvalidRRule)
+	case len(r.ByYearDay) > 0 && r.Freq != FreqYearly:
+		return fmt.Errorf("%w: BYYEARDAY needs FREQ=YEARLY", ErrInvalidRRule)
+	case len(r.ByMonthDay) > 0 && r.Freq == FreqWeekly:
+		return fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRRule)
+	case len(r.BySetPos) > 0 && !r.hasBy():
+		return fmt.Errorf("%w: BYSETPOS needs another BYxxx part", ErrInvalidRRule)
+	}
+	ranges := []struct {
+		name     string
+		values   []int
+		min, max int
+		signed   bool
+	}{
+		{"BYMONTH", r.ByMonth, 1, 12, false},
+		{"BYWEEKNO", r.ByWeekNo, 1, 53, true},
+		{"BYYEARDAY", r.ByYearDay, 1, 366, true},
+		{"BYMONTHDAY", r.ByMonthDay, 1, 31, true},
+		{"BYHOUR", r.ByHour, 0, 23, false},
+		{"BYMINUTE", r.ByMinute, 0, 59, false},
+		{"BYSECOND", r.BySecond, 0, 60, false},
+		{"BYSETPOS", r.BySetPos, 1, 366, true},
+	}
+	for _, rg := range ranges {
+		for _, v := range rg.values {
+			abs := v
+			if rg.signed && v < 0 {
+				abs = -v
+			}
+			if abs < rg.min || abs > rg.max {
+				return fmt.Errorf("%w: %s value %d out of range", ErrInvalidRRule, rg.name, v)
+			}
+		}
+	}
+	for _, day := range r.ByDay {
+		if day.Day < time.Sunday || day.Day > time.Saturday {
+			return fmt.Errorf("%w: invalid BYDAY weekday %d", ErrInvalidRRule, day.Day)
+		}
+		if day.N == 0 {
+			continue
+		}
+		if day.N < -53 || day.N > 53 {
+			return fmt.Errorf("%w: BYDAY ordinal %d out of range", ErrInvalidRRule, day.N)
+		}
+		if r.Freq != FreqMonthly && r.Freq != FreqYearly || len(r.ByWeekNo) > 0 {
+			return fmt.Errorf("%w: BYDAY ordinals need FREQ=MONTHLY or YEARLY without BYWEEKNO", ErrInvalidRRule)
+		}
+	}
+	return nil
+}
+
+func (r RRule) hasBy() bool {
+	return len(r.ByMonth)+len(r.ByWeekNo)+len(r.ByYearDay)+len(r.ByMonthDay)+len(r.ByDay)+
+		len(r.ByHour)+len(r.ByMinute)+len(r.BySecond) > 0
+}
+
+// String writes the rule in RRULE value syntax. UNTIL is always written in
+// UTC.
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+rruleDays[r.WeekStart])
+	}
+	lists := []struct {
+		name   string
+		values []int
+	}{
+		{"BYMONTH", r.ByMonth},
+		{"BYWEEKNO", r.ByWeekNo},
+		{"BYYEARDAY", r.ByYearDay},
+		{"BYMONTHDAY", r.ByMonthDay},
+	}
+	for _, l := range lists {
+		if len(l.values) > 0 {
+			parts = append(parts, l.name+"="+joinInts(l.values))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	lists = []struct {
+		name   string
+		values []int
+	}{
+		{"BYHOUR", r.ByHour},
+		{"BYMINUTE", r.ByMinute},
+		{"BYSECOND", r.BySecond},
+		{"BYSETPOS", r.BySetPos},
+	}
+	for _, l := range lists {
+		if len(l.values) > 0 {
+			parts = append(parts, l.name+"="+joinInts(l.values))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Expand calls fn with each occurrence of the rule started at start that is
+// after from, in order, until fn returns false or the rule ends. Occurrences
+// are computed on the wall clock of start's location; dates that do not
+// exist (February 30th) are skipped.
+func (r RRule) Expand(start, from time.Time, fn func(time.Time) bool) {
+	if r.Validate() != nil {
+		return
+	}
+	loc := start.Location()
+	period := r.firstPeriod(start)
+	if r.Count == 0 && from.After(start) {
+		period = r.skipTo(period, from.In(loc))
+	}
+	horizon := from
+	if start.After(horizon) {
+		horizon = start
+	}
+	horizon = horizon.AddDate(rruleHorizon, 0, 0)
+
+	hours := orDefault(r.ByHour, start.Hour())
+	minutes := orDefault(r.ByMinute, start.Minute())
+	seconds := orDefault(r.BySecond, start.Second())
+	emitted := 0
+	for !civilTime(period, 0, 0, 0, 0, loc).After(horizon) {
+		var set []time.Time
+		for _, day := range r.periodDays(period) {
+			if !r.matchDay(day, start) {
+				continue
+			}
+			for _, h := range hours {
+				for _, m := range minutes {
+					for _, s := range seconds {
+						set = append(set, civilTime(day, h, m, s, start.Nanosecond(), loc))
+					}
+				}
+			}
+		}
+		sort.Slice(set, func(i, j int) bool { return set[i].Before(set[j]) })
+		if len(r.BySetPos) > 0 {
+			set = pickSetPos(set, r.BySetPos)
+		}
+		for _, at := range set {
+			if at.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && at.After(r.Until) {
+				return
+			}
+			emitted++
+			if r.Count > 0 && emitted > r.Count {
+				return
+			}
+			if at.After(from) && !fn(at) {
+				return
+			}
+		}
+		period = r.nextPeriod(period)
+	}
+}
+
+// firstPeriod is the first civil date of the period holding start.
+func (r RRule) firstPeriod(start time.Time) time.Time {
+	y, m, d := start.Date()
+	switch r.Freq {
+	case FreqYearly:
+		return civilDate(y, 1, 1)
+	case FreqMonthly:
+		return civilDate(y, m, 1)
+	case FreqWeekly:
+		day := civilDate(y, m, d)
+		return day.AddDate(0, 0, -daysSinceWeekStart(day.Weekday(), r.WeekStart))
+	default:
+		return civilDate(y, m, d)
+	}
+}
+
+// skipTo moves period forward by whole intervals to the one holding at.
+func (r RRule) skipTo(period, at time.Time) time.Time {
+	y, m, d := at.Date()
+	target := civilDate(y, m, d)
+	var steps int
+	switch r.Freq {
+	case FreqYearly:
+		steps = (y - period.Year()) / r.Interval
+		return period.AddDate(steps*r.Interval, 0, 0)
+	case FreqMonthly:
+		steps = ((y-period.Year())*12 + int(m) - int(period.Month())) / r.Interval
+		return period.AddDate(0, steps*r.Interval, 0)
+	case FreqWeekly:
+		steps = civilDays(period, target) / 7 / r.Interval
+		return period.AddDate(0, 0, steps*7*r.Interval)
+	default:
+		steps = civilDays(period, target) / r.Interval
+		return period.AddDate(0, 0, steps*r.Interval)
+	}
+}
+
+func (r RRule) nextPeriod(period time.Time) time.Time {
+	switch r.Freq {
+	case FreqYearly:
+		return period.AddDate(r.Interval, 0, 0)
+	case FreqMonthly:
+		return period.AddDate(0, r.Interval, 0)
+	case FreqWeekly:
+		return period.AddDate(0, 0, 7*r.Interval)
+	default:
+		return period.AddDate(0, 0, r.Interval)
+	}
+}
+
+func (r RRule) periodDays(period time.Time) []time.Time {
+	var end time.Time
+	switch r.Freq {
+	case FreqYearly:
+		end = period.AddDate(1, 0, 0)
+	case FreqMonthly:
+		end = period.AddDate(0, 1, 0)
+	case FreqWeekly:
+		end = period.AddDate(0, 0, 7)
+	default:
+		return []time.Time{period}
+	}
+	days := make([]time.Time, 0, civilDays(period, end))
+	for day := period; day.Before(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// matchDay reports whether the civil date day passes the rule's day filters.
+// Without any day-level part the date of start is implied, as RFC 5545
+// describes.
+func (r RRule) matchDay(day, start time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(day.Month())) {
+		return false
+	}
+	if len(r.ByWeekNo) > 0 {
+		week, weeks := weekNumber(day, r.WeekStart)
+		if !matchesSigned(r.ByWeekNo, week, weeks) {
+			return false
+		}
+	}
+	if len(r.ByYearDay) > 0 && !matchesSigned(r.ByYearDay, day.YearDay(), daysIn(day.Year())) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 && !matchesSigned(r.ByMonthDay, day.Day(), daysInMonth(day.Year(), day.Month())) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !r.matchWeekday(day) {
+		return false
+	}
+	if len(r.ByWeekNo)+len(r.ByYearDay)+len(r.ByMonthDay)+len(r.ByDay) > 0 {
+		return true
+	}
+	switch r.Freq {
+	case FreqYearly:
+		if len(r.ByMonth) == 0 && day.Month() != start.Month() {
+			return false
+		}
+		return day.Day() == start.Day()
+	case FreqMonthly:
+		return day.Day() == start.Day()
+	case FreqWeekly:
+		return day.Weekday() == start.Weekday()
+	}
+	return true
+}
+
+// matchWeekday checks BYDAY. Ordinals count within the month for MONTHLY
+// rules and YEARLY rules with BYMONTH, and within the year otherwise.
+func (r RRule) matchWeekday(day time.Time) bool {
+	for _, want := range r.ByDay {
+		if want.Day != day.Weekday() {
+			continue
+		}
+		if want.N == 0 {
+			return true
+		}
+		pos, last := day.YearDay(), daysIn(day.Year())
+		if r.Freq == FreqMonthly || len(r.ByMonth) > 0 {
+			pos, last = day.Day(), daysInMonth(day.Year(), day.Month())
+		}
+		if want.N > 0 && (pos-1)/7+1 == want.N || want.N < 0 && -((last-pos)/7+1) == want.N {
+			return true
+		}
+	}
+	return false
+}
+
+// weekNumber numbers day's week the RFC 5545 way: weeks begin on wkst and
+// week 1 is the first with at least four days in the year. It also returns
+// how many weeks that week's year has.
+func weekNumber(day time.Time, wkst time.Weekday) (week, weeks int) {
+	year := day.Year()
+	first := firstWeekStart(year, wkst)
+	if day.Before(first) {
+		year--
+		first = firstWeekStart(year, wkst)
+	} else if next := firstWeekStart(year+1, wkst); !day.Before(next) {
+		year++
+		first = next
+	}
+	weeks = civilDays(first, firstWeekStart(year+1, wkst)) / 7
+	return civilDays(first, day)/7 + 1, weeks
+}
+
+func firstWeekStart(year int, wkst time.Weekday) time.Time {
+	jan1 := civilDate(year, time.January, 1)
+	offset := daysSinceWeekStart(jan1.Weekday(), wkst)
+	start := jan1.AddDate(0, 0, -offset)
+	if offset > 3 {
+		start = start.AddDate(0, 0, 7)
+	}
+	return start
+}
+
+func daysSinceWeekStart(day, wkst time.Weekday) int {
+	return (int(day) - int(wkst) + 7) % 7
+}
+
+// matchesSigned reports whether v (1-based, of n) is listed, counting
+// negative entries from the end.
+func matchesSigned(list []int, v, n int) bool {
+	for _, want := range list {
+		if want == v || want < 0 && n+want+1 == v {
+			return true
+		}
+	}
+	return false
+}
+
+func pickSetPos(set []time.Time, positions []int) []time.Time {
+	var out []time.Time
+	for _, pos := range positions {
+		i := pos - 1
+		if pos < 0 {
+			i = len(set) + pos
+		}
+		if i < 0 || i >= len(set) {
+			continue
+		}
+		dup := false
+		for _, t := range out {
+			dup = dup || t.Equal(set[i])
+		}
+		if !dup {
+			out = append(out, set[i])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// civilDate holds a calendar date as midnight UTC, so stepping it never meets
+// a DST change.
+func civilDate(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// civilTime is the instant at which loc's clocks show h:m:s on day's date.
+// As RFC 5545 asks, a time skipped by a DST gap takes the offset from before
+// the gap, so 02:30 on a spring-forward night is 03:30, and a time repeated
+// when clocks fall back is its first instance.
+func civilTime(day time.Time, h, m, s, ns int, loc *time.Location) time.Time {
+	naive := time.Date(day.Year(), day.Month(), day.Day(), h, m, s, ns, time.UTC)
+	_, before := naive.Add(-48 * time.Hour).In(loc).Zone()
+	_, after := naive.Add(48 * time.Hour).In(loc).Zone()
+	first := naive.Add(-time.Duration(before) * time.Second).In(loc)
+	second := naive.Add(-time.Duration(after) * time.Second).In(loc)
+	shows := func(t time.Time) bool {
+		y, mo, d := t.Date()
+		return y == naive.Year() && mo == naive.Month() && d == naive.Day() &&
+			t.Hour() == naive.Hour() && t.Minute() == naive.Minute() && t.Second() == naive.Second()
+	}
+	switch {
+	case shows(first) && shows(second) && second.Before(first):
+		return second
+	case !shows(first) && shows(second):
+		return second
+	default:
+		return first
+	}
+}
+
+func civilDays(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func daysIn(year int) int {
+	return civilDays(civilDate(year, time.January, 1), civilDate(year+1, time.January, 1))
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return civilDate(year, month+1, 0).Day()
+}
+
+func orDefault(values []int, fallback int) []int {
+	if len(values) == 0 {
+		return []int{fallback}
+	}
+	out := append([]int(nil), values...)
+	sort.Ints(out)
+	return out
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func parseInts(value string) ([]int, error) {
+	items := strings.Split(value, ",")
+	out := make([]int, 0, len(items))
+	for _, item := range items {
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(item), "+"))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("bad weekday %q", s)
+	}
+	var out WeekdayNum
+	if prefix := strings.TrimPrefix(s[:len(s)-2], "+"); prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 {
+			return WeekdayNum{}, fmt.Errorf("bad weekday %q", s)
+		}
+		out.N = n
+	}
+	for i, name := range rruleDays {
+		if s[len(s)-2:] == name {
+			out.Day = time.Weekday(i)
+			return out, nil
+		}
+	}
+	return WeekdayNum{}, fmt.Errorf("bad weekday %q", s)
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if len(value) == 8 {
+		day, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return day.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return parseICalTime(value, loc)
+}
+
+// parseICalTime reads an RFC 5545 DATE-TIME: UTC with a trailing Z, else
+// local time in loc.
+func parseICalTime(value string, loc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
@@ -0,0 +1,455 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expandSet returns up to n occurrences of the set, written as local
+// yyyymmdd, with THHMM appended when the time differs from DTSTART's.
+func expandSet(t *testing.T, text string, n int) []string {
+	t.Helper()
+	set, err := ParseRecurrenceSet(text, time.Time{})
+	if err != nil {
+		t.Fatalf("parse %q: %v", text, err)
+	}
+	clock := set.Start.Format("1504")
+	var out []string
+	cursor := set.Start.Add(-time.Second)
+	for len(out) < n {
+		at, ok := set.After(cursor)
+		if !ok {
+			break
+		}
+		s := at.Format("20060102")
+		if at.Format("1504") != clock {
+			s += at.Format("T1504")
+		}
+		out = append(out, s)
+		cursor = at
+	}
+	return out
+}
+
+func dates(list string) []string {
+	return strings.Fields(list)
+}
+
+// The cases are the examples of RFC 5545 section 3.8.5.3 that use daily or
+// coarser frequencies, all in America/New_York, so several cross a DST change
+// and must keep 09:00 local time.
+func TestRRuleExpandsRFC5545Examples(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		n    int
+		want []string
+	}{
+		{
+			name: "daily for 10 occurrences",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;COUNT=10",
+			n:    20,
+			want: dates("19970902 19970903 19970904 19970905 19970906 19970907 19970908 19970909 19970910 19970911"),
+		},
+		{
+			name: "every other day",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;INTERVAL=2",
+			n:    5,
+			want: dates("19970902 19970904 19970906 19970908 19970910"),
+		},
+		{
+			name: "every 10 days, 5 occurrences",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;INTERVAL=10;COUNT=5",
+			n:    10,
+			want: dates("19970902 19970912 19970922 19971002 19971012"),
+		},
+		{
+			name: "weekly for 10 occurrences across the end of DST",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=WEEKLY;COUNT=10",
+			n:    20,
+			want: dates("19970902 19970909 19970916 19970923 19970930 19971007 19971014 19971021 19971028 19971104"),
+		},
+		{
+			name: "every other week",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;WKST=SU",
+			n:    9,
+			want: dates("19970902 19970916 19970930 19971014 19971028 19971111 19971125 19971209 19971223"),
+		},
+		{
+			name: "weekly on Tuesday and Thursday for five weeks",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=WEEKLY;UNTIL=19971007T000000Z;WKST=SU;BYDAY=TU,TH",
+			n:    20,
+			want: dates("19970902 19970904 19970909 19970911 19970916 19970918 19970923 19970925 19970930 19971002"),
+		},
+		{
+			name: "every other week on Monday, Wednesday and Friday until December 24",
+			text: "DTSTART;TZID=America/New_York:19970901T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=19971224T000000Z;WKST=SU;BYDAY=MO,WE,FR",
+			n:    40,
+			want: dates("19970901 19970903 19970905 19970915 19970917 19970919 19970929 19971001 19971003 19971013 19971015 19971017 19971027 19971029 19971031 19971110 19971112 19971114 19971124 19971126 19971128 19971208 19971210 19971212 19971222"),
+		},
+		{
+			name: "every other week on Tuesday and Thursday for 8 occurrences",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=8;WKST=SU;BYDAY=TU,TH",
+			n:    20,
+			want: dates("19970902 19970904 19970916 19970918 19970930 19971002 19971014 19971016"),
+		},
+		{
+			name: "monthly on the first Friday",
+			text: "DTSTART;TZID=America/New_York:19970905T090000\nRRULE:FREQ=MONTHLY;COUNT=10;BYDAY=1FR",
+			n:    20,
+			want: dates("19970905 19971003 19971107 19971205 19980102 19980206 19980306 19980403 19980501 19980605"),
+		},
+		{
+			name: "every other month on the first and last Sunday",
+			text: "DTSTART;TZID=America/New_York:19970907T090000\nRRULE:FREQ=MONTHLY;INTERVAL=2;COUNT=10;BYDAY=1SU,-1SU",
+			n:    20,
+			want: dates("19970907 19970928 19971102 19971130 19980104 19980125 19980301 19980329 19980503 19980531"),
+		},
+		{
+			name: "monthly on the second-to-last Monday",
+			text: "DTSTART;TZID=America/New_York:19970922T090000\nRRULE:FREQ=MONTHLY;COUNT=6;BYDAY=-2MO",
+			n:    20,
+			want: dates("19970922 19971020 19971117 19971222 19980119 19980216"),
+		},
+		{
+			name: "monthly on the third-to-last day",
+			text: "DTSTART;TZID=America/New_York:19970928T090000\nRRULE:FREQ=MONTHLY;BYMONTHDAY=-3",
+			n:    6,
+			want: dates("19970928 19971029 19971128 19971229 19980129 19980226"),
+		},
+		{
+			name: "monthly on the 2nd and 15th",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=MONTHLY;COUNT=10;BYMONTHDAY=2,15",
+			n:    20,
+			want: dates("19970902 19970915 19971002 19971015 19971102 19971115 19971202 19971215 19980102 19980115"),
+		},
+		{
+			name: "monthly on the first and last day",
+			text: "DTSTART;TZID=America/New_York:19970930T090000\nRRULE:FREQ=MONTHLY;COUNT=10;BYMONTHDAY=1,-1",
+			n:    20,
+			want: dates("19970930 19971001 19971031 19971101 19971130 19971201 19971231 19980101 19980131 19980201"),
+		},
+		{
+			name: "every 18 months on the 10th to 15th",
+			text: "DTSTART;TZID=America/New_York:19970910T090000\nRRULE:FREQ=MONTHLY;INTERVAL=18;COUNT=10;BYMONTHDAY=10,11,12,13,14,15",
+			n:    20,
+			want: dates("19970910 19970911 19970912 19970913 19970914 19970915 19990310 19990311 19990312 19990313"),
+		},
+		{
+			name: "every Tuesday, every other month",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=TU",
+			n:    18,
+			want: dates("19970902 19970909 19970916 19970923 19970930 19971104 19971111 19971118 19971125 19980106 19980113 19980120 19980127 19980303 19980310 19980317 19980324 19980331"),
+		},
+		{
+			name: "yearly in June and July",
+			text: "DTSTART;TZID=America/New_York:19970610T090000\nRRULE:FREQ=YEARLY;COUNT=10;BYMONTH=6,7",
+			n:    20,
+			want: dates("19970610 19970710 19980610 19980710 19990610 19990710 20000610 20000710 20010610 20010710"),
+		},
+		{
+			name: "every other year in January, February and March",
+			text: "DTSTART;TZID=America/New_York:19970310T090000\nRRULE:FREQ=YEARLY;INTERVAL=2;COUNT=10;BYMONTH=1,2,3",
+			n:    20,
+			want: dates("19970310 19990110 19990210 19990310 20010110 20010210 20010310 20030110 20030210 20030310"),
+		},
+		{
+			name: "every third year on the 1st, 100th and 200th day",
+			text: "DTSTART;TZID=America/New_York:19970101T090000\nRRULE:FREQ=YEARLY;INTERVAL=3;COUNT=10;BYYEARDAY=1,100,200",
+			n:    20,
+			want: dates("19970101 19970410 19970719 20000101 20000409 20000718 20030101 20030410 20030719 20060101"),
+		},
+		{
+			name: "every 20th Monday of the year",
+			text: "DTSTART;TZID=America/New_York:19970519T090000\nRRULE:FREQ=YEARLY;BYDAY=20MO",
+			n:    3,
+			want: dates("19970519 19980518 19990517"),
+		},
+		{
+			name: "Monday of week 20",
+			text: "DTSTART;TZID=America/New_York:19970512T090000\nRRULE:FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO",
+			n:    3,
+			want: dates("19970512 19980511 19990517"),
+		},
+		{
+			name: "every Thursday in March",
+			text: "DTSTART;TZID=America/New_York:19970313T090000\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=TH",
+			n:    11,
+			want: dates("19970313 19970320 19970327 19980305 19980312 19980319 19980326 19990304 19990311 19990318 19990325"),
+		},
+		{
+			name: "every Thursday in June, July and August",
+			text: "DTSTART;TZID=America/New_York:19970605T090000\nRRULE:FREQ=YEARLY;BYDAY=TH;BYMONTH=6,7,8",
+			n:    14,
+			want: dates("19970605 19970612 19970619 19970626 19970703 19970710 19970717 19970724 19970731 19970807 19970814 19970821 19970828 19980604"),
+		},
+		{
+			name: "every Friday the 13th, excluding DTSTART",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nEXDATE;TZID=America/New_York:19970902T090000\nRRULE:FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			n:    5,
+			want: dates("19980213 19980313 19981113 19990813 20001013"),
+		},
+		{
+			name: "first Saturday after the first Sunday",
+			text: "DTSTART;TZID=America/New_York:19970913T090000\nRRULE:FREQ=MONTHLY;BYDAY=SA;BYMONTHDAY=7,8,9,10,11,12,13",
+			n:    10,
+			want: dates("19970913 19971011 19971108 19971213 19980110 19980207 19980307 19980411 19980509 19980613"),
+		},
+		{
+			name: "US presidential election day",
+			text: "DTSTART;TZID=America/New_York:19961105T090000\nRRULE:FREQ=YEARLY;INTERVAL=4;BYMONTH=11;BYDAY=TU;BYMONTHDAY=2,3,4,5,6,7,8",
+			n:    3,
+			want: dates("19961105 20001107 20041102"),
+		},
+		{
+			name: "third Tuesday, Wednesday or Thursday of the month",
+			text: "DTSTART;TZID=America/New_York:19970904T090000\nRRULE:FREQ=MONTHLY;COUNT=3;BYDAY=TU,WE,TH;BYSETPOS=3",
+			n:    10,
+			want: dates("19970904 19971007 19971106"),
+		},
+		{
+			name: "second-to-last weekday of the month",
+			text: "DTSTART;TZID=America/New_York:19970929T090000\nRRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-2",
+			n:    7,
+			want: dates("19970929 19971030 19971127 19971230 19980129 19980226 19980330"),
+		},
+		{
+			name: "WKST=MO changes which days pair up",
+			text: "DTSTART;TZID=America/New_York:19970805T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			n:    10,
+			want: dates("19970805 19970810 19970819 19970824"),
+		},
+		{
+			name: "WKST=SU changes which days pair up",
+			text: "DTSTART;TZID=America/New_York:19970805T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			n:    10,
+			want: dates("19970805 19970817 19970819 19970831"),
+		},
+		{
+			name: "invalid dates are skipped",
+			text: "DTSTART;TZID=America/New_York:20070115T090000\nRRULE:FREQ=MONTHLY;BYMONTHDAY=15,30;COUNT=5",
+			n:    10,
+			want: dates("20070115 20070130 20070215 20070315 20070330"),
+		},
+		{
+			name: "several times a day",
+			text: "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;BYHOUR=9,10;BYMINUTE=0,30",
+			n:    6,
+			want: dates("19970902 19970902T0930 19970902T1000 19970902T1030 19970903 19970903T0930"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := expandSet(t, tc.text, tc.n)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expansion mismatch\n got: %v\nwant: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRRuleEndsWithUntil(t *testing.T) {
+	text := "DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=DAILY;UNTIL=19971224T000000Z"
+	got := expandSet(t, text, 200)
+	if len(got) != 113 || got[len(got)-1] != "19971223" {
+		t.Fatalf("expected 113 days ending 1997-12-23, got %d ending %v", len(got), got[len(got)-1])
+	}
+
+	yearly := expandSet(t, "DTSTART;TZID=America/New_York:19980101T090000\nRRULE:FREQ=YEARLY;UNTIL=20000131T140000Z;BYMONTH=1;BYDAY=SU,MO,TU,WE,TH,FR,SA", 200)
+	daily := expandSet(t, "DTSTART;TZID=America/New_York:19980101T090000\nRRULE:FREQ=DAILY;UNTIL=20000131T140000Z;BYMONTH=1", 200)
+	if len(yearly) != 93 || !reflect.DeepEqual(yearly, daily) {
+		t.Fatalf("expected every January day of 1998-2000 both ways, got %d and %d", len(yearly), len(daily))
+	}
+
+	dateOnly := expandSet(t, "DTSTART:20260301T090000Z\nRRULE:FREQ=DAILY;UNTIL=20260303", 10)
+	if !reflect.DeepEqual(dateOnly, dates("20260301 20260302 20260303")) {
+		t.Fatalf("expected a date UNTIL to include its day, got %v", dateOnly)
+	}
+}
+
+func TestRecurrenceSetCombinesRDateAndExDate(t *testing.T) {
+	text := strings.Join([]string{
+		"DTSTART;TZID=Europe/Berlin:20260302T090000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		"EXDATE;TZID=Europe/Berlin:20260304T090000,20260309T090000",
+		"RDATE;VALUE=DATE:20260307",
+		"EXDATE:20260316T080000Z",
+		"RDATE;VALUE=PERIOD:20260320T150000Z/PT1H",
+	}, "\r\n")
+	got := expandSet(t, text, 6)
+	want := dates("20260302 20260307 20260311 20260318 20260320T1600 20260323")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expansion mismatch\n got: %v\nwant: %v", got, want)
+	}
+
+	folded := "DTSTART:20260302T090000Z\nRRULE:FREQ=DAILY;\n COUNT=2"
+	if got := expandSet(t, folded, 5); !reflect.DeepEqual(got, dates("20260302 20260303")) {
+		t.Fatalf("expected folded lines to be joined, got %v", got)
+	}
+
+	set, err := ParseRecurrenceSet(text, time.Time{})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	between := set.Between(time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC))
+	if len(between) != 2 || between[0].Day() != 7 || between[1].Day() != 11 {
+		t.Fatalf("unexpected Between result: %v", between)
+	}
+}
+
+func TestRecurrenceSetStringRoundTrips(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, berlin)
+	texts := []string{
+		"RRULE:FREQ=MONTHLY;BYDAY=2TU",
+		"RRULE:FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=15",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=10;WKST=SU;BYDAY=TU,TH",
+		"RRULE:FREQ=DAILY;UNTIL=20261231T230000Z;BYHOUR=9,17;BYMINUTE=30\nEXDATE;TZID=Europe/Berlin:20260303T093000",
+		"RRULE:FREQ=YEARLY;BYWEEKNO=1,-1;BYYEARDAY=-1;BYDAY=MO;BYSETPOS=1\nRDATE;TZID=Europe/Berlin:20260401T090000",
+	}
+	for _, text := range texts {
+		set, err := ParseRecurrenceSet(text, start)
+		if err != nil {
+			t.Fatalf("parse %q: %v", text, err)
+		}
+		if got := set.String(); got != text {
+			t.Fatalf("round trip of %q gave %q", text, got)
+		}
+	}
+	if rule, err := ParseRRule("rrule:freq=monthly;byday=+2tu", nil); err != nil || rule.String() != "FREQ=MONTHLY;BYDAY=2TU" {
+		t.Fatalf("expected case-insensitive parsing, got %q, %v", rule.String(), err)
+	}
+}
+
+func TestParseRRuleRejectsInvalidRules(t *testing.T) {
+	cases := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=FORTNIGHTLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20260101T000000Z",
+		"FREQ=DAILY;COUNT=3;COUNT=4",
+		"FREQ=DAILY;COLOR=red",
+		"FREQ=DAILY;BYMONTH=13",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYWEEKNO=1",
+		"FREQ=MONTHLY;BYYEARDAY=1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYWEEKNO=1;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;WKST=1MO",
+		"FREQ",
+	}
+	for _, text := range cases {
+		if _, err := ParseRRule(text, time.UTC); !errors.Is(err, ErrInvalidRRule) {
+			t.Fatalf("expected ErrInvalidRRule for %q, got %v", text, err)
+		}
+	}
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	for _, text := range []string{
+		"EXDATE:20260303T090000Z",
+		"DTSTART:soon\nRRULE:FREQ=DAILY",
+		"SUMMARY:stand-up\nRRULE:FREQ=DAILY",
+		"EXDATE;TZID=Mars/Olympus:20260303T090000\nRRULE:FREQ=DAILY",
+	} {
+		if _, err := ParseRecurrenceSet(text, start); !errors.Is(err, ErrInvalidRRule) {
+			t.Fatalf("expected ErrInvalidRRule for %q, got %v", text, err)
+		}
+	}
+	if _, err := ParseRecurrenceSet("RRULE:FREQ=DAILY", time.Time{}); !errors.Is(err, ErrInvalidRRule) {
+		t.Fatalf("expected a missing DTSTART to be rejected, got %v", err)
+	}
+}
+
+func TestRRuleRecurrenceNextAfterAndPreview(t *testing.T) {
+	rule := RecurrenceRule{
+		Type:     RecurrenceRRule,
+		Interval: 1,
+		Anchor:   time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC),
+		RRule:    "RRULE:FREQ=MONTHLY;BYDAY=2TU;COUNT=3",
+	}
+	next, err := rule.NextAfter(time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), nil)
+	if err != nil || !next.Equal(time.Date(2026, 4, 14, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the second Tuesday of April, got %v, %v", next, err)
+	}
+	preview, err := rule.Preview(rule.Anchor.Add(-time.Minute), nil, 5)
+	if err != nil || len(preview) != 3 || !preview[2].Equal(time.Date(2026, 5, 12, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the preview to stop after COUNT, got %v, %v", preview, err)
+	}
+	if _, err := rule.NextAfter(preview[2], nil); !errors.Is(err, ErrRecurrenceEnded) {
+		t.Fatalf("expected ErrRecurrenceEnded, got %v", err)
+	}
+
+	// A rule that can never match is rejected up front, and only the first
+	// check pays for searching the whole horizon.
+	never := RecurrenceRule{Type: RecurrenceRRule, Interval: 1, Anchor: rule.Anchor, RRule: "FREQ=DAILY;BYMONTH=2;BYMONTHDAY=30"}
+	if err := never.Validate(); !errors.Is(err, ErrInvalidRRule) {
+		t.Fatalf("expected a rule without dates to be invalid, got %v", err)
+	}
+	started := time.Now()
+	for i := 0; i < 50; i++ {
+		if _, err := never.NextAfter(rule.Anchor, nil); !errors.Is(err, ErrInvalidRRule) {
+			t.Fatalf("expected NextAfter to reject the rule, got %v", err)
+		}
+	}
+	if took := time.Since(started); took > time.Second {
+		t.Fatalf("re-checking a rule without dates took %v", took)
+	}
+
+	for _, bad := range []RecurrenceRule{
+		{Type: RecurrenceRRule, Interval: 1, Anchor: rule.Anchor},
+		{Type: RecurrenceRRule, Interval: 1, Anchor: rule.Anchor, RRule: "FREQ=SOMETIMES"},
+		{Type: RecurrenceRRule, Interval: 1, Anchor: rule.Anchor, RRule: "FREQ=YEARLY;INTERVAL=4;BYMONTH=2;BYMONTHDAY=29"},
+		{Type: RecurrenceEveryNDays, Interval: 1, Anchor: rule.Anchor, RRule: "FREQ=DAILY"},
+	} {
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidRRule) {
+			t.Fatalf("expected ErrInvalidRRule for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestBuiltInRecurrencesMatchTheirRRules(t *testing.T) {
+	anchor := time.Date(2026, 1, 14, 9, 30, 0, 0, time.UTC) // Wednesday
+	rules := []RecurrenceRule{
+		{Type: RecurrenceEveryWeekday, Interval: 1, Anchor: anchor},
+		{Type: RecurrenceEveryWeekday, Interval: 1, Anchor: anchor, Weekdays: []time.Weekday{time.Tuesday, time.Saturday}},
+		{Type: RecurrenceEveryNDays, Interval: 3, Anchor: anchor},
+		{Type: RecurrenceEveryNWeeks, Interval: 2, Anchor: anchor},
+		{Type: RecurrenceLastDayOfMonth, Interval: 1, Anchor: anchor},
+	}
+	for _, rule := range rules {
+		set, err := rule.Set()
+		if err != nil {
+			t.Fatalf("%s: set: %v", rule.Type, err)
+		}
+		asRRule := RecurrenceRule{Type: RecurrenceRRule, Interval: 1, Anchor: anchor, RRule: set.String()}
+		want, err := rule.Preview(anchor, nil, 12)
+		if err != nil {
+			t.Fatalf("%s: preview: %v", rule.Type, err)
+		}
+		got, err := asRRule.Preview(anchor, nil, 12)
+		if err != nil {
+			t.Fatalf("%s as %q: preview: %v", rule.Type, set.String(), err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s as %q differs\n got: %v\nwant: %v", rule.Type, set.String(), got, want)
+		}
+	}
+
+	after := RecurrenceRule{Type: RecurrenceAfterComplete, Interval: 1, Anchor: anchor}
+	if _, err := after.Set(); !errors.Is(err, ErrNotExpressible) {
+		t.Fatalf("expected after_completion to have no rrule, got %v", err)
+	}
+}
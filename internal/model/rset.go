@@ -0,0 +1,334 @@
+package model
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecurrenceSet is an RFC 5545 recurrence set: the occurrences of its
+// RRULEs from Start, plus RDates, minus ExDates.
+type RecurrenceSet struct {
+	Start   time.Time
+	RRules  []RRule
+	RDates  []time.Time
+	ExDates []time.Time
+}
+
+// ParseRecurrenceSet reads RRULE, RDATE, EXDATE and optionally DTSTART
+// content lines, one per line, for example
+//
+//	RRULE:FREQ=MONTHLY;BYDAY=2TU
+//	EXDATE;TZID=Europe/Berlin:20260310T090000
+//
+// A bare rule value ("FREQ=DAILY") is read as an RRULE line. start is used
+// when there is no DTSTART line; floating times are read in its location and
+// DATE values for RDATE and EXDATE take its time of day.
+func ParseRecurrenceSet(text string, start time.Time) (RecurrenceSet, error) {
+	lines := unfoldLines(text)
+	set := RecurrenceSet{Start: start}
+	for _, line := range lines {
+		name, params, value, err := splitContentLine(line)
+		if err != nil {
+			return RecurrenceSet{}, err
+		}
+		if name == "DTSTART" {
+			times, err := parseDateList(params, value, time.Time{}, start.Location())
+			if err != nil || len(times) != 1 {
+				return RecurrenceSet{}, fmt.Errorf("%w: DTSTART %q", ErrInvalidRRule, value)
+			}
+			set.Start = times[0]
+		}
+	}
+	if set.Start.IsZero() {
+		return RecurrenceSet{}, fmt.Errorf("%w: DTSTART is required", ErrInvalidRRule)
+	}
+
+	loc := set.Start.Location()
+	for _, line := range lines {
+		name, params, value, _ := splitContentLine(line)
+		switch name {
+		case "DTSTART":
+		case "RRULE":
+			rule, err := ParseRRule(value, loc)
+			if err != nil {
+				return RecurrenceSet{}, err
+			}
+			set.RRules = append(set.RRules, rule)
+		case "RDATE", "EXDATE":
+			times, err := parseDateList(params, value, set.Start, loc)
+			if err != nil {
+				return RecurrenceSet{}, fmt.Errorf("%w: %s: %v", ErrInvalidRRule, name, err)
+			}
+			for i := range times {
+				times[i] = times[i].In(loc)
+			}
+			if name == "RDATE" {
+				set.RDates = append(set.RDates, times...)
+			} else {
+				set.ExDates = append(set.ExDates, times...)
+			}
+		default:
+			return RecurrenceSet{}, fmt.Errorf("%w: unsupported property %s", ErrInvalidRRule, name)
+		}
+	}
+	if len(set.RRules) == 0 && len(set.RDates) == 0 {
+		return RecurrenceSet{}, fmt.Errorf("%w: no RRULE or RDATE", ErrInvalidRRule)
+	}
+	return set, nil
+}
+
+// After returns the first occurrence strictly after t.
+func (s RecurrenceSet) After(t time.Time) (time.Time, bool) {
+	var best time.Time
+	found := false
+	consider := func(at time.Time) {
+		if !found || at.Before(best) {
+			best, found = at, true
+		}
+	}
+	for _, rule := range s.RRules {
+		rule.Expand(s.Start, t, func(at time.Time) bool {
+			if s.excluded(at) {
+				return true
+			}
+			consider(at)
+			return false
+		})
+	}
+	for _, at := range s.RDates {
+		if at.After(t) && !s.excluded(at) {
+			consider(at)
+		}
+	}
+	return best, found
+}
+
+// Each calls fn with every occurrence strictly after from, in order, until
+// fn returns false. Unlike repeated calls to After, it expands each RRULE
+// once, so walking n occurrences of a COUNT rule costs O(n).
+func (s RecurrenceSet) Each(from time.Time, fn func(time.Time) bool) {
+	var nexts []func() (time.Time, bool)
+	for _, rule := range s.RRules {
+		next, stop := iter.Pull(func(yield func(time.Time) bool) { rule.Expand(s.Start, from, yield) })
+		defer stop()
+		nexts = append(nexts, next)
+	}
+	var rdates []time.Time
+	for _, at := range s.RDates {
+		if at.After(from) {
+			rdates = append(rdates, at)
+		}
+	}
+	sort.Slice(rdates, func(i, j int) bool { return rdates[i].Before(rdates[j]) })
+	nexts = append(nexts, func() (time.Time, bool) {
+		if len(rdates) == 0 {
+			return time.Time{}, false
+		}
+		at := rdates[0]
+		rdates = rdates[1:]
+		return at, true
+	})
+
+	heads := make([]time.Time, len(nexts))
+	live := make([]bool, len(nexts))
+	for i, next := range nexts {
+		heads[i], live[i] = next()
+	}
+	var last time.Time
+	for {
+		min := -1
+		for i := range heads {
+			if live[i] && (min < 0 || heads[i].Before(heads[min])) {
+				min = i
+			}
+		}
+		if min < 0 {
+			return
+		}
+		at := heads[min]
+		heads[min], live[min] = nexts[min]()
+		// Several rules, or a rule and an RDATE, may produce the same instant.
+		if s.excluded(at) || (!last.IsZero() && at.Equal(last)) {
+			continue
+		}
+		last = at
+		if !fn(at) {
+			return
+		}
+	}
+}
+
+// occursCache remembers whether a set has any occurrence, keyed by its text
+// and start. A set that never matches is only found out by searching the
+// whole rruleHorizon, and every NextAfter validates its rule first.
+var occursCache = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: make(map[string]bool)}
+
+// occursCacheSize bounds occursCache; it is emptied when full.
+const occursCacheSize = 256
+
+// occurs reports whether s has any occurrence on or after Start.
+func (s RecurrenceSet) occurs() bool {
+	key := s.String() + "\x00" + s.Start.Format(time.RFC3339Nano) + "\x00" + s.Start.Location().String()
+	occursCache.Lock()
+	ok, seen := occursCache.seen[key]
+	occursCache.Unlock()
+	if seen {
+		return ok
+	}
+	_, ok = s.After(s.Start.Add(-time.Nanosecond))
+	occursCache.Lock()
+	if len(occursCache.seen) >= occursCacheSize {
+		clear(occursCache.seen)
+	}
+	occursCache.seen[key] = ok
+	occursCache.Unlock()
+	return ok
+}
+
+// Between returns the occurrences after from and up to to, in order.
+func (s RecurrenceSet) Between(from, to time.Time) []time.Time {
+	var out []time.Time
+	for _, rule := range s.RRules {
+		rule.Expand(s.Start, from, func(at time.Time) bool {
+			if at.After(to) {
+				return false
+			}
+			if !s.excluded(at) {
+				out = append(out, at)
+			}
+			return true
+		})
+	}
+	for _, at := range s.RDates {
+		if at.After(from) && !at.After(to) && !s.excluded(at) {
+			out = append(out, at)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	// Several rules, or a rule and an RDATE, may produce the same instant.
+	uniq := out[:0]
+	for i, at := range out {
+		if i == 0 || !at.Equal(out[i-1]) {
+			uniq = append(uniq, at)
+		}
+	}
+	return uniq
+}
+
+func (s RecurrenceSet) excluded(at time.Time) bool {
+	for _, ex := range s.ExDates {
+		if ex.Equal(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// String writes the set's RRULE, RDATE and EXDATE lines. DTSTART is left
+// out: the rule's anchor carries it. Dates are written in Start's zone.
+func (s RecurrenceSet) String() string {
+	lines := make([]string, 0, len(s.RRules)+2)
+	for _, rule := range s.RRules {
+		lines = append(lines, "RRULE:"+rule.String())
+	}
+	if len(s.RDates) > 0 {
+		lines = append(lines, "RDATE"+formatDateList(s.RDates, s.Start.Location()))
+	}
+	if len(s.ExDates) > 0 {
+		lines = append(lines, "EXDATE"+formatDateList(s.ExDates, s.Start.Location()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatDateList(times []time.Time, loc *time.Location) string {
+	values := make([]string, len(times))
+	if loc == nil || loc == time.UTC || loc.String() == "UTC" {
+		for i, t := range times {
+			values[i] = t.UTC().Format("20060102T150405Z")
+		}
+		return ":" + strings.Join(values, ",")
+	}
+	for i, t := range times {
+		values[i] = t.In(loc).Format("20060102T150405")
+	}
+	return ";TZID=" + loc.String() + ":" + strings.Join(values, ",")
+}
+
+// unfoldLines joins folded content lines and drops blank ones.
+func unfoldLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(out) > 0 {
+			out[len(out)-1] += line[1:]
+			continue
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func splitContentLine(line string) (name string, params map[string]string, value string, err error) {
+	if strings.HasPrefix(strings.ToUpper(line), "FREQ=") {
+		return "RRULE", nil, line, nil
+	}
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", fmt.Errorf("%w: %q is not a content line", ErrInvalidRRule, line)
+	}
+	fields := strings.Split(head, ";")
+	name = strings.ToUpper(strings.TrimSpace(fields[0]))
+	params = make(map[string]string, len(fields)-1)
+	for _, f := range fields[1:] {
+		k, v, _ := strings.Cut(f, "=")
+		params[strings.ToUpper(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	return name, params, strings.TrimSpace(value), nil
+}
+
+// parseDateList reads a comma-separated DATE, DATE-TIME or PERIOD list. A
+// TZID parameter overrides loc. DATE values take clock's time of day; a
+// PERIOD contributes its start.
+func parseDateList(params map[string]string, value string, clock time.Time, loc *time.Location) ([]time.Time, error) {
+	if tzid := params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return nil, fmt.Errorf("unknown TZID %q", tzid)
+		}
+	}
+	kind := strings.ToUpper(params["VALUE"])
+	var out []time.Time
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if kind == "PERIOD" {
+			item, _, _ = strings.Cut(item, "/")
+		}
+		if kind == "DATE" || len(item) == 8 {
+			day, err := time.ParseInLocation("20060102", item, loc)
+			if err != nil {
+				return nil, err
+			}
+			if !clock.IsZero() {
+				c := clock.In(loc)
+				day = time.Date(day.Year(), day.Month(), day.Day(), c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), loc)
+			}
+			out = append(out, day)
+			continue
+		}
+		at, err := parseICalTime(item, loc)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, at)
+	}
+	return out, nil
+}
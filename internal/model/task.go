@@ -8,9 +8,14 @@ import (
 )
 
 var (
-	ErrInvalidState    = errors.New("model: invalid task state")
-	ErrInvalidPriority = errors.New("model: invalid task priority")
-	ErrInvalidEnergy   = errors.New("model: invalid task energy")
+	ErrInvalidState        = errors.New("model: invalid task state")
+	ErrInvalidPriority     = errors.New("model: invalid task priority")
+	ErrInvalidEnergy       = errors.New("model: invalid task energy")
+	ErrTaskIDRequired      = errors.New("model: task id is required")
+	ErrTaskTitleRequired   = errors.New("model: task title is required")
+	ErrCreatedAtRequired   = errors.New("model: task created_at is required")
+	ErrCompletedAtRequired = errors.New("model: completed_at is required when task state is Done")
+	ErrCompletedAtNotDone  = errors.New("model: completed_at must be nil when task state is not Done")
 )
 
 type TaskState string
@@ -75,16 +80,18 @@ type Task struct {
 	Priority    Priority
 	Energy      Energy
 	Tags        []string
+	ScheduledAt *time.Time
+	DueAt       *time.Time
 	CreatedAt   time.Time
 	CompletedAt *time.Time
 }
 
 func (t Task) Validate() error {
 	if strings.TrimSpace(t.ID) == "" {
-		return errors.New("model: task id is required")
+		return ErrTaskIDRequired
 	}
 	if strings.TrimSpace(t.Title) == "" {
-		return errors.New("model: task title is required")
+		return ErrTaskTitleRequired
 	}
 	if !t.State.IsValid() {
 		return fmt.Errorf("%w: %q", ErrInvalidState, t.State)
@@ -96,13 +103,13 @@ func (t Task) Validate() error {
 		return fmt.Errorf("%w: %q", ErrInvalidEnergy, t.Energy)
 	}
 	if t.CreatedAt.IsZero() {
-		return errors.New("model: task created_at is required")
+		return ErrCreatedAtRequired
 	}
 	if t.State == TaskStateDone && t.CompletedAt == nil {
-		return errors.New("model: completed_at is required when task state is Done")
+		return ErrCompletedAtRequired
 	}
 	if t.State != TaskStateDone && t.CompletedAt != nil {
-		return errors.New("model: completed_at must be nil when task state is not Done")
+		return ErrCompletedAtNotDone
 	}
 	return nil
 }
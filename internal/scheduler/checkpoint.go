@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCatchUpPolicy = errors.New("scheduler: invalid catch-up policy")
+
+// CatchUpPolicy decides what happens to reminders whose trigger time passed
+// while the engine was not running.
+type CatchUpPolicy string
+
+const (
+	// CatchUpAll replays every missed reminder.
+	CatchUpAll CatchUpPolicy = "all"
+	// CatchUpLatest replays only the most recent missed reminder per task.
+	CatchUpLatest CatchUpPolicy = "latest"
+	// CatchUpSkip drops missed reminders.
+	CatchUpSkip CatchUpPolicy = "skip"
+)
+
+func ParseCatchUpPolicy(raw string) (CatchUpPolicy, error) {
+	switch p := CatchUpPolicy(strings.ToLower(strings.TrimSpace(raw))); p {
+	case CatchUpAll, CatchUpLatest, CatchUpSkip:
+		return p, nil
+	case "":
+		return CatchUpAll, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidCatchUpPolicy, raw)
+	}
+}
+
+// Checkpoint marks how far delivery got: LastTickAt is the trigger time of the
+// last delivered event and Cursor its ID.
+type Checkpoint struct {
+	LastTickAt time.Time
+	Cursor     string
+}
+
+// Delivered reports whether ev is at or behind the checkpoint.
+func (c Checkpoint) Delivered(ev ReminderEvent) bool {
+	if c.LastTickAt.IsZero() {
+		return false
+	}
+	if ev.TriggerAt.Before(c.LastTickAt) {
+		return true
+	}
+	return ev.TriggerAt.Equal(c.LastTickAt) && ev.ID == c.Cursor
+}
+
+// Advance moves the checkpoint to ev unless ev is older than it.
+func (c Checkpoint) Advance(ev ReminderEvent) Checkpoint {
+	if ev.TriggerAt.Before(c.LastTickAt) {
+		return c
+	}
+	return Checkpoint{LastTickAt: ev.TriggerAt, Cursor: ev.ID}
+}
+
+type RestoreReport struct {
+	Scheduled int
+	Replayed  int
+	Skipped   int
+}
+
+// Restore rehydrates the queue from persisted reminders. Events behind cp are
+// ignored, future events are scheduled as usual and events that came due
+// while the engine was down are replayed according to policy. Replayed events
+// keep their original trigger time, so they are delivered immediately.
+func (e *Engine) Restore(events []ReminderEvent, cp Checkpoint, now time.Time, policy CatchUpPolicy) (RestoreReport, error) {
+	var report RestoreReport
+	missed := make([]ReminderEvent, 0)
+	for _, ev := range events {
+		if cp.Delivered(ev) {
+			continue
+		}
+		if ev.TriggerAt.After(now) {
+			if err := e.Schedule(ev); err != nil {
+				return report, err
+			}
+			report.Scheduled++
+			continue
+		}
+		missed = append(missed, ev)
+	}
+
+	replay := missed
+	switch policy {
+	case CatchUpAll, "":
+	case CatchUpLatest:
+		replay = latestPerTask(missed)
+	case CatchUpSkip:
+		replay = nil
+	default:
+		return report, fmt.Errorf("%w: %q", ErrInvalidCatchUpPolicy, policy)
+	}
+	for _, ev := range replay {
+		if err := e.Schedule(ev); err != nil {
+			return report, err
+		}
+	}
+	report.Replayed = len(replay)
+	report.Skipped = len(missed) - len(replay)
+	return report, nil
+}
+
+func latestPerTask(events []ReminderEvent) []ReminderEvent {
+	latest := make(map[string]ReminderEvent)
+	for _, ev := range events {
+		key := ev.TaskID
+		if key == "" {
+			key = ev.ID
+		}
+		if cur, ok := latest[key]; !ok || ev.TriggerAt.After(cur.TriggerAt) {
+			latest[key] = ev
+		}
+	}
+	out := make([]ReminderEvent, 0, len(latest))
+	for _, ev := range latest {
+		out = append(out, ev)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TriggerAt.Before(out[j].TriggerAt) })
+	return out
+}
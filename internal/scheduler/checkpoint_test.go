@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEngineRestoreCatchUpPolicies(t *testing.T) {
+	now := time.Now().UTC()
+	lastTick := now.Add(-10 * time.Hour)
+	events := []ReminderEvent{
+		{ID: "delivered", TaskID: "a", TriggerAt: lastTick.Add(-time.Hour)},
+		{ID: "cursor", TaskID: "a", TriggerAt: lastTick},
+		{ID: "missed-a1", TaskID: "a", TriggerAt: now.Add(-8 * time.Hour)},
+		{ID: "missed-a2", TaskID: "a", TriggerAt: now.Add(-2 * time.Hour)},
+		{ID: "missed-b", TaskID: "b", TriggerAt: now.Add(-5 * time.Hour)},
+		{ID: "future", TaskID: "c", TriggerAt: now.Add(time.Hour)},
+	}
+	cp := Checkpoint{LastTickAt: lastTick, Cursor: "cursor"}
+
+	cases := []struct {
+		policy CatchUpPolicy
+		want   []string
+		report RestoreReport
+	}{
+		{CatchUpAll, []string{"missed-a1", "missed-a2", "missed-b"}, RestoreReport{Scheduled: 1, Replayed: 3}},
+		{CatchUpLatest, []string{"missed-a2", "missed-b"}, RestoreReport{Scheduled: 1, Replayed: 2, Skipped: 1}},
+		{CatchUpSkip, nil, RestoreReport{Scheduled: 1, Skipped: 3}},
+	}
+	for _, tc := range cases {
+		engine := NewEngine(8)
+		report, err := engine.Restore(events, cp, now, tc.policy)
+		if err != nil {
+			t.Fatalf("%s: restore: %v", tc.policy, err)
+		}
+		if report != tc.report {
+			t.Fatalf("%s: report = %+v, want %+v", tc.policy, report, tc.report)
+		}
+		engine.Start()
+		got := make([]string, 0)
+		for range tc.want {
+			got = append(got, waitEvent(t, engine.C(), time.Second).ID)
+		}
+		select {
+		case ev := <-engine.C():
+			t.Fatalf("%s: unexpected extra event %s", tc.policy, ev.ID)
+		case <-time.After(30 * time.Millisecond):
+		}
+		engine.Stop()
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: replayed %v, want %v", tc.policy, got, tc.want)
+		}
+	}
+
+	if _, err := NewEngine(1).Restore(events, cp, now, "sometimes"); !errors.Is(err, ErrInvalidCatchUpPolicy) {
+		t.Fatalf("expected ErrInvalidCatchUpPolicy, got %v", err)
+	}
+}
+
+func TestCheckpointAdvanceIsMonotonic(t *testing.T) {
+	base := time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC)
+	cp := Checkpoint{}.Advance(ReminderEvent{ID: "b", TriggerAt: base})
+	cp = cp.Advance(ReminderEvent{ID: "a", TriggerAt: base.Add(-time.Minute)})
+	if cp.Cursor != "b" || !cp.LastTickAt.Equal(base) {
+		t.Fatalf("expected older event not to move checkpoint, got %+v", cp)
+	}
+	if !cp.Delivered(ReminderEvent{ID: "b", TriggerAt: base}) || cp.Delivered(ReminderEvent{ID: "c", TriggerAt: base}) {
+		t.Fatalf("unexpected delivered check at cursor time for %+v", cp)
+	}
+
+	for raw, want := range map[string]CatchUpPolicy{"": CatchUpAll, "Latest": CatchUpLatest, " skip ": CatchUpSkip} {
+		got, err := ParseCatchUpPolicy(raw)
+		if err != nil || got != want {
+			t.Fatalf("parse %q = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+}
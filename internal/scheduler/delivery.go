@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+)
+
+var ErrInvalidDeliveryPolicy = errors.New("scheduler: invalid delivery policy")
+
+const (
+	defaultRetryDelay = time.Second
+	defaultMaxRetries = 5
+)
+
+// DeliveryPolicy decides what the engine does with a due reminder while the
+// C() buffer is full.
+type DeliveryPolicy string
+
+const (
+	// DeliveryDrop discards the reminder and reports it as a dead letter.
+	DeliveryDrop DeliveryPolicy = "drop"
+	// DeliveryBlock keeps due reminders queued until the consumer makes room.
+	DeliveryBlock DeliveryPolicy = "block"
+	// DeliveryRequeue retries after the retry delay and dead-letters the
+	// reminder once the retry budget is spent.
+	DeliveryRequeue DeliveryPolicy = "requeue"
+)
+
+func ParseDeliveryPolicy(raw string) (DeliveryPolicy, error) {
+	switch p := DeliveryPolicy(strings.ToLower(strings.TrimSpace(raw))); p {
+	case DeliveryDrop, DeliveryBlock, DeliveryRequeue:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidDeliveryPolicy, raw)
+	}
+}
+
+type DropReason string
+
+const (
+	DropBufferFull       DropReason = "buffer full"
+	DropRetriesExhausted DropReason = "retries exhausted"
+)
+
+// DeadLetter is a reminder the engine gave up delivering.
+type DeadLetter struct {
+	Event  ReminderEvent
+	Reason DropReason
+	At     time.Time
+}
+
+type Option func(*Engine)
+
+func WithDeliveryPolicy(policy DeliveryPolicy) Option {
+	return func(e *Engine) {
+		e.delivery = policy
+	}
+}
+
+// WithRetry configures DeliveryRequeue. maxRetries <= 0 retries forever.
+func WithRetry(delay time.Duration, maxRetries int) Option {
+	return func(e *Engine) {
+		if delay > 0 {
+			e.retryDelay = delay
+		}
+		e.maxRetries = maxRetries
+	}
+}
+
+// WithClock drives the engine from c instead of the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(e *Engine) {
+		e.clock = clock.OrReal(c)
+	}
+}
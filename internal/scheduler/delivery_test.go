@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+)
+
+func TestEngineBlockPolicyDeliversEverything(t *testing.T) {
+	engine := NewEngine(1, WithDeliveryPolicy(DeliveryBlock))
+	engine.Start()
+	defer engine.Stop()
+
+	now := time.Now().UTC()
+	const total = 20
+	for i := 0; i < total; i++ {
+		if err := engine.Schedule(ReminderEvent{ID: fmt.Sprintf("evt-%d", i), TriggerAt: now}); err != nil {
+			t.Fatalf("schedule event: %v", err)
+		}
+	}
+
+	// Let the buffer fill up before the consumer starts reading.
+	time.Sleep(50 * time.Millisecond)
+	seen := make(map[string]bool)
+	for i := 0; i < total; i++ {
+		seen[waitEvent(t, engine.C(), time.Second).ID] = true
+	}
+	if len(seen) != total || engine.Dropped() != 0 {
+		t.Fatalf("expected %d distinct deliveries and no drops, got %d and %d dropped", total, len(seen), engine.Dropped())
+	}
+	select {
+	case letter := <-engine.DeadLetters():
+		t.Fatalf("unexpected dead letter %+v", letter)
+	default:
+	}
+}
+
+func TestEngineBlockPolicyWaitsWithoutPollingAndHonoursCancel(t *testing.T) {
+	start := time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	engine := NewEngine(1, WithClock(fake), WithDeliveryPolicy(DeliveryBlock))
+	engine.Start()
+	defer engine.Stop()
+
+	for _, id := range []string{"first", "blocked"} {
+		if err := engine.Schedule(ReminderEvent{ID: id, TriggerAt: start}); err != nil {
+			t.Fatalf("schedule %s: %v", id, err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		engine.mu.Lock()
+		offered := engine.offer != nil
+		engine.mu.Unlock()
+		if offered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("engine never blocked on the full buffer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := fake.Timers(); n != 0 {
+		t.Fatalf("expected no timers while blocked on the consumer, got %d", n)
+	}
+
+	if !engine.Cancel("blocked") {
+		t.Fatalf("expected the blocked reminder to still be cancellable")
+	}
+	if got := waitEvent(t, engine.C(), time.Second); got.ID != "first" {
+		t.Fatalf("unexpected event %s", got.ID)
+	}
+	select {
+	case ev := <-engine.C():
+		t.Fatalf("cancelled event %s was delivered", ev.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestEngineRequeuePolicyRetriesThenDeadLetters(t *testing.T) {
+	engine := NewEngine(1, WithDeliveryPolicy(DeliveryRequeue), WithRetry(5*time.Millisecond, 2))
+	engine.Start()
+	defer engine.Stop()
+
+	now := time.Now().UTC()
+	for _, id := range []string{"first", "second"} {
+		if err := engine.Schedule(ReminderEvent{ID: id, TriggerAt: now}); err != nil {
+			t.Fatalf("schedule %s: %v", id, err)
+		}
+	}
+
+	letter := waitDeadLetter(t, engine.DeadLetters(), time.Second)
+	if letter.Reason != DropRetriesExhausted {
+		t.Fatalf("expected retries exhausted, got %+v", letter)
+	}
+	if got := waitEvent(t, engine.C(), time.Second); got.ID == letter.Event.ID {
+		t.Fatalf("dead-lettered event %s was also delivered", got.ID)
+	}
+	if engine.Dropped() != 1 {
+		t.Fatalf("expected one dropped event, got %d", engine.Dropped())
+	}
+}
+
+func TestEngineRequeuePolicyDeliversOnceConsumerCatchesUp(t *testing.T) {
+	engine := NewEngine(1, WithDeliveryPolicy(DeliveryRequeue), WithRetry(5*time.Millisecond, 0))
+	engine.Start()
+	defer engine.Stop()
+
+	now := time.Now().UTC()
+	for _, id := range []string{"a", "b", "c"} {
+		if err := engine.Schedule(ReminderEvent{ID: id, TriggerAt: now}); err != nil {
+			t.Fatalf("schedule %s: %v", id, err)
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		waitEvent(t, engine.C(), time.Second)
+	}
+	if engine.Dropped() != 0 {
+		t.Fatalf("expected no drops with unlimited retries, got %d", engine.Dropped())
+	}
+}
+
+func TestEngineDropPolicyReportsDeadLetters(t *testing.T) {
+	engine := NewEngine(1)
+	engine.Start()
+	defer engine.Stop()
+
+	now := time.Now().UTC()
+	for _, id := range []string{"kept", "dropped"} {
+		if err := engine.Schedule(ReminderEvent{ID: id, TriggerAt: now}); err != nil {
+			t.Fatalf("schedule %s: %v", id, err)
+		}
+	}
+
+	letter := waitDeadLetter(t, engine.DeadLetters(), time.Second)
+	if letter.Reason != DropBufferFull || letter.At.IsZero() {
+		t.Fatalf("unexpected dead letter %+v", letter)
+	}
+	if got := waitEvent(t, engine.C(), time.Second); got.ID == letter.Event.ID {
+		t.Fatalf("dropped event %s was also delivered", got.ID)
+	}
+}
+
+func TestParseDeliveryPolicy(t *testing.T) {
+	for raw, want := range map[string]DeliveryPolicy{"drop": DeliveryDrop, " Block ": DeliveryBlock, "REQUEUE": DeliveryRequeue} {
+		got, err := ParseDeliveryPolicy(raw)
+		if err != nil || got != want {
+			t.Fatalf("parse %q = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseDeliveryPolicy("sometimes"); !errors.Is(err, ErrInvalidDeliveryPolicy) {
+		t.Fatalf("expected ErrInvalidDeliveryPolicy, got %v", err)
+	}
+}
+
+func waitDeadLetter(t *testing.T, ch <-chan DeadLetter, timeout time.Duration) DeadLetter {
+	t.Helper()
+	select {
+	case letter := <-ch:
+		return letter
+	case <-time.After(timeout):
+		t.Fatalf("timed out waiting for dead letter after %s", timeout)
+		return DeadLetter{}
+	}
+}
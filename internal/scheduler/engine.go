@@ -3,12 +3,19 @@ package scheduler
 import (
 	"container/heap"
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
 )
 
-var ErrInvalidTriggerTime = errors.New("scheduler: invalid trigger time")
+var (
+	ErrInvalidTriggerTime = errors.New("scheduler: invalid trigger time")
+	ErrUnknownReminder    = errors.New("scheduler: unknown reminder")
+)
 
 type ReminderEvent struct {
 	ID         string
@@ -20,61 +27,121 @@ type ReminderEvent struct {
 
 type queueItem struct {
 	event ReminderEvent
+	// at is when the item is next due. It starts at event.TriggerAt and moves
+	// later when a requeue policy retries delivery.
+	at       time.Time
+	attempts int
+	index    int
 }
 
-type priorityQueue []queueItem
+type priorityQueue []*queueItem
 
 func (pq priorityQueue) Len() int { return len(pq) }
 
 func (pq priorityQueue) Less(i, j int) bool {
-	return pq[i].event.TriggerAt.Before(pq[j].event.TriggerAt)
+	return pq[i].at.Before(pq[j].at)
 }
 
 func (pq priorityQueue) Swap(i, j int) {
 	pq[i], pq[j] = pq[j], pq[i]
+	pq[i].index = i
+	pq[j].index = j
 }
 
 func (pq *priorityQueue) Push(x any) {
-	*pq = append(*pq, x.(queueItem))
+	item := x.(*queueItem)
+	item.index = len(*pq)
+	*pq = append(*pq, item)
 }
 
 func (pq *priorityQueue) Pop() any {
 	old := *pq
 	n := len(old)
 	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
 	*pq = old[0 : n-1]
 	return item
 }
 
+// rescheduleWindow is how long after delivery a reminder can still be
+// re-armed by Reschedule; older deliveries are forgotten.
+const rescheduleWindow = 24 * time.Hour
+
+// deliveredEvent is a reminder that left the queue, kept for Reschedule.
+type deliveredEvent struct {
+	event ReminderEvent
+	at    time.Time
+}
+
+// deliveredID is a delivered map entry in delivery order.
+type deliveredID struct {
+	id string
+	at time.Time
+}
+
 type Engine struct {
 	mu      sync.Mutex
 	queue   priorityQueue
+	pending map[string]*queueItem
+	// delivered remembers reminders sent or dead-lettered within the
+	// reschedule window so they can be re-armed by Reschedule.
+	delivered map[string]deliveredEvent
+	// deliveredOrder lists delivered IDs oldest first, so expired entries
+	// are dropped from the front instead of by scanning the map.
+	deliveredOrder []deliveredID
+	// offer is the due reminder the loop is blocked sending under
+	// DeliveryBlock, if any.
+	offer   *blockedOffer
 	out     chan ReminderEvent
+	dead    chan DeadLetter
 	wakeup  chan struct{}
 	stopCh  chan struct{}
 	doneCh  chan struct{}
 	started bool
 	stopped bool
 	dropped uint64
+
+	delivery   DeliveryPolicy
+	retryDelay time.Duration
+	maxRetries int
+	clock      clock.Clock
 }
 
-func NewEngine(bufferSize int) *Engine {
+func NewEngine(bufferSize int, opts ...Option) *Engine {
 	if bufferSize <= 0 {
 		bufferSize = 1
 	}
-	return &Engine{
-		queue:  make(priorityQueue, 0),
-		out:    make(chan ReminderEvent, bufferSize),
-		wakeup: make(chan struct{}, 1),
-		stopCh: make(chan struct{}),
-		doneCh: make(chan struct{}),
+	e := &Engine{
+		queue:      make(priorityQueue, 0),
+		pending:    make(map[string]*queueItem),
+		delivered:  make(map[string]deliveredEvent),
+		out:        make(chan ReminderEvent, bufferSize),
+		dead:       make(chan DeadLetter, bufferSize),
+		wakeup:     make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		delivery:   DeliveryDrop,
+		retryDelay: defaultRetryDelay,
+		maxRetries: defaultMaxRetries,
+		clock:      clock.Real{},
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 func (e *Engine) C() <-chan ReminderEvent {
 	return e.out
 }
 
+// DeadLetters reports reminders the engine gave up on. Letters are dropped
+// (but still counted by Dropped) when nobody drains the channel.
+func (e *Engine) DeadLetters() <-chan DeadLetter {
+	return e.dead
+}
+
 func (e *Engine) Start() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -98,20 +165,102 @@ func (e *Engine) Stop() {
 	<-e.doneCh
 }
 
+// Schedule queues ev, replacing any pending reminder with the same ID.
 func (e *Engine) Schedule(ev ReminderEvent) error {
 	if ev.TriggerAt.IsZero() {
 		return ErrInvalidTriggerTime
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stopped {
+		return errors.New("scheduler: engine stopped")
+	}
+	e.upsertLocked(ev)
+	return nil
+}
 
+// Reschedule moves the reminder with id to at. A reminder that fired within
+// the last day is re-armed with its last payload; IDs the engine never saw,
+// cancelled or delivered longer ago return ErrUnknownReminder.
+func (e *Engine) Reschedule(id string, at time.Time) error {
+	if at.IsZero() {
+		return ErrInvalidTriggerTime
+	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if e.stopped {
 		return errors.New("scheduler: engine stopped")
 	}
+	var ev ReminderEvent
+	if item, ok := e.pending[id]; ok {
+		ev = item.event
+	} else if d, ok := e.delivered[id]; ok && e.clock.Now().Sub(d.at) <= rescheduleWindow {
+		ev = d.event
+	} else {
+		return fmt.Errorf("%w: %s", ErrUnknownReminder, id)
+	}
+	ev.TriggerAt = at
+	e.upsertLocked(ev)
+	return nil
+}
 
-	heap.Push(&e.queue, queueItem{event: ev})
+// Cancel removes the reminder with id and reports whether it was still
+// pending. Once Cancel returns true the reminder is never sent on C().
+func (e *Engine) Cancel(id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if o := e.offer; o != nil && o.event.ID == id {
+		// The reminder is being offered on a full C(); withdraw it and wait
+		// to learn whether the consumer took it first.
+		o.withdraw()
+		e.mu.Unlock()
+		<-o.settled
+		e.mu.Lock()
+	}
+	delete(e.delivered, id)
+	item, ok := e.pending[id]
+	if !ok {
+		return false
+	}
+	heap.Remove(&e.queue, item.index)
+	delete(e.pending, id)
+	e.signalWakeup()
+	return true
+}
+
+// Pending returns the queued reminders ordered by trigger time.
+func (e *Engine) Pending() []ReminderEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]ReminderEvent, 0, len(e.queue))
+	items := append(priorityQueue(nil), e.queue...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].at.Before(items[j].at) })
+	for _, item := range items {
+		out = append(out, item.event)
+	}
+	return out
+}
+
+// Len reports how many reminders are queued.
+func (e *Engine) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.queue)
+}
+
+func (e *Engine) upsertLocked(ev ReminderEvent) {
+	delete(e.delivered, ev.ID)
+	if item, ok := e.pending[ev.ID]; ok {
+		item.event = ev
+		item.at = ev.TriggerAt
+		item.attempts = 0
+		heap.Fix(&e.queue, item.index)
+	} else {
+		item := &queueItem{event: ev, at: ev.TriggerAt}
+		heap.Push(&e.queue, item)
+		e.pending[ev.ID] = item
+	}
 	e.signalWakeup()
-	return nil
 }
 
 func (e *Engine) Dropped() uint64 {
@@ -122,7 +271,7 @@ func (e *Engine) loop() {
 	defer close(e.doneCh)
 	defer close(e.out)
 
-	var timer *time.Timer
+	var timer clock.Timer
 	for {
 		next, hasNext := e.peek()
 		if !hasNext {
@@ -134,21 +283,16 @@ func (e *Engine) loop() {
 			}
 		}
 
-		wait := time.Until(next.TriggerAt)
+		wait := next.Sub(e.clock.Now())
 		if wait < 0 {
 			wait = 0
 		}
-		timer = resetTimer(timer, wait)
+		timer = e.resetTimer(timer, wait)
 
 		select {
-		case <-timer.C:
-			due := e.popDue(time.Now().UTC())
-			for _, ev := range due {
-				select {
-				case e.out <- ev:
-				default:
-					atomic.AddUint64(&e.dropped, 1)
-				}
+		case <-timer.C():
+			if blocked := e.deliverDue(e.clock.Now().UTC()); blocked != nil {
+				e.offerBlocked(blocked)
 			}
 		case <-e.wakeup:
 			continue
@@ -161,54 +305,158 @@ func (e *Engine) loop() {
 	}
 }
 
+// blockedOffer is a due reminder waiting for room in C() under
+// DeliveryBlock.
+type blockedOffer struct {
+	item  *queueItem
+	event ReminderEvent
+	at    time.Time
+	// abort is closed to withdraw the offer; settled is closed once the
+	// loop knows whether the event was sent.
+	abort     chan struct{}
+	withdrawn bool
+	settled   chan struct{}
+}
+
+func (o *blockedOffer) withdraw() {
+	if !o.withdrawn {
+		o.withdrawn = true
+		close(o.abort)
+	}
+}
+
+// offerBlocked waits for the consumer to take item, without polling. Any
+// change to the queue withdraws the offer so the loop can look again; Cancel
+// waits for it to settle so a cancelled reminder is never sent.
+func (e *Engine) offerBlocked(item *queueItem) {
+	e.mu.Lock()
+	o := &blockedOffer{item: item, event: item.event, at: item.at, abort: make(chan struct{}), settled: make(chan struct{})}
+	e.offer = o
+	e.mu.Unlock()
+
+	sent := false
+	select {
+	case e.out <- o.event:
+		sent = true
+	case <-o.abort:
+	case <-e.stopCh:
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.offer = nil
+	// A reminder rescheduled while it was offered stays queued at its new
+	// time even if the old one was taken.
+	if sent && e.pending[o.event.ID] == item && item.at.Equal(o.at) && item.event == o.event {
+		heap.Remove(&e.queue, item.index)
+		delete(e.pending, o.event.ID)
+	}
+	if sent {
+		e.rememberDeliveredLocked(o.event, e.clock.Now().UTC())
+	}
+	close(o.settled)
+}
+
 func (e *Engine) signalWakeup() {
+	if e.offer != nil {
+		e.offer.withdraw()
+	}
 	select {
 	case e.wakeup <- struct{}{}:
 	default:
 	}
 }
 
-func (e *Engine) peek() (ReminderEvent, bool) {
+func (e *Engine) peek() (time.Time, bool) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if len(e.queue) == 0 {
-		return ReminderEvent{}, false
+		return time.Time{}, false
 	}
-	return e.queue[0].event, true
+	return e.queue[0].at, true
 }
 
-func (e *Engine) popDue(now time.Time) []ReminderEvent {
+// deliverDue sends every reminder due at now and returns the one delivery
+// is blocked on when the buffer is full under DeliveryBlock. Items are only
+// removed from the queue once sent (or given up on), under the lock, so
+// Cancel can never race with an in-flight event.
+func (e *Engine) deliverDue(now time.Time) *queueItem {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-
-	out := make([]ReminderEvent, 0)
 	for len(e.queue) > 0 {
-		next := e.queue[0].event
-		if next.TriggerAt.After(now) {
-			break
+		item := e.queue[0]
+		if item.at.After(now) {
+			return nil
+		}
+		select {
+		case e.out <- item.event:
+			heap.Pop(&e.queue)
+			delete(e.pending, item.event.ID)
+			e.rememberDeliveredLocked(item.event, now)
+			continue
+		default:
+		}
+
+		switch e.delivery {
+		case DeliveryBlock:
+			return item
+		case DeliveryRequeue:
+			item.attempts++
+			if e.maxRetries <= 0 || item.attempts <= e.maxRetries {
+				item.at = now.Add(e.retryDelay)
+				heap.Fix(&e.queue, item.index)
+				continue
+			}
+			e.deadLetterLocked(item, DropRetriesExhausted, now)
+		default:
+			e.deadLetterLocked(item, DropBufferFull, now)
 		}
-		item := heap.Pop(&e.queue).(queueItem)
-		out = append(out, item.event)
 	}
-	return out
+	return nil
+}
+
+// rememberDeliveredLocked keeps ev for Reschedule and forgets deliveries
+// older than the reschedule window, so the map stays bounded.
+func (e *Engine) rememberDeliveredLocked(ev ReminderEvent, now time.Time) {
+	for len(e.deliveredOrder) > 0 && now.Sub(e.deliveredOrder[0].at) > rescheduleWindow {
+		old := e.deliveredOrder[0]
+		e.deliveredOrder = e.deliveredOrder[1:]
+		// The ID may have been delivered again, or cancelled, since.
+		if d, ok := e.delivered[old.id]; ok && d.at.Equal(old.at) {
+			delete(e.delivered, old.id)
+		}
+	}
+	e.delivered[ev.ID] = deliveredEvent{event: ev, at: now}
+	e.deliveredOrder = append(e.deliveredOrder, deliveredID{id: ev.ID, at: now})
+}
+
+func (e *Engine) deadLetterLocked(item *queueItem, reason DropReason, now time.Time) {
+	heap.Remove(&e.queue, item.index)
+	delete(e.pending, item.event.ID)
+	e.rememberDeliveredLocked(item.event, now)
+	atomic.AddUint64(&e.dropped, 1)
+	select {
+	case e.dead <- DeadLetter{Event: item.event, Reason: reason, At: now}:
+	default:
+	}
 }
 
-func resetTimer(timer *time.Timer, d time.Duration) *time.Timer {
+func (e *Engine) resetTimer(timer clock.Timer, d time.Duration) clock.Timer {
 	if timer == nil {
-		return time.NewTimer(d)
+		return e.clock.NewTimer(d)
 	}
 	stopTimer(timer)
 	timer.Reset(d)
 	return timer
 }
 
-func stopTimer(timer *time.Timer) {
+func stopTimer(timer clock.Timer) {
 	if timer == nil {
 		return
 	}
 	if !timer.Stop() {
 		select {
-		case <-timer.C:
+		case <-timer.C():
 		default:
 		}
 	}
@@ -1,8 +1,12 @@
 package scheduler
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
 )
 
 func TestEngineEmitsInTriggerOrder(t *testing.T) {
@@ -33,7 +37,7 @@ func TestEngineNonBlockingDropsWhenConsumerIsSlow(t *testing.T) {
 	now := time.Now().UTC().Add(20 * time.Millisecond)
 	for i := 0; i < 25; i++ {
 		if err := engine.Schedule(ReminderEvent{
-			ID:        "evt",
+			ID:        fmt.Sprintf("evt-%d", i),
 			TriggerAt: now,
 		}); err != nil {
 			t.Fatalf("schedule event: %v", err)
@@ -46,6 +50,30 @@ func TestEngineNonBlockingDropsWhenConsumerIsSlow(t *testing.T) {
 	}
 }
 
+func TestEngineWithFakeClockFiresOnAdvance(t *testing.T) {
+	start := time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	engine := NewEngine(4, WithClock(fake))
+	engine.Start()
+	defer engine.Stop()
+
+	if err := engine.Schedule(ReminderEvent{ID: "nag", TriggerAt: start.Add(2 * time.Minute)}); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	select {
+	case ev := <-engine.C():
+		t.Fatalf("event %s delivered before its trigger time", ev.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	fake.Advance(time.Minute)
+	if ev := waitEvent(t, engine.C(), time.Second); ev.ID != "nag" {
+		t.Fatalf("unexpected event %s", ev.ID)
+	}
+}
+
 func TestScheduleValidatesTriggerTime(t *testing.T) {
 	engine := NewEngine(1)
 	if err := engine.Schedule(ReminderEvent{ID: "bad"}); err != ErrInvalidTriggerTime {
@@ -63,3 +91,95 @@ func waitEvent(t *testing.T, ch <-chan ReminderEvent, timeout time.Duration) Rem
 		return ReminderEvent{}
 	}
 }
+
+func TestEngineScheduleUpsertsByID(t *testing.T) {
+	engine := NewEngine(8)
+	now := time.Now().UTC()
+	for i := 0; i < 3; i++ {
+		if err := engine.Schedule(ReminderEvent{ID: "nag", Type: "Nagging", TriggerAt: now.Add(time.Duration(i+1) * time.Hour)}); err != nil {
+			t.Fatalf("schedule nag: %v", err)
+		}
+	}
+	if err := engine.Schedule(ReminderEvent{ID: "hard", Type: "Hard", TriggerAt: now.Add(90 * time.Minute)}); err != nil {
+		t.Fatalf("schedule hard: %v", err)
+	}
+	if engine.Len() != 2 {
+		t.Fatalf("expected duplicate IDs to collapse, len=%d", engine.Len())
+	}
+	pending := engine.Pending()
+	if pending[0].ID != "hard" || pending[1].ID != "nag" || !pending[1].TriggerAt.Equal(now.Add(3*time.Hour)) {
+		t.Fatalf("unexpected pending snapshot: %#v", pending)
+	}
+
+	if err := engine.Reschedule("nag", now.Add(time.Minute)); err != nil {
+		t.Fatalf("reschedule nag: %v", err)
+	}
+	if pending := engine.Pending(); pending[0].ID != "nag" || pending[0].Type != "Nagging" {
+		t.Fatalf("expected nag moved to front with payload kept, got %#v", pending)
+	}
+	if err := engine.Reschedule("ghost", now); !errors.Is(err, ErrUnknownReminder) {
+		t.Fatalf("expected ErrUnknownReminder, got %v", err)
+	}
+
+	if !engine.Cancel("nag") || engine.Cancel("nag") {
+		t.Fatal("expected first cancel to remove and second to report absent")
+	}
+	if engine.Len() != 1 || engine.Pending()[0].ID != "hard" {
+		t.Fatalf("unexpected queue after cancel: %#v", engine.Pending())
+	}
+	if err := engine.Reschedule("nag", now); !errors.Is(err, ErrUnknownReminder) {
+		t.Fatalf("expected cancelled reminder to be forgotten, got %v", err)
+	}
+}
+
+func TestEngineReschedulesDeliveredReminder(t *testing.T) {
+	engine := NewEngine(8)
+	engine.Start()
+	defer engine.Stop()
+
+	if err := engine.Schedule(ReminderEvent{ID: "soft", Type: "Soft", TriggerAt: time.Now().UTC()}); err != nil {
+		t.Fatalf("schedule soft: %v", err)
+	}
+	first := waitEvent(t, engine.C(), time.Second)
+	if engine.Len() != 0 {
+		t.Fatalf("expected delivered reminder to leave the queue, len=%d", engine.Len())
+	}
+	if err := engine.Reschedule(first.ID, time.Now().UTC().Add(10*time.Millisecond)); err != nil {
+		t.Fatalf("re-arm soft: %v", err)
+	}
+	second := waitEvent(t, engine.C(), time.Second)
+	if second.ID != "soft" || second.Type != "Soft" {
+		t.Fatalf("unexpected re-armed event: %#v", second)
+	}
+}
+
+func TestEngineForgetsOldDeliveries(t *testing.T) {
+	start := time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	engine := NewEngine(4, WithClock(fake))
+	engine.Start()
+	defer engine.Stop()
+
+	if err := engine.Schedule(ReminderEvent{ID: "old", TriggerAt: start}); err != nil {
+		t.Fatalf("schedule old: %v", err)
+	}
+	waitEvent(t, engine.C(), time.Second)
+	fake.Advance(rescheduleWindow + time.Minute)
+	if err := engine.Schedule(ReminderEvent{ID: "new", TriggerAt: fake.Now()}); err != nil {
+		t.Fatalf("schedule new: %v", err)
+	}
+	waitEvent(t, engine.C(), time.Second)
+
+	if err := engine.Reschedule("old", fake.Now().Add(time.Hour)); !errors.Is(err, ErrUnknownReminder) {
+		t.Fatalf("expected a day-old delivery forgotten, got %v", err)
+	}
+	engine.mu.Lock()
+	remembered := len(engine.delivered)
+	engine.mu.Unlock()
+	if remembered != 1 {
+		t.Fatalf("expected only the recent delivery remembered, got %d", remembered)
+	}
+	if err := engine.Reschedule("new", fake.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("re-arm new: %v", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
 )
 
 func TestEngineStressConcurrentSchedule(t *testing.T) {
@@ -59,3 +61,63 @@ func TestEngineStressConcurrentSchedule(t *testing.T) {
 		t.Fatalf("expected zero drops with active consumer, got=%d", engine.Dropped())
 	}
 }
+
+func TestEngineCancelledNeverDelivered(t *testing.T) {
+	base := time.Date(2026, 2, 11, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(base)
+	engine := NewEngine(4096, WithClock(fake))
+	engine.Start()
+
+	const total = 2000
+	const spread = 30
+	for i := 0; i < total; i++ {
+		delay := time.Duration(i%spread+1) * time.Millisecond
+		if err := engine.Schedule(ReminderEvent{ID: fmt.Sprintf("r-%d", i), TriggerAt: base.Add(delay)}); err != nil {
+			t.Fatalf("schedule failed: %v", err)
+		}
+	}
+
+	var cancelled sync.Map
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// Cancel every other reminder while earlier ones come due;
+		// reminders that were already delivered simply report false.
+		for i := 0; i < total; i += 2 {
+			if id := fmt.Sprintf("r-%d", i); engine.Cancel(id) {
+				cancelled.Store(id, true)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		// The last slot only comes due after both goroutines finish, so its
+		// cancellations always land before delivery.
+		for step := 1; step < spread; step++ {
+			fake.Advance(time.Millisecond)
+		}
+	}()
+	wg.Wait()
+	for i := spread - 1; i < total; i += spread {
+		if _, ok := cancelled.Load(fmt.Sprintf("r-%d", i)); i%2 == 0 && !ok {
+			t.Fatalf("expected r-%d to be cancelled before it came due", i)
+		}
+	}
+
+	fake.Advance(time.Hour)
+	deadline := time.Now().Add(5 * time.Second)
+	for engine.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for deliveries, %d still pending", engine.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	engine.Stop()
+
+	for ev := range engine.C() {
+		if _, ok := cancelled.Load(ev.ID); ok {
+			t.Fatalf("cancelled reminder %s was delivered", ev.ID)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package server exposes the task repository as a local HTTP/JSON API with a
+// server-sent event stream of reminder firings.
+package server
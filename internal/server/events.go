@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+)
+
+// subscriberBuffer is how many firings a slow client may lag behind before
+// further ones are dropped for it.
+const subscriberBuffer = 16
+
+// keepAliveInterval spaces the comment lines that stop proxies from closing an
+// idle stream.
+const keepAliveInterval = 30 * time.Second
+
+func (s *Server) subscribe() chan scheduler.ReminderEvent {
+	ch := make(chan scheduler.ReminderEvent, subscriberBuffer)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan scheduler.ReminderEvent) {
+	s.mu.Lock()
+	delete(s.subscribers, ch)
+	s.mu.Unlock()
+}
+
+func (s *Server) publish(ev scheduler.ReminderEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// streamEvents writes each reminder firing as a server-sent event named
+// "reminder" whose data is an eventBody.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errors.New("server: streaming unsupported"))
+		return
+	}
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case ev := <-ch:
+			data, err := json.Marshal(eventFromScheduler(ev))
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: reminder\nid: %s\ndata: %s\n\n", ev.ID, data)
+		}
+		flusher.Flush()
+	}
+}
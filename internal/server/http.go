@@ -0,0 +1,140 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+var (
+	ErrBadRequest           = errors.New("server: bad request")
+	ErrConflict             = errors.New("server: already exists")
+	ErrPreconditionFailed   = errors.New("server: resource changed since it was read")
+	ErrPreconditionRequired = errors.New("server: If-Match header required")
+)
+
+// maxBodyBytes bounds request bodies; records are small.
+const maxBodyBytes = 1 << 20
+
+type errorBody struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type listBody[T any] struct {
+	Items []T `json:"items"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeResource writes v with its ETag.
+func writeResource(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("ETag", etagOf(v))
+	writeJSON(w, status, v)
+}
+
+func writeList[T any](w http.ResponseWriter, items []T) {
+	if items == nil {
+		items = []T{}
+	}
+	writeJSON(w, http.StatusOK, listBody[T]{Items: items})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status, code := classify(err)
+	var body errorBody
+	body.Error.Code = code
+	body.Error.Message = err.Error()
+	writeJSON(w, status, body)
+}
+
+func classify(err error) (int, string) {
+	var invalid *storage.ValidationError
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict, "already_exists"
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, "precondition_failed"
+	case errors.Is(err, ErrPreconditionRequired):
+		return http.StatusPreconditionRequired, "precondition_required"
+	case errors.As(err, &invalid),
+		errors.Is(err, ErrBadRequest),
+		errors.Is(err, storage.ErrInvalidTagName),
+		errors.Is(err, storage.ErrInvalidFilter):
+		return http.StatusBadRequest, "invalid_argument"
+	default:
+		return http.StatusInternalServerError, "internal"
+	}
+}
+
+func decodeBody(r *http.Request, dst any) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("%w: decode body: %v", ErrBadRequest, err)
+	}
+	return nil
+}
+
+// etagOf is a strong validator over the resource's JSON form, so any visible
+// change, including one made by the TUI, yields a new tag.
+func etagOf(v any) string {
+	raw, _ := json.Marshal(v)
+	sum := sha256.Sum256(raw)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// checkIfMatch enforces optimistic concurrency: writes must name the ETag they
+// last read, or "*" for any current version.
+func checkIfMatch(r *http.Request, current any) error {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return ErrPreconditionRequired
+	}
+	if header == "*" {
+		return nil
+	}
+	want := etagOf(current)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: current ETag is %s", ErrPreconditionFailed, want)
+}
+
+func queryInt(r *http.Request, name string) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: invalid %s %q", ErrBadRequest, name, raw)
+	}
+	return n, nil
+}
+
+func queryList(r *http.Request, name string) []string {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func (s *Server) listRecurrences(w http.ResponseWriter, r *http.Request) {
+	filter := storage.RecurrenceListFilter{TaskID: r.URL.Query().Get("task_id")}
+	if raw := r.URL.Query().Get("enabled"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: invalid enabled %q", ErrBadRequest, raw))
+			return
+		}
+		filter.Enabled = &enabled
+	}
+	var err error
+	if filter.Limit, err = queryInt(r, "limit"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if filter.Offset, err = queryInt(r, "offset"); err != nil {
+		writeError(w, err)
+		return
+	}
+	rules, err := s.repo.ListRecurrences(r.Context(), filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]recurrenceBody, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, recurrenceFromStore(rule))
+	}
+	writeList(w, out)
+}
+
+func (s *Server) getRecurrence(w http.ResponseWriter, r *http.Request) {
+	rule, err := s.repo.GetRecurrence(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResource(w, http.StatusOK, recurrenceFromStore(rule))
+}
+
+func (s *Server) createRecurrence(w http.ResponseWriter, r *http.Request) {
+	var body recurrenceBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID == "" {
+		body.ID = model.NewID("rec")
+	}
+	if body.Interval == 0 {
+		body.Interval = 1
+	}
+	if body.Timezone == "" {
+		body.Timezone = "UTC"
+	}
+	if body.CreatedAt.IsZero() {
+		body.CreatedAt = s.clock.Now().UTC()
+	}
+
+	rule, err := body.store()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	var created storage.RecurrenceRule
+	err = s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		if err := validateRecurrence(ctx, tx, rule); err != nil {
+			return err
+		}
+		if err := ensureAbsent(tx.GetRecurrence(ctx, body.ID)); err != nil {
+			return fmt.Errorf("recurrence %s: %w", body.ID, err)
+		}
+		if err := tx.CreateRecurrence(ctx, rule); err != nil {
+			return err
+		}
+		var err error
+		created, err = tx.GetRecurrence(ctx, body.ID)
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/recurrences/"+created.ID)
+	writeResource(w, http.StatusCreated, recurrenceFromStore(created))
+}
+
+func (s *Server) updateRecurrence(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body recurrenceBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID != "" && body.ID != id {
+		writeError(w, fmt.Errorf("%w: body id %q does not match path", ErrBadRequest, body.ID))
+		return
+	}
+
+	next, err := body.store()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	var updated storage.RecurrenceRule
+	var reminders []storage.Reminder
+	err = s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetRecurrence(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, recurrenceFromStore(current)); err != nil {
+			return err
+		}
+		next.ID = id
+		next.CreatedAt = current.CreatedAt
+		if next.Exceptions == nil {
+			next.Exceptions = current.Exceptions
+		}
+		if err := validateRecurrence(ctx, tx, next); err != nil {
+			return err
+		}
+		if err := tx.UpdateRecurrence(ctx, next); err != nil {
+			return err
+		}
+		// Skipping or moving the occurrence the task stands for moves the
+		// task, and its reminders with it.
+		moved, err := storage.ReplanOccurrence(ctx, tx, current, next)
+		if err != nil {
+			return err
+		}
+		if moved != nil {
+			if reminders, err = tx.ListReminders(ctx, storage.ReminderListFilter{TaskID: moved.ID}); err != nil {
+				return err
+			}
+		}
+		updated, err = tx.GetRecurrence(ctx, id)
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, rem := range reminders {
+		s.syncReminder(rem)
+	}
+	writeResource(w, http.StatusOK, recurrenceFromStore(updated))
+}
+
+func (s *Server) deleteRecurrence(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := r.Context()
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetRecurrence(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, recurrenceFromStore(current)); err != nil {
+			return err
+		}
+		return tx.DeleteRecurrence(ctx, id)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateRecurrence(ctx context.Context, repo storage.Repository, rule storage.RecurrenceRule) error {
+	domain, err := storage.RecurrenceToModel(rule)
+	if err == nil {
+		err = domain.Validate()
+	}
+	if err != nil {
+		return &storage.ValidationError{Entity: "recurrence", ID: rule.ID, Err: err}
+	}
+	return requireTaskID(ctx, repo, rule.TaskID)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func (s *Server) listReminders(w http.ResponseWriter, r *http.Request) {
+	filter := storage.ReminderListFilter{TaskID: r.URL.Query().Get("task_id")}
+	if raw := r.URL.Query().Get("enabled"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: invalid enabled %q", ErrBadRequest, raw))
+			return
+		}
+		filter.Enabled = &enabled
+	}
+	var err error
+	if filter.Limit, err = queryInt(r, "limit"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if filter.Offset, err = queryInt(r, "offset"); err != nil {
+		writeError(w, err)
+		return
+	}
+	reminders, err := s.repo.ListReminders(r.Context(), filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]reminderBody, 0, len(reminders))
+	for _, rem := range reminders {
+		out = append(out, reminderFromStore(rem))
+	}
+	writeList(w, out)
+}
+
+func (s *Server) getReminder(w http.ResponseWriter, r *http.Request) {
+	rem, err := s.repo.GetReminder(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResource(w, http.StatusOK, reminderFromStore(rem))
+}
+
+func (s *Server) createReminder(w http.ResponseWriter, r *http.Request) {
+	var body reminderBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID == "" {
+		body.ID = model.NewID("rem")
+	}
+	if body.CreatedAt.IsZero() {
+		body.CreatedAt = s.clock.Now().UTC()
+	}
+
+	ctx := r.Context()
+	var created storage.Reminder
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		if err := validateReminder(ctx, tx, body.store()); err != nil {
+			return err
+		}
+		if err := ensureAbsent(tx.GetReminder(ctx, body.ID)); err != nil {
+			return fmt.Errorf("reminder %s: %w", body.ID, err)
+		}
+		if err := tx.CreateReminder(ctx, body.store()); err != nil {
+			return err
+		}
+		var err error
+		created, err = tx.GetReminder(ctx, body.ID)
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.syncReminder(created)
+	w.Header().Set("Location", "/v1/reminders/"+created.ID)
+	writeResource(w, http.StatusCreated, reminderFromStore(created))
+}
+
+func (s *Server) updateReminder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body reminderBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID != "" && body.ID != id {
+		writeError(w, fmt.Errorf("%w: body id %q does not match path", ErrBadRequest, body.ID))
+		return
+	}
+
+	ctx := r.Context()
+	var updated storage.Reminder
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetReminder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, reminderFromStore(current)); err != nil {
+			return err
+		}
+		next := body.store()
+		next.ID = id
+		if next.CreatedAt.IsZero() {
+			next.CreatedAt = current.CreatedAt
+		}
+		if err := validateReminder(ctx, tx, next); err != nil {
+			return err
+		}
+		if err := tx.UpdateReminder(ctx, next); err != nil {
+			return err
+		}
+		updated, err = tx.GetReminder(ctx, id)
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.syncReminder(updated)
+	writeResource(w, http.StatusOK, reminderFromStore(updated))
+}
+
+func (s *Server) deleteReminder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := r.Context()
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetReminder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, reminderFromStore(current)); err != nil {
+			return err
+		}
+		return tx.DeleteReminder(ctx, id)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.cancelReminders([]string{id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateReminder(ctx context.Context, repo storage.Repository, rem storage.Reminder) error {
+	err := model.Reminder{
+		ID:          rem.ID,
+		TaskID:      rem.TaskID,
+		TriggerTime: rem.TriggerAt,
+		Type:        model.ReminderType(rem.Type),
+		RepeatRule:  rem.RepeatRule,
+		LastFiredAt: rem.LastFired,
+		Enabled:     rem.Enabled,
+	}.Validate()
+	if err != nil {
+		return &storage.ValidationError{Entity: "reminder", ID: rem.ID, Err: err}
+	}
+	return requireTaskID(ctx, repo, rem.TaskID)
+}
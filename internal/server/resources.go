@@ -0,0 +1,246 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// The wire types use the same field names as the CLI's taskd/v1 records.
+
+type taskBody struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	State       string     `json:"state"`
+	Priority    string     `json:"priority"`
+	Energy      string     `json:"energy"`
+	Tags        []string   `json:"tags"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func taskFromStore(in storage.Task) taskBody {
+	tags := in.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return taskBody{
+		ID:          in.ID,
+		Title:       in.Title,
+		Description: in.Description,
+		State:       in.State,
+		Priority:    in.Priority,
+		Energy:      in.Energy,
+		Tags:        tags,
+		ScheduledAt: utc(in.ScheduledAt),
+		DueAt:       utc(in.DueAt),
+		CreatedAt:   in.CreatedAt.UTC(),
+		CompletedAt: utc(in.CompletedAt),
+	}
+}
+
+func (b taskBody) store() storage.Task {
+	tags := b.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return storage.Task{
+		ID:          b.ID,
+		Title:       b.Title,
+		Description: b.Description,
+		State:       b.State,
+		Priority:    b.Priority,
+		Energy:      b.Energy,
+		ScheduledAt: b.ScheduledAt,
+		DueAt:       b.DueAt,
+		CreatedAt:   b.CreatedAt,
+		CompletedAt: b.CompletedAt,
+		Tags:        tags,
+	}
+}
+
+type reminderBody struct {
+	ID         string     `json:"id"`
+	TaskID     string     `json:"task_id"`
+	Type       string     `json:"type"`
+	TriggerAt  time.Time  `json:"trigger_at"`
+	RepeatRule string     `json:"repeat_rule,omitempty"`
+	LastFired  *time.Time `json:"last_fired,omitempty"`
+	Enabled    *bool      `json:"enabled,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func reminderFromStore(in storage.Reminder) reminderBody {
+	enabled := in.Enabled
+	return reminderBody{
+		ID:         in.ID,
+		TaskID:     in.TaskID,
+		Type:       in.Type,
+		TriggerAt:  in.TriggerAt.UTC(),
+		RepeatRule: in.RepeatRule,
+		LastFired:  utc(in.LastFired),
+		Enabled:    &enabled,
+		CreatedAt:  in.CreatedAt.UTC(),
+	}
+}
+
+// store converts b to a row; a missing "enabled" means enabled.
+func (b reminderBody) store() storage.Reminder {
+	enabled := b.Enabled == nil || *b.Enabled
+	return storage.Reminder{
+		ID:         b.ID,
+		TaskID:     b.TaskID,
+		Type:       b.Type,
+		TriggerAt:  b.TriggerAt,
+		RepeatRule: b.RepeatRule,
+		LastFired:  b.LastFired,
+		Enabled:    enabled,
+		CreatedAt:  b.CreatedAt,
+	}
+}
+
+type tagBody struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func tagFromStore(in storage.Tag) tagBody {
+	return tagBody{ID: in.ID, Name: in.Name, CreatedAt: in.CreatedAt.UTC()}
+}
+
+type recurrenceBody struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"task_id"`
+	RuleType  string     `json:"rule_type"`
+	Interval  int        `json:"interval"`
+	Timezone  string     `json:"timezone"`
+	StartAt   time.Time  `json:"start_at"`
+	NextAt    *time.Time `json:"next_at,omitempty"`
+	Enabled   *bool      `json:"enabled,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	// Weekdays is a list such as "mon,wed,fri"; AfterCompleteIn a Go
+	// duration such as "36h".
+	Weekdays        string `json:"weekdays,omitempty"`
+	AfterCompleteIn string `json:"after_complete_in,omitempty"`
+	// RRule holds the RRULE, RDATE and EXDATE lines of an "rrule" rule.
+	RRule string `json:"rrule,omitempty"`
+	// Until and Count end the series; at most one is set. Exceptions left
+	// out of an update keep the stored ones and [] clears them.
+	Until      *time.Time      `json:"until,omitempty"`
+	Count      int             `json:"count,omitempty"`
+	Exceptions []exceptionBody `json:"exceptions,omitempty"`
+}
+
+// exceptionBody skips or moves the occurrence at At, or pauses the series
+// from At until To.
+type exceptionBody struct {
+	Kind string     `json:"kind"`
+	At   time.Time  `json:"at"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+func recurrenceFromStore(in storage.RecurrenceRule) recurrenceBody {
+	enabled := in.Enabled
+	body := recurrenceBody{
+		ID:        in.ID,
+		TaskID:    in.TaskID,
+		RuleType:  in.RuleType,
+		Interval:  in.IntervalValue,
+		Timezone:  in.Timezone,
+		StartAt:   in.StartAt.UTC(),
+		NextAt:    utc(in.NextAt),
+		Enabled:   &enabled,
+		CreatedAt: in.CreatedAt.UTC(),
+		Weekdays:  storage.FormatWeekdays(in.Weekdays),
+		RRule:     in.RRule,
+		Until:     utc(in.Until),
+		Count:     in.Count,
+	}
+	if in.AfterCompleteIn > 0 {
+		body.AfterCompleteIn = in.AfterCompleteIn.String()
+	}
+	for _, ex := range in.Exceptions {
+		body.Exceptions = append(body.Exceptions, exceptionBody{Kind: ex.Kind, At: ex.At.UTC(), To: utc(ex.To)})
+	}
+	return body
+}
+
+func (b recurrenceBody) store() (storage.RecurrenceRule, error) {
+	weekdays, err := storage.ParseWeekdays(b.Weekdays)
+	if err != nil {
+		return storage.RecurrenceRule{}, fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	var afterComplete time.Duration
+	if b.AfterCompleteIn != "" {
+		if afterComplete, err = time.ParseDuration(b.AfterCompleteIn); err != nil {
+			return storage.RecurrenceRule{}, fmt.Errorf("%w: invalid after_complete_in %q", ErrBadRequest, b.AfterCompleteIn)
+		}
+	}
+	var exceptions []storage.RecurrenceException
+	if b.Exceptions != nil {
+		exceptions = make([]storage.RecurrenceException, 0, len(b.Exceptions))
+		for _, ex := range b.Exceptions {
+			exceptions = append(exceptions, storage.RecurrenceException{Kind: ex.Kind, At: ex.At, To: ex.To})
+		}
+	}
+	return storage.RecurrenceRule{
+		ID:              b.ID,
+		TaskID:          b.TaskID,
+		RuleType:        b.RuleType,
+		IntervalValue:   b.Interval,
+		Timezone:        b.Timezone,
+		StartAt:         b.StartAt,
+		NextAt:          b.NextAt,
+		Enabled:         b.Enabled == nil || *b.Enabled,
+		CreatedAt:       b.CreatedAt,
+		Weekdays:        weekdays,
+		AfterCompleteIn: afterComplete,
+		RRule:           b.RRule,
+		Until:           b.Until,
+		Count:           b.Count,
+		Exceptions:      exceptions,
+	}, nil
+}
+
+// eventBody is the data of a "reminder" server-sent event.
+type eventBody struct {
+	ID         string    `json:"id"`
+	TaskID     string    `json:"task_id"`
+	Type       string    `json:"type"`
+	RepeatRule string    `json:"repeat_rule,omitempty"`
+	TriggerAt  time.Time `json:"trigger_at"`
+}
+
+func eventFromScheduler(ev scheduler.ReminderEvent) eventBody {
+	return eventBody{
+		ID:         ev.ID,
+		TaskID:     ev.TaskID,
+		Type:       ev.Type,
+		RepeatRule: ev.RepeatRule,
+		TriggerAt:  ev.TriggerAt.UTC(),
+	}
+}
+
+func eventFromReminder(rem storage.Reminder) scheduler.ReminderEvent {
+	return scheduler.ReminderEvent{
+		ID:         rem.ID,
+		TaskID:     rem.TaskID,
+		Type:       rem.Type,
+		RepeatRule: rem.RepeatRule,
+		TriggerAt:  rem.TriggerAt.UTC(),
+	}
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.UTC()
+	return &v
+}
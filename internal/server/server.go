@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// changePollInterval is how often the server reads the change log for
+// reminders written by other taskd processes.
+const changePollInterval = 2 * time.Second
+
+// Server serves the REST API over a repository. Reminder writes are mirrored
+// into engine, whose firings are streamed to /v1/events subscribers.
+type Server struct {
+	repo   storage.Repository
+	engine *scheduler.Engine
+	token  string
+	clock  clock.Clock
+
+	// changeSeq is the newest change-log row already mirrored into engine;
+	// only Restore and Run's goroutine touch it.
+	changeSeq int64
+
+	mu          sync.Mutex
+	subscribers map[chan scheduler.ReminderEvent]struct{}
+}
+
+// New returns a Server that requires token as a bearer token. engine may be
+// nil, in which case the event stream stays silent.
+func New(repo storage.Repository, engine *scheduler.Engine, token string, c clock.Clock) *Server {
+	return &Server{
+		repo:        repo.WithSource(storage.SourceAPI),
+		engine:      engine,
+		token:       token,
+		clock:       clock.OrReal(c),
+		subscribers: make(map[chan scheduler.ReminderEvent]struct{}),
+	}
+}
+
+// Handler returns the authenticated API routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/tasks", s.listTasks)
+	mux.HandleFunc("POST /v1/tasks", s.createTask)
+	mux.HandleFunc("GET /v1/tasks/{id}", s.getTask)
+	mux.HandleFunc("PUT /v1/tasks/{id}", s.updateTask)
+	mux.HandleFunc("DELETE /v1/tasks/{id}", s.deleteTask)
+
+	mux.HandleFunc("GET /v1/reminders", s.listReminders)
+	mux.HandleFunc("POST /v1/reminders", s.createReminder)
+	mux.HandleFunc("GET /v1/reminders/{id}", s.getReminder)
+	mux.HandleFunc("PUT /v1/reminders/{id}", s.updateReminder)
+	mux.HandleFunc("DELETE /v1/reminders/{id}", s.deleteReminder)
+
+	mux.HandleFunc("GET /v1/tags", s.listTags)
+	mux.HandleFunc("POST /v1/tags", s.createTag)
+	mux.HandleFunc("GET /v1/tags/{id}", s.getTag)
+	mux.HandleFunc("PUT /v1/tags/{id}", s.updateTag)
+	mux.HandleFunc("DELETE /v1/tags/{id}", s.deleteTag)
+
+	mux.HandleFunc("GET /v1/recurrences", s.listRecurrences)
+	mux.HandleFunc("POST /v1/recurrences", s.createRecurrence)
+	mux.HandleFunc("GET /v1/recurrences/{id}", s.getRecurrence)
+	mux.HandleFunc("PUT /v1/recurrences/{id}", s.updateRecurrence)
+	mux.HandleFunc("DELETE /v1/recurrences/{id}", s.deleteRecurrence)
+
+	mux.HandleFunc("GET /v1/events", s.streamEvents)
+
+	return s.authenticate(mux)
+}
+
+// authenticate accepts "Authorization: Bearer <token>". The event stream also
+// takes ?access_token= for EventSource clients that cannot set headers; no
+// other route does, so tokens stay out of ordinary request URLs and logs.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got string
+		if r.Method == http.MethodGet && r.URL.Path == "/v1/events" {
+			got = r.URL.Query().Get("access_token")
+		}
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			got = strings.TrimSpace(bearer)
+		}
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="taskd"`)
+			var body errorBody
+			body.Error.Code = "unauthenticated"
+			body.Error.Message = "server: missing or invalid bearer token"
+			writeJSON(w, http.StatusUnauthorized, body)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Restore queues every enabled reminder that has yet to fire, so firings are
+// streamed even when no client created them. Missed reminders are left to the
+// TUI, which owns the delivery checkpoint.
+func (s *Server) Restore(ctx context.Context) error {
+	if s.engine == nil {
+		return nil
+	}
+	// Read the change log first so writes racing the load are re-synced by
+	// Run rather than lost.
+	seq, err := s.repo.LatestChangeSeq(ctx)
+	if err != nil {
+		return fmt.Errorf("read change log: %w", err)
+	}
+	enabled := true
+	reminders, err := s.repo.ListReminders(ctx, storage.ReminderListFilter{Enabled: &enabled})
+	if err != nil {
+		return fmt.Errorf("load reminders: %w", err)
+	}
+	now := s.clock.Now()
+	for _, rem := range reminders {
+		if !rem.TriggerAt.After(now) {
+			continue
+		}
+		if err := s.engine.Schedule(eventFromReminder(rem)); err != nil {
+			return fmt.Errorf("schedule reminder %s: %w", rem.ID, err)
+		}
+	}
+	s.changeSeq = seq
+	return nil
+}
+
+// Run fans engine firings out to event stream subscribers until ctx is done.
+// It also polls the change log so reminders written by the TUI, the CLI or a
+// sync are scheduled without a restart.
+func (s *Server) Run(ctx context.Context) {
+	if s.engine == nil {
+		<-ctx.Done()
+		return
+	}
+	poll := s.clock.After(changePollInterval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll:
+			s.syncChanges(ctx)
+			poll = s.clock.After(changePollInterval)
+		case ev := <-s.engine.C():
+			if s.stillArmed(ctx, ev) {
+				s.publish(ev)
+			}
+		}
+	}
+}
+
+// syncChanges re-syncs the reminders touched by other processes since the
+// last poll. Errors are retried on the next poll.
+func (s *Server) syncChanges(ctx context.Context) {
+	changes, err := s.repo.ChangesSince(ctx, s.changeSeq)
+	if err != nil || len(changes) == 0 {
+		return
+	}
+	if s.changeSeq > 0 && changes[0].Seq > s.changeSeq+1 {
+		// The log was trimmed past what we had seen; reload everything.
+		if err := s.Restore(ctx); err == nil {
+			return
+		}
+	}
+	origin := s.repo.Origin()
+	for _, change := range changes {
+		if change.Origin != origin {
+			if err := s.syncChange(ctx, change); err != nil {
+				return
+			}
+		}
+		s.changeSeq = change.Seq
+	}
+}
+
+func (s *Server) syncChange(ctx context.Context, change storage.Change) error {
+	switch change.Entity {
+	case storage.ChangeReminder:
+		rem, err := s.repo.GetReminder(ctx, change.EntityID)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.engine.Cancel(change.EntityID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.syncReminder(rem)
+	case storage.ChangeTask:
+		// Deleting a task cascades to its reminders without logging them;
+		// stillArmed catches those when they fire.
+		reminders, err := s.repo.ListReminders(ctx, storage.ReminderListFilter{TaskID: change.EntityID})
+		if err != nil {
+			return err
+		}
+		for _, rem := range reminders {
+			s.syncReminder(rem)
+		}
+	}
+	return nil
+}
+
+// stillArmed reports whether the reminder behind ev is still stored and
+// enabled, so a firing for one removed since the last poll is not streamed.
+func (s *Server) stillArmed(ctx context.Context, ev scheduler.ReminderEvent) bool {
+	rem, err := s.repo.GetReminder(ctx, ev.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false
+	}
+	return err != nil || rem.Enabled
+}
+
+// syncReminder mirrors a stored reminder into the engine.
+func (s *Server) syncReminder(rem storage.Reminder) {
+	if s.engine == nil {
+		return
+	}
+	if !rem.Enabled || !rem.TriggerAt.After(s.clock.Now()) {
+		s.engine.Cancel(rem.ID)
+		return
+	}
+	_ = s.engine.Schedule(eventFromReminder(rem))
+}
+
+func (s *Server) cancelReminders(ids []string) {
+	if s.engine == nil {
+		return
+	}
+	for _, id := range ids {
+		s.engine.Cancel(id)
+	}
+}
@@ -0,0 +1,502 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+const testToken = "test-token"
+
+var testNow = time.Date(2026, 2, 11, 14, 0, 0, 0, time.UTC)
+
+type apiFixture struct {
+	t      *testing.T
+	db     *sql.DB
+	repo   *storage.SQLiteRepository
+	clock  *clock.Fake
+	server *Server
+	http   *httptest.Server
+}
+
+func newAPIFixture(t *testing.T) *apiFixture {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "taskd-api.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo, err := storage.NewSQLiteRepository(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	fake := clock.NewFake(testNow)
+	engine := scheduler.NewEngine(8, scheduler.WithClock(fake))
+	engine.Start()
+	t.Cleanup(engine.Stop)
+
+	srv := New(repo, engine, testToken, fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	go srv.Run(ctx)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		cancel()
+		ts.Close()
+	})
+	return &apiFixture{t: t, db: db, repo: repo, clock: fake, server: srv, http: ts}
+}
+
+// do sends body as JSON with the test token and any extra headers given as
+// name/value pairs, and decodes a JSON response into out when non-nil.
+func (f *apiFixture) do(method, path string, body any, out any, headers ...string) *http.Response {
+	f.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			f.t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, f.http.URL+path, reader)
+	if err != nil {
+		f.t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := f.http.Client().Do(req)
+	if err != nil {
+		f.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			f.t.Fatalf("decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp
+}
+
+func expectStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: status %d, want %d", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, want)
+	}
+}
+
+func TestRequestsNeedBearerToken(t *testing.T) {
+	f := newAPIFixture(t)
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		want   int
+	}{
+		{"missing", http.MethodGet, "/v1/tasks", "", http.StatusUnauthorized},
+		{"wrong", http.MethodGet, "/v1/tasks", "Bearer nope", http.StatusUnauthorized},
+		{"header", http.MethodGet, "/v1/tasks", "Bearer " + testToken, http.StatusOK},
+		{"query on events", http.MethodGet, "/v1/events?access_token=" + testToken, "", http.StatusOK},
+		{"query on tasks", http.MethodGet, "/v1/tasks?access_token=" + testToken, "", http.StatusUnauthorized},
+		{"query on task write", http.MethodDelete, "/v1/tasks/none?access_token=" + testToken, "", http.StatusUnauthorized},
+		{"wrong query on events", http.MethodGet, "/v1/events?access_token=nope", "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(tc.method, f.http.URL+tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		resp, err := f.http.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Fatalf("%s: status %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestTaskCRUDWithETags(t *testing.T) {
+	f := newAPIFixture(t)
+
+	var created taskBody
+	resp := f.do(http.MethodPost, "/v1/tasks", map[string]any{"title": "pay rent", "tags": []string{"finance"}}, &created)
+	expectStatus(t, resp, http.StatusCreated)
+	etag := resp.Header.Get("ETag")
+	if etag == "" || resp.Header.Get("Location") != "/v1/tasks/"+created.ID {
+		t.Fatalf("missing ETag or Location: %v", resp.Header)
+	}
+	if created.State != "Inbox" || created.Priority != "Medium" || !created.CreatedAt.Equal(testNow) {
+		t.Fatalf("expected server defaults, got %+v", created)
+	}
+
+	resp = f.do(http.MethodGet, "/v1/tasks/"+created.ID, nil, nil)
+	expectStatus(t, resp, http.StatusOK)
+	if resp.Header.Get("ETag") != etag {
+		t.Fatalf("GET ETag %s differs from POST ETag %s", resp.Header.Get("ETag"), etag)
+	}
+
+	next := created
+	next.State = "Planned"
+	next.Tags = []string{"finance", "home"}
+	expectStatus(t, f.do(http.MethodPut, "/v1/tasks/"+created.ID, next, nil), http.StatusPreconditionRequired)
+	expectStatus(t, f.do(http.MethodPut, "/v1/tasks/"+created.ID, next, nil, "If-Match", `"stale"`), http.StatusPreconditionFailed)
+
+	var updated taskBody
+	resp = f.do(http.MethodPut, "/v1/tasks/"+created.ID, next, &updated, "If-Match", etag)
+	expectStatus(t, resp, http.StatusOK)
+	if updated.State != "Planned" || strings.Join(updated.Tags, ",") != "finance,home" {
+		t.Fatalf("unexpected update result: %+v", updated)
+	}
+	newTag := resp.Header.Get("ETag")
+	if newTag == etag {
+		t.Fatal("expected a new ETag after update")
+	}
+
+	// A write from another process (the TUI) invalidates the client's ETag.
+	stored, err := f.repo.GetTask(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	stored.Title = "pay rent today"
+	if err := f.repo.UpdateTask(context.Background(), stored); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	var failure errorBody
+	resp = f.do(http.MethodDelete, "/v1/tasks/"+created.ID, nil, &failure, "If-Match", newTag)
+	expectStatus(t, resp, http.StatusPreconditionFailed)
+	if failure.Error.Code != "precondition_failed" {
+		t.Fatalf("unexpected error body: %+v", failure)
+	}
+
+	expectStatus(t, f.do(http.MethodDelete, "/v1/tasks/"+created.ID, nil, nil, "If-Match", "*"), http.StatusNoContent)
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks/"+created.ID, nil, nil), http.StatusNotFound)
+}
+
+func TestTaskValidationAndListing(t *testing.T) {
+	f := newAPIFixture(t)
+
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"title": ""}, nil), http.StatusBadRequest)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"title": "x", "priority": "Urgent"}, nil), http.StatusBadRequest)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"title": "x", "colour": "red"}, nil), http.StatusBadRequest)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"id": "task-a", "title": "a", "state": "Planned"}, nil), http.StatusCreated)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"id": "task-a", "title": "again"}, nil), http.StatusConflict)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"id": "task-b", "title": "b"}, nil), http.StatusCreated)
+
+	var list listBody[taskBody]
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks?state=Planned", nil, &list), http.StatusOK)
+	if len(list.Items) != 1 || list.Items[0].ID != "task-a" {
+		t.Fatalf("unexpected filtered list: %+v", list.Items)
+	}
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks?sort=sideways", nil, nil), http.StatusBadRequest)
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks?limit=-1", nil, nil), http.StatusBadRequest)
+}
+
+func TestReminderTagAndRecurrenceCRUD(t *testing.T) {
+	f := newAPIFixture(t)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"id": "task-a", "title": "a"}, nil), http.StatusCreated)
+
+	trigger := testNow.Add(time.Hour)
+	expectStatus(t, f.do(http.MethodPost, "/v1/reminders", map[string]any{"task_id": "task-a", "type": "Loud", "trigger_at": trigger}, nil), http.StatusBadRequest)
+	expectStatus(t, f.do(http.MethodPost, "/v1/reminders", map[string]any{"task_id": "task-x", "type": "Hard", "trigger_at": trigger}, nil), http.StatusBadRequest)
+	var rem reminderBody
+	resp := f.do(http.MethodPost, "/v1/reminders", map[string]any{"task_id": "task-a", "type": "Hard", "trigger_at": trigger}, &rem)
+	expectStatus(t, resp, http.StatusCreated)
+	if rem.Enabled == nil || !*rem.Enabled || f.server.engine.Len() != 1 {
+		t.Fatalf("expected an enabled, scheduled reminder: %+v (queued %d)", rem, f.server.engine.Len())
+	}
+	disabled := false
+	rem.Enabled = &disabled
+	expectStatus(t, f.do(http.MethodPut, "/v1/reminders/"+rem.ID, rem, nil, "If-Match", resp.Header.Get("ETag")), http.StatusOK)
+	if f.server.engine.Len() != 0 {
+		t.Fatal("disabling a reminder should cancel it in the engine")
+	}
+	var reminders listBody[reminderBody]
+	expectStatus(t, f.do(http.MethodGet, "/v1/reminders?task_id=task-a&enabled=false", nil, &reminders), http.StatusOK)
+	if len(reminders.Items) != 1 || reminders.Items[0].ID != rem.ID {
+		t.Fatalf("unexpected reminders: %+v", reminders.Items)
+	}
+
+	var tag tagBody
+	resp = f.do(http.MethodPost, "/v1/tags", map[string]any{"name": " #Home "}, &tag)
+	expectStatus(t, resp, http.StatusCreated)
+	if tag.Name != "home" {
+		t.Fatalf("expected normalised tag name, got %q", tag.Name)
+	}
+	expectStatus(t, f.do(http.MethodPost, "/v1/tags", map[string]any{"name": "home"}, nil), http.StatusConflict)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tags", map[string]any{"name": "  "}, nil), http.StatusBadRequest)
+	tag.Name = "house"
+	expectStatus(t, f.do(http.MethodPut, "/v1/tags/"+tag.ID, tag, &tag, "If-Match", resp.Header.Get("ETag")), http.StatusOK)
+	if tag.Name != "house" {
+		t.Fatalf("expected renamed tag, got %+v", tag)
+	}
+
+	expectStatus(t, f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "hourly", "start_at": testNow}, nil), http.StatusBadRequest)
+	expectStatus(t, f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "every_n_days", "start_at": testNow, "timezone": "Mars/Olympus"}, nil), http.StatusBadRequest)
+	var rule recurrenceBody
+	resp = f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "every_n_days", "interval": 3, "start_at": testNow, "timezone": "Europe/Berlin"}, &rule)
+	expectStatus(t, resp, http.StatusCreated)
+	if rule.Interval != 3 || rule.Timezone != "Europe/Berlin" {
+		t.Fatalf("unexpected recurrence: %+v", rule)
+	}
+	expectStatus(t, f.do(http.MethodDelete, "/v1/recurrences/"+rule.ID, nil, nil, "If-Match", resp.Header.Get("ETag")), http.StatusNoContent)
+
+	expectStatus(t, f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "weekday", "start_at": testNow}, nil), http.StatusBadRequest)
+	expectStatus(t, f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "every_weekday", "start_at": testNow, "weekdays": "mon,funday"}, nil), http.StatusBadRequest)
+	resp = f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "every_weekday", "start_at": testNow, "weekdays": "mon,thu"}, &rule)
+	expectStatus(t, resp, http.StatusCreated)
+	if rule.RuleType != "every_weekday" || rule.Weekdays != "mon,thu" {
+		t.Fatalf("unexpected weekday recurrence: %+v", rule)
+	}
+	expectStatus(t, f.do(http.MethodDelete, "/v1/recurrences/"+rule.ID, nil, nil, "If-Match", resp.Header.Get("ETag")), http.StatusNoContent)
+	resp = f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "after_completion", "start_at": testNow, "after_complete_in": "36h"}, &rule)
+	expectStatus(t, resp, http.StatusCreated)
+	if rule.AfterCompleteIn != "36h0m0s" {
+		t.Fatalf("unexpected after-completion recurrence: %+v", rule)
+	}
+	expectStatus(t, f.do(http.MethodDelete, "/v1/recurrences/"+rule.ID, nil, nil, "If-Match", resp.Header.Get("ETag")), http.StatusNoContent)
+	expectStatus(t, f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "rrule", "start_at": testNow, "rrule": "FREQ=MONTHLY;BYDAY=2XX"}, nil), http.StatusBadRequest)
+	resp = f.do(http.MethodPost, "/v1/recurrences", map[string]any{"task_id": "task-a", "rule_type": "rrule", "start_at": testNow, "rrule": "FREQ=MONTHLY;BYDAY=2TU;COUNT=10"}, &rule)
+	expectStatus(t, resp, http.StatusCreated)
+	if rule.RuleType != "rrule" || rule.RRule != "FREQ=MONTHLY;BYDAY=2TU;COUNT=10" {
+		t.Fatalf("unexpected rrule recurrence: %+v", rule)
+	}
+	expectStatus(t, f.do(http.MethodDelete, "/v1/recurrences/"+rule.ID, nil, nil, "If-Match", resp.Header.Get("ETag")), http.StatusNoContent)
+
+	var rules listBody[recurrenceBody]
+	expectStatus(t, f.do(http.MethodGet, "/v1/recurrences?task_id=task-a", nil, &rules), http.StatusOK)
+	if len(rules.Items) != 0 {
+		t.Fatalf("expected no recurrences, got %+v", rules.Items)
+	}
+}
+
+func TestCompletingRecurringTaskCreatesNextOccurrence(t *testing.T) {
+	f := newAPIFixture(t)
+	scheduled := testNow.Add(time.Hour)
+
+	var task taskBody
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"title": "stand-up", "state": "Planned", "scheduled_at": scheduled}, &task), http.StatusCreated)
+	expectStatus(t, f.do(http.MethodPost, "/v1/recurrences", map[string]any{
+		"task_id": task.ID, "rule_type": "every_weekday", "interval": 1, "timezone": "UTC", "start_at": scheduled,
+	}, nil), http.StatusCreated)
+	expectStatus(t, f.do(http.MethodPost, "/v1/reminders", map[string]any{
+		"task_id": task.ID, "type": "Hard", "trigger_at": scheduled.Add(-10 * time.Minute),
+	}, nil), http.StatusCreated)
+
+	done := task
+	completed := testNow
+	done.State, done.CompletedAt = "Done", &completed
+	expectStatus(t, f.do(http.MethodPut, "/v1/tasks/"+task.ID, done, nil, "If-Match", "*"), http.StatusOK)
+
+	var list struct {
+		Items []taskBody `json:"items"`
+	}
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks?state=Planned", nil, &list), http.StatusOK)
+	wantAt := scheduled.AddDate(0, 0, 1)
+	if len(list.Items) != 1 || list.Items[0].Title != "stand-up" || list.Items[0].ScheduledAt == nil || !list.Items[0].ScheduledAt.Equal(wantAt) {
+		t.Fatalf("expected the next stand-up on %s, got %+v", wantAt, list.Items)
+	}
+	pending := f.server.engine.Pending()
+	if len(pending) != 1 || pending[0].TaskID != list.Items[0].ID || !pending[0].TriggerAt.Equal(wantAt.Add(-10*time.Minute)) {
+		t.Fatalf("expected only the carried reminder armed, got %+v", pending)
+	}
+
+	// Replaying the completion creates nothing new.
+	expectStatus(t, f.do(http.MethodPut, "/v1/tasks/"+task.ID, done, nil, "If-Match", "*"), http.StatusOK)
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks", nil, &list), http.StatusOK)
+	if len(list.Items) != 2 {
+		t.Fatalf("expected the done task and one next occurrence, got %+v", list.Items)
+	}
+}
+
+func TestSkippingAnOccurrenceMovesItsTask(t *testing.T) {
+	f := newAPIFixture(t)
+	scheduled := testNow.Add(time.Hour)
+
+	var task taskBody
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"title": "stand-up", "state": "Planned", "scheduled_at": scheduled}, &task), http.StatusCreated)
+	expectStatus(t, f.do(http.MethodPost, "/v1/reminders", map[string]any{
+		"task_id": task.ID, "type": "Hard", "trigger_at": scheduled.Add(-10 * time.Minute),
+	}, nil), http.StatusCreated)
+	until := scheduled.AddDate(0, 0, 10)
+	expectStatus(t, f.do(http.MethodPost, "/v1/recurrences", map[string]any{
+		"task_id": task.ID, "rule_type": "every_n_days", "start_at": scheduled, "until": until, "count": 3,
+	}, nil), http.StatusBadRequest)
+	var rule recurrenceBody
+	resp := f.do(http.MethodPost, "/v1/recurrences", map[string]any{
+		"task_id": task.ID, "rule_type": "every_n_days", "start_at": scheduled, "until": until,
+	}, &rule)
+	expectStatus(t, resp, http.StatusCreated)
+	if rule.Until == nil || !rule.Until.Equal(until) || rule.Exceptions != nil {
+		t.Fatalf("unexpected recurrence: %+v", rule)
+	}
+
+	rule.Exceptions = []exceptionBody{{Kind: "skip", At: scheduled}, {Kind: "move", At: scheduled.AddDate(0, 0, 1), To: ptrTime(scheduled.AddDate(0, 0, 1).Add(3 * time.Hour))}}
+	expectStatus(t, f.do(http.MethodPut, "/v1/recurrences/"+rule.ID, rule, &rule, "If-Match", resp.Header.Get("ETag")), http.StatusOK)
+	if len(rule.Exceptions) != 2 {
+		t.Fatalf("expected both exceptions stored, got %+v", rule.Exceptions)
+	}
+	wantAt := scheduled.AddDate(0, 0, 1).Add(3 * time.Hour)
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks/"+task.ID, nil, &task), http.StatusOK)
+	if task.ScheduledAt == nil || !task.ScheduledAt.Equal(wantAt) {
+		t.Fatalf("expected the task moved to %s, got %+v", wantAt, task.ScheduledAt)
+	}
+	pending := f.server.engine.Pending()
+	if len(pending) != 1 || !pending[0].TriggerAt.Equal(wantAt.Add(-10*time.Minute)) {
+		t.Fatalf("expected the reminder re-armed with the task, got %+v", pending)
+	}
+
+	// Leaving exceptions out keeps them; an empty list clears them.
+	rule.Exceptions = nil
+	expectStatus(t, f.do(http.MethodPut, "/v1/recurrences/"+rule.ID, rule, &rule, "If-Match", "*"), http.StatusOK)
+	if len(rule.Exceptions) != 2 {
+		t.Fatalf("expected exceptions kept, got %+v", rule.Exceptions)
+	}
+	var cleared recurrenceBody
+	expectStatus(t, f.do(http.MethodPut, "/v1/recurrences/"+rule.ID, map[string]any{
+		"task_id": task.ID, "rule_type": "every_n_days", "interval": 1, "timezone": "UTC", "start_at": scheduled, "until": until, "exceptions": []any{},
+	}, &cleared, "If-Match", "*"), http.StatusOK)
+	if len(cleared.Exceptions) != 0 {
+		t.Fatalf("expected exceptions cleared, got %+v", cleared.Exceptions)
+	}
+	expectStatus(t, f.do(http.MethodGet, "/v1/tasks/"+task.ID, nil, &task), http.StatusOK)
+	// The task stood for the moved occurrence, which is back in its place.
+	if wantAt = scheduled.AddDate(0, 0, 1); !task.ScheduledAt.Equal(wantAt) {
+		t.Fatalf("expected the task back at %s, got %s", wantAt, task.ScheduledAt)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+// openEventStream connects to /v1/events and consumes the greeting comment.
+func (f *apiFixture) openEventStream(ctx context.Context) *bufio.Scanner {
+	f.t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, f.http.URL+"/v1/events?access_token="+testToken, nil)
+	resp, err := f.http.Client().Do(req)
+	if err != nil {
+		f.t.Fatalf("open stream: %v", err)
+	}
+	f.t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		f.t.Fatalf("unexpected content type %q", ct)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": connected" {
+		f.t.Fatalf("expected connected comment, got %q", lines.Text())
+	}
+	return lines
+}
+
+// nextEvent returns the lines of the next non-comment event on the stream.
+func nextEvent(t *testing.T, lines *bufio.Scanner) []string {
+	t.Helper()
+	got := make(chan []string, 1)
+	go func() {
+		var event []string
+		for lines.Scan() {
+			if lines.Text() == "" {
+				if len(event) > 0 && !strings.HasPrefix(event[0], ":") {
+					got <- event
+					return
+				}
+				event = nil
+				continue
+			}
+			event = append(event, lines.Text())
+		}
+	}()
+	select {
+	case event := <-got:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reminder event")
+		return nil
+	}
+}
+
+func TestEventStreamDeliversReminderFirings(t *testing.T) {
+	f := newAPIFixture(t)
+	expectStatus(t, f.do(http.MethodPost, "/v1/tasks", map[string]any{"id": "task-a", "title": "a"}, nil), http.StatusCreated)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := f.openEventStream(ctx)
+
+	trigger := testNow.Add(5 * time.Minute)
+	expectStatus(t, f.do(http.MethodPost, "/v1/reminders", map[string]any{"id": "rem-1", "task_id": "task-a", "type": "Soft", "trigger_at": trigger}, nil), http.StatusCreated)
+	// One timer for the change poll and one for the reminder.
+	f.clock.BlockUntil(2)
+	f.clock.Advance(5 * time.Minute)
+
+	event := nextEvent(t, lines)
+	if len(event) != 3 || event[0] != "event: reminder" || event[1] != "id: rem-1" {
+		t.Fatalf("unexpected event: %q", event)
+	}
+	var data eventBody
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(event[2], "data: ")), &data); err != nil {
+		t.Fatalf("decode event data: %v", err)
+	}
+	if data.TaskID != "task-a" || data.Type != "Soft" || !data.TriggerAt.Equal(trigger) {
+		t.Fatalf("unexpected event data: %+v", data)
+	}
+}
+
+func TestEventStreamPicksUpRemindersWrittenElsewhere(t *testing.T) {
+	f := newAPIFixture(t)
+	// A second repository on the same database stands in for the TUI.
+	other, err := storage.NewSQLiteRepository(f.db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := f.openEventStream(ctx)
+
+	if err := other.CreateTask(ctx, storage.Task{ID: "task-b", Title: "b", State: "Inbox", Priority: "Medium", Energy: "Light", CreatedAt: testNow}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	trigger := testNow.Add(10 * time.Minute)
+	for _, rem := range []storage.Reminder{
+		{ID: "rem-kept", TaskID: "task-b", Type: "Soft", TriggerAt: trigger, Enabled: true, CreatedAt: testNow},
+		{ID: "rem-removed", TaskID: "task-b", Type: "Soft", TriggerAt: trigger.Add(-time.Minute), Enabled: true, CreatedAt: testNow},
+	} {
+		if err := other.CreateReminder(ctx, rem); err != nil {
+			t.Fatalf("create reminder %s: %v", rem.ID, err)
+		}
+	}
+
+	f.clock.BlockUntil(1)
+	f.clock.Advance(changePollInterval)
+	// The next poll plus the newly scheduled reminder.
+	f.clock.BlockUntil(2)
+	if err := other.DeleteReminder(ctx, "rem-removed"); err != nil {
+		t.Fatalf("delete reminder: %v", err)
+	}
+	f.clock.Advance(changePollInterval)
+	f.clock.BlockUntil(2)
+	f.clock.Advance(10 * time.Minute)
+
+	if event := nextEvent(t, lines); len(event) != 3 || event[1] != "id: rem-kept" {
+		t.Fatalf("unexpected event: %q", event)
+	}
+}
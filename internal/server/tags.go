@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	var filter storage.TagListFilter
+	var err error
+	if filter.Limit, err = queryInt(r, "limit"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if filter.Offset, err = queryInt(r, "offset"); err != nil {
+		writeError(w, err)
+		return
+	}
+	tags, err := s.repo.ListTags(r.Context(), filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]tagBody, 0, len(tags))
+	for _, tag := range tags {
+		out = append(out, tagFromStore(tag))
+	}
+	writeList(w, out)
+}
+
+func (s *Server) getTag(w http.ResponseWriter, r *http.Request) {
+	tag, err := s.repo.GetTag(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResource(w, http.StatusOK, tagFromStore(tag))
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request) {
+	var body tagBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID == "" {
+		body.ID = model.NewID("tag")
+	}
+	if body.CreatedAt.IsZero() {
+		body.CreatedAt = s.clock.Now().UTC()
+	}
+
+	ctx := r.Context()
+	var created storage.Tag
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		name, err := uniqueTagName(ctx, tx, body.ID, body.Name)
+		if err != nil {
+			return err
+		}
+		if err := ensureAbsent(tx.GetTag(ctx, body.ID)); err != nil {
+			return fmt.Errorf("tag %s: %w", body.ID, err)
+		}
+		if err := tx.CreateTag(ctx, storage.Tag{ID: body.ID, Name: name, CreatedAt: body.CreatedAt}); err != nil {
+			return err
+		}
+		created, err = tx.GetTag(ctx, body.ID)
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/tags/"+created.ID)
+	writeResource(w, http.StatusCreated, tagFromStore(created))
+}
+
+// updateTag renames a tag; every task carrying it follows.
+func (s *Server) updateTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body tagBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID != "" && body.ID != id {
+		writeError(w, fmt.Errorf("%w: body id %q does not match path", ErrBadRequest, body.ID))
+		return
+	}
+
+	ctx := r.Context()
+	var updated storage.Tag
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetTag(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, tagFromStore(current)); err != nil {
+			return err
+		}
+		name, err := uniqueTagName(ctx, tx, id, body.Name)
+		if err != nil {
+			return err
+		}
+		if err := tx.UpdateTag(ctx, storage.Tag{ID: id, Name: name, CreatedAt: current.CreatedAt}); err != nil {
+			return err
+		}
+		updated, err = tx.GetTag(ctx, id)
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResource(w, http.StatusOK, tagFromStore(updated))
+}
+
+func (s *Server) deleteTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := r.Context()
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetTag(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, tagFromStore(current)); err != nil {
+			return err
+		}
+		return tx.DeleteTag(ctx, id)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// uniqueTagName normalises name the way quick-add does and rejects names
+// already used by another tag.
+func uniqueTagName(ctx context.Context, repo storage.Repository, id, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "#")))
+	if name == "" {
+		return "", fmt.Errorf("%w: %q", storage.ErrInvalidTagName, name)
+	}
+	tags, err := repo.ListTags(ctx, storage.TagListFilter{})
+	if err != nil {
+		return "", err
+	}
+	for _, tag := range tags {
+		if tag.Name == name && tag.ID != id {
+			return "", fmt.Errorf("tag name %q: %w", name, ErrConflict)
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	offset, err := queryInt(r, "offset")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tasks, err := s.repo.ListTasks(r.Context(), storage.TaskListFilter{
+		States:     queryList(r, "state"),
+		Priorities: queryList(r, "priority"),
+		Energies:   queryList(r, "energy"),
+		Tag:        q.Get("tag"),
+		Search:     q.Get("search"),
+		Sort:       storage.TaskSort(q.Get("sort")),
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]taskBody, 0, len(tasks))
+	for _, task := range tasks {
+		out = append(out, taskFromStore(task))
+	}
+	writeList(w, out)
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	task, err := s.repo.GetTask(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResource(w, http.StatusOK, taskFromStore(task))
+}
+
+// createTask fills in the id, state, priority, energy and created_at a client
+// leaves out, as quick-add does.
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	var body taskBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID == "" {
+		body.ID = model.NewID("task")
+	}
+	if body.State == "" {
+		body.State = string(model.TaskStateInbox)
+	}
+	if body.Priority == "" {
+		body.Priority = string(model.DefaultPriority)
+	}
+	if body.Energy == "" {
+		body.Energy = string(model.DefaultEnergy)
+	}
+	if body.CreatedAt.IsZero() {
+		body.CreatedAt = s.clock.Now().UTC()
+	}
+
+	ctx := r.Context()
+	var created storage.Task
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		if err := ensureAbsent(tx.GetTask(ctx, body.ID)); err != nil {
+			return fmt.Errorf("task %s: %w", body.ID, err)
+		}
+		if err := tx.CreateTask(ctx, body.store()); err != nil {
+			return err
+		}
+		var err error
+		created, err = tx.GetTask(ctx, body.ID)
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/tasks/"+created.ID)
+	writeResource(w, http.StatusCreated, taskFromStore(created))
+}
+
+// updateTask replaces the task with the request body. created_at may be
+// omitted to keep the stored value.
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body taskBody
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.ID != "" && body.ID != id {
+		writeError(w, fmt.Errorf("%w: body id %q does not match path", ErrBadRequest, body.ID))
+		return
+	}
+
+	ctx := r.Context()
+	var updated storage.Task
+	var reminders []storage.Reminder
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, taskFromStore(current)); err != nil {
+			return err
+		}
+		next := body.store()
+		next.ID = id
+		if next.CreatedAt.IsZero() {
+			next.CreatedAt = current.CreatedAt
+		}
+		if err := tx.UpdateTask(ctx, next); err != nil {
+			return err
+		}
+		if updated, err = tx.GetTask(ctx, id); err != nil {
+			return err
+		}
+		if current.State == string(model.TaskStateDone) || updated.State != string(model.TaskStateDone) {
+			return nil
+		}
+		// Completing a recurring task creates its next occurrence, which
+		// takes over the task's reminders.
+		occurrence, err := storage.NextOccurrence(ctx, tx, updated)
+		if err != nil || occurrence == nil {
+			return err
+		}
+		for _, taskID := range []string{id, occurrence.ID} {
+			rems, err := tx.ListReminders(ctx, storage.ReminderListFilter{TaskID: taskID})
+			if err != nil {
+				return err
+			}
+			reminders = append(reminders, rems...)
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, rem := range reminders {
+		s.syncReminder(rem)
+	}
+	writeResource(w, http.StatusOK, taskFromStore(updated))
+}
+
+// deleteTask removes the task; its reminders and recurrences go with it.
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := r.Context()
+	var reminderIDs []string
+	err := s.repo.WithTx(ctx, func(tx storage.Repository) error {
+		current, err := tx.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, taskFromStore(current)); err != nil {
+			return err
+		}
+		reminders, err := tx.ListReminders(ctx, storage.ReminderListFilter{TaskID: id})
+		if err != nil {
+			return err
+		}
+		for _, rem := range reminders {
+			reminderIDs = append(reminderIDs, rem.ID)
+		}
+		return tx.DeleteTask(ctx, id)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.cancelReminders(reminderIDs)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ensureAbsent turns the result of a Get into ErrConflict when the row exists.
+func ensureAbsent[T any](_ T, err error) error {
+	switch {
+	case err == nil:
+		return ErrConflict
+	case errors.Is(err, storage.ErrNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+// requireTaskID rejects child records that do not name an existing task.
+func requireTaskID(ctx context.Context, repo storage.Repository, taskID string) error {
+	if taskID == "" {
+		return fmt.Errorf("%w: task_id is required", ErrBadRequest)
+	}
+	if _, err := repo.GetTask(ctx, taskID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("%w: task %q does not exist", ErrBadRequest, taskID)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChangeEntity names the kind of row a Change touched.
+type ChangeEntity string
+
+const (
+	ChangeTask       ChangeEntity = "task"
+	ChangeReminder   ChangeEntity = "reminder"
+	ChangeTag        ChangeEntity = "tag"
+	ChangeRecurrence ChangeEntity = "recurrence"
+)
+
+// ChangeOp is what a write did to the row.
+type ChangeOp string
+
+const (
+	ChangeCreate ChangeOp = "create"
+	ChangeUpdate ChangeOp = "update"
+	ChangeDelete ChangeOp = "delete"
+)
+
+// changeLogRetention is how many change_log rows are kept; readers that fall
+// further behind still see that something changed, just not every row.
+const changeLogRetention = 10000
+
+// Change is one change_log row. Seq increases with every write to the
+// database, whichever process made it.
+type Change struct {
+	Seq       int64
+	Entity    ChangeEntity
+	EntityID  string
+	Op        ChangeOp
+	Origin    string
+	ChangedAt time.Time
+}
+
+// lastChangedBatch bounds how many IDs one LastChangedAt query binds.
+const lastChangedBatch = 500
+
+// LastChangedAt returns when each of ids of entity was last written, deleted
+// rows included. Unlike the change log it is never trimmed; IDs never written
+// through a repository are left out.
+func (r *SQLiteRepository) LastChangedAt(ctx context.Context, entity ChangeEntity, ids []string) (map[string]time.Time, error) {
+	out := make(map[string]time.Time, len(ids))
+	for start := 0; start < len(ids); start += lastChangedBatch {
+		batch := ids[start:min(start+lastChangedBatch, len(ids))]
+		clauses, args := appendInClause([]string{"entity = ?"}, []any{entity}, "entity_id", batch)
+		rows, err := r.db.QueryContext(ctx, `SELECT entity_id, changed_at FROM row_changes WHERE `+strings.Join(clauses, " AND "), args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id, changed string
+			if err := rows.Scan(&id, &changed); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			at, err := parseRequiredTime(changed)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			out[id] = at
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Origin identifies this repository's writes in the change log. Repositories
+// handed to WithTx share the origin of their parent.
+func (r *SQLiteRepository) Origin() string {
+	return r.origin
+}
+
+// LatestChangeSeq returns the newest change sequence number, or 0 when
+// nothing has been written yet.
+func (r *SQLiteRepository) LatestChangeSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM change_log`).Scan(&seq)
+	return seq, err
+}
+
+// ChangesSince returns the changes after seq, oldest first.
+func (r *SQLiteRepository) ChangesSince(ctx context.Context, seq int64) ([]Change, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT seq, entity, entity_id, op, origin, changed_at
+		FROM change_log WHERE seq > ? ORDER BY seq ASC`, seq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Change, 0)
+	for rows.Next() {
+		var c Change
+		var changed string
+		if err := rows.Scan(&c.Seq, &c.Entity, &c.EntityID, &c.Op, &c.Origin, &changed); err != nil {
+			return nil, err
+		}
+		if c.ChangedAt, err = parseRequiredTime(changed); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// write runs fn in a transaction and records the change it made, both in the
+// change log and, for anything belonging to a task, in its history.
+func (r *SQLiteRepository) write(ctx context.Context, entity ChangeEntity, id string, op ChangeOp, fn func(tx dbtx) error) error {
+	return r.inTx(ctx, func(tx dbtx) error {
+		before, err := auditSnapshot(ctx, tx, entity, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(ctx, tx, entity, id)
+		if err != nil {
+			return err
+		}
+		if err := r.recordTaskEvents(ctx, tx, entity, before, after); err != nil {
+			return err
+		}
+		return r.logChange(ctx, tx, entity, id, op)
+	})
+}
+
+// logChange records one change to the row in change_log and row_changes,
+// trimming change_log to changeLogRetention.
+func (r *SQLiteRepository) logChange(ctx context.Context, tx dbtx, entity ChangeEntity, id string, op ChangeOp) error {
+	now := mustTime(time.Now())
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO change_log (entity, entity_id, op, origin, changed_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		entity, id, op, r.origin, now,
+	)
+	if err != nil {
+		return fmt.Errorf("record change: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO row_changes (entity, entity_id, changed_at) VALUES (?, ?, ?)
+		ON CONFLICT (entity, entity_id) DO UPDATE SET changed_at = excluded.changed_at`,
+		entity, id, now,
+	); err != nil {
+		return fmt.Errorf("record change: %w", err)
+	}
+	seq, err := res.LastInsertId()
+	if err != nil || seq <= changeLogRetention {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM change_log WHERE seq <= ?`, seq-changeLogRetention)
+	return err
+}
@@ -13,6 +13,9 @@ type Task struct {
 	DueAt       *time.Time
 	CreatedAt   time.Time
 	CompletedAt *time.Time
+	// Tags holds tag names. CreateTask attaches them; UpdateTask replaces the
+	// task's tags only when Tags is non-nil.
+	Tags []string
 }
 
 type Reminder struct {
@@ -42,10 +45,86 @@ type RecurrenceRule struct {
 	NextAt        *time.Time
 	Enabled       bool
 	CreatedAt     time.Time
+	// Weekdays limits an every_weekday rule to these days; empty means
+	// Monday to Friday.
+	Weekdays []time.Weekday
+	// AfterCompleteIn is the after_completion delay; zero means
+	// IntervalValue days.
+	AfterCompleteIn time.Duration
+	// RRule holds the RFC 5545 RRULE, RDATE and EXDATE lines of an rrule
+	// rule; StartAt is its DTSTART.
+	RRule string
+	// Until and Count end the series; nil and 0 mean it never ends.
+	Until *time.Time
+	Count int
+	// Exceptions are written with the rule. UpdateRecurrence leaves the
+	// stored ones alone when Exceptions is nil; pass an empty slice to clear
+	// them.
+	Exceptions []RecurrenceException
 }
 
+// RecurrenceException skips or moves one occurrence of a rule, or pauses it
+// from At until To.
+type RecurrenceException struct {
+	Kind string
+	At   time.Time
+	To   *time.Time
+}
+
+// SchedulerState is the single reminder-engine checkpoint row. LastTickAt is
+// the trigger time of the last delivered reminder and CheckpointCursor its ID.
+type SchedulerState struct {
+	LastTickAt       *time.Time
+	CheckpointCursor string
+	UpdatedAt        time.Time
+}
+
+// TimeRange bounds a timestamp column. From is inclusive, To is exclusive and
+// either may be nil. A non-empty range excludes rows where the column is NULL.
+// Bounds are compared with millisecond resolution.
+type TimeRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+func (r TimeRange) IsZero() bool {
+	return r.From == nil && r.To == nil
+}
+
+// TaskSearchResult is a full-text match. Snippet is an excerpt of the best
+// matching column with matched terms wrapped in [ and ]; a higher Rank is a
+// better match.
+type TaskSearchResult struct {
+	Task    Task
+	Snippet string
+	Rank    float64
+}
+
+type TaskSort string
+
+const (
+	TaskSortCreatedDesc   TaskSort = "created_desc"
+	TaskSortCreatedAsc    TaskSort = "created_asc"
+	TaskSortScheduledAsc  TaskSort = "scheduled_asc"
+	TaskSortDueAsc        TaskSort = "due_asc"
+	TaskSortCompletedDesc TaskSort = "completed_desc"
+	TaskSortPriorityDesc  TaskSort = "priority_desc"
+	TaskSortTitleAsc      TaskSort = "title_asc"
+)
+
 type TaskListFilter struct {
-	State  string
+	State      string
+	States     []string
+	Priorities []string
+	Energies   []string
+	Tag        string
+	Scheduled  TimeRange
+	Due        TimeRange
+	Completed  TimeRange
+	// Search matches a case-insensitive substring of title or description.
+	Search string
+	// Sort defaults to TaskSortCreatedDesc.
+	Sort   TaskSort
 	Limit  int
 	Offset int
 }
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventSource names the kind of client whose write produced a TaskEvent.
+type EventSource string
+
+const (
+	SourceTUI  EventSource = "tui"
+	SourceCLI  EventSource = "cli"
+	SourceAPI  EventSource = "api"
+	SourceSync EventSource = "sync"
+	// SourceSystem marks writes from a repository nobody labelled.
+	SourceSystem EventSource = "system"
+)
+
+// Event fields beyond the task's own columns.
+const (
+	EventCreated    = "created"
+	EventDeleted    = "deleted"
+	EventReminder   = "reminder"
+	EventRecurrence = "recurrence"
+)
+
+// TaskEvent is one changed field of a task. Created and deleted events carry
+// the title; reminder and recurrence events summarise the rule.
+type TaskEvent struct {
+	ID         int64
+	TaskID     string
+	Field      string
+	OldValue   string
+	NewValue   string
+	Source     EventSource
+	OccurredAt time.Time
+}
+
+type TaskEventFilter struct {
+	TaskID   string
+	Field    string
+	Occurred TimeRange
+	// NewestFirst reverses the default oldest-first order.
+	NewestFirst bool
+	Limit       int
+	Offset      int
+}
+
+// WithSource returns a repository on the same database whose writes are
+// attributed to source.
+func (r *SQLiteRepository) WithSource(source EventSource) Repository {
+	out := *r
+	out.source = source
+	return &out
+}
+
+// ListTaskEvents returns recorded task events matching filter.
+func (r *SQLiteRepository) ListTaskEvents(ctx context.Context, filter TaskEventFilter) ([]TaskEvent, error) {
+	query := `SELECT id, task_id, field, old_value, new_value, source, occurred_at FROM task_events`
+	clauses := make([]string, 0, 4)
+	args := make([]any, 0, 6)
+	if filter.TaskID != "" {
+		clauses = append(clauses, "task_id = ?")
+		args = append(args, filter.TaskID)
+	}
+	if filter.Field != "" {
+		clauses = append(clauses, "field = ?")
+		args = append(args, filter.Field)
+	}
+	clauses, args = appendRangeClause(clauses, args, "occurred_at", filter.Occurred)
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	if filter.NewestFirst {
+		query += " ORDER BY julianday(occurred_at) DESC, id DESC"
+	} else {
+		query += " ORDER BY julianday(occurred_at) ASC, id ASC"
+	}
+	query += applyPagination(&args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]TaskEvent, 0)
+	for rows.Next() {
+		var ev TaskEvent
+		var occurred string
+		if err := rows.Scan(&ev.ID, &ev.TaskID, &ev.Field, &ev.OldValue, &ev.NewValue, &ev.Source, &occurred); err != nil {
+			return nil, err
+		}
+		if ev.OccurredAt, err = parseRequiredTime(occurred); err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+// CountSnoozes returns how many times each of taskIDs was snoozed, keyed by
+// task ID; tasks never snoozed are left out. A snooze is a write that moved
+// the task to Snoozed or, while it already was, gave it a new scheduled time.
+func (r *SQLiteRepository) CountSnoozes(ctx context.Context, taskIDs []string) (map[string]int, error) {
+	out := make(map[string]int)
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	clauses, args := appendInClause(nil, nil, "e.task_id", taskIDs)
+	// The events of one write share occurred_at, so counting distinct
+	// timestamps counts writes rather than changed fields.
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT e.task_id, COUNT(DISTINCT e.occurred_at)
+		FROM task_events e
+		WHERE `+clauses[0]+`
+			AND ((e.field = 'state' AND e.new_value = 'Snoozed')
+				OR (e.field = 'scheduled_at'
+					AND NOT EXISTS (
+						SELECT 1 FROM task_events w
+						WHERE w.task_id = e.task_id AND w.field = 'state' AND w.occurred_at = e.occurred_at)
+					AND (
+						SELECT s.new_value FROM task_events s
+						WHERE s.task_id = e.task_id AND s.field = 'state' AND s.id < e.id
+						ORDER BY s.id DESC LIMIT 1) = 'Snoozed'))
+		GROUP BY e.task_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		out[id] = n
+	}
+	return out, rows.Err()
+}
+
+// auditState is the audited view of one row; nil means the row is absent.
+type auditState struct {
+	taskID string
+	fields map[string]string
+}
+
+func auditSnapshot(ctx context.Context, tx dbtx, entity ChangeEntity, id string) (*auditState, error) {
+	q := &SQLiteRepository{db: tx}
+	var state *auditState
+	var err error
+	switch entity {
+	case ChangeTask:
+		var task Task
+		if task, err = q.GetTask(ctx, id); err == nil {
+			tags := append([]string{}, task.Tags...)
+			sort.Strings(tags)
+			state = &auditState{taskID: task.ID, fields: map[string]string{
+				"title":        task.Title,
+				"description":  task.Description,
+				"state":        task.State,
+				"priority":     task.Priority,
+				"energy":       task.Energy,
+				"scheduled_at": auditTime(task.ScheduledAt),
+				"due_at":       auditTime(task.DueAt),
+				"completed_at": auditTime(task.CompletedAt),
+				"tags":         strings.Join(tags, ","),
+			}}
+		}
+	case ChangeReminder:
+		var rem Reminder
+		if rem, err = q.GetReminder(ctx, id); err == nil {
+			summary := fmt.Sprintf("%s: %s at %s", rem.ID, rem.Type, auditTime(&rem.TriggerAt))
+			if rem.RepeatRule != "" {
+				summary += " repeating " + rem.RepeatRule
+			}
+			if !rem.Enabled {
+				summary += " (disabled)"
+			}
+			state = &auditState{taskID: rem.TaskID, fields: map[string]string{EventReminder: summary}}
+		}
+	case ChangeRecurrence:
+		var rule RecurrenceRule
+		if rule, err = q.GetRecurrence(ctx, id); err == nil {
+			summary := fmt.Sprintf("%s: %s x%d from %s (%s)", rule.ID, rule.RuleType, rule.IntervalValue, auditTime(&rule.StartAt), rule.Timezone)
+			if len(rule.Weekdays) > 0 {
+				summary += " on " + FormatWeekdays(rule.Weekdays)
+			}
+			if rule.AfterCompleteIn > 0 {
+				summary += " after " + rule.AfterCompleteIn.String()
+			}
+			if rule.RRule != "" {
+				summary += " " + strings.Join(strings.Fields(rule.RRule), " ")
+			}
+			if rule.Until != nil {
+				summary += " until " + auditTime(rule.Until)
+			}
+			if rule.Count > 0 {
+				summary += fmt.Sprintf(" for %d", rule.Count)
+			}
+			for _, ex := range rule.Exceptions {
+				summary += fmt.Sprintf("; %s %s", ex.Kind, auditTime(&ex.At))
+				if ex.To != nil {
+					summary += " to " + auditTime(ex.To)
+				}
+			}
+			if !rule.Enabled {
+				summary += " (disabled)"
+			}
+			state = &auditState{taskID: rule.TaskID, fields: map[string]string{EventRecurrence: summary}}
+		}
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return state, err
+}
+
+// recordTaskEvents appends an event for every audited field that differs
+// between before and after.
+func (r *SQLiteRepository) recordTaskEvents(ctx context.Context, tx dbtx, entity ChangeEntity, before, after *auditState) error {
+	var events []TaskEvent
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before != nil && after != nil && before.taskID != after.taskID:
+		// A reminder or rule moved to another task leaves one history and
+		// joins the other.
+		if err := r.recordTaskEvents(ctx, tx, entity, before, nil); err != nil {
+			return err
+		}
+		return r.recordTaskEvents(ctx, tx, entity, nil, after)
+	case entity == ChangeTask && before == nil:
+		events = append(events, TaskEvent{TaskID: after.taskID, Field: EventCreated, NewValue: after.fields["title"]})
+	case entity == ChangeTask && after == nil:
+		events = append(events, TaskEvent{TaskID: before.taskID, Field: EventDeleted, OldValue: before.fields["title"]})
+	default:
+		var oldFields, newFields map[string]string
+		taskID := ""
+		if before != nil {
+			oldFields, taskID = before.fields, before.taskID
+		}
+		if after != nil {
+			newFields, taskID = after.fields, after.taskID
+		}
+		names := make([]string, 0, len(oldFields)+len(newFields))
+		for name := range oldFields {
+			names = append(names, name)
+		}
+		for name := range newFields {
+			if _, ok := oldFields[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if oldFields[name] != newFields[name] {
+				events = append(events, TaskEvent{TaskID: taskID, Field: name, OldValue: oldFields[name], NewValue: newFields[name]})
+			}
+		}
+	}
+
+	source := r.source
+	if source == "" {
+		source = SourceSystem
+	}
+	now := mustTime(time.Now())
+	for _, ev := range events {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO task_events (task_id, field, old_value, new_value, source, occurred_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			ev.TaskID, ev.Field, ev.OldValue, ev.NewValue, source, now,
+		); err != nil {
+			return fmt.Errorf("record task event: %w", err)
+		}
+	}
+	return nil
+}
+
+func auditTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+)
+
+var (
+	ErrUnknownTimezone = errors.New("storage: unknown timezone")
+	ErrInvalidWeekday  = errors.New("storage: invalid weekday")
+)
+
+// ValidationError reports a row rejected by domain validation before it was
+// written. Err is the model error, so errors.Is(err, model.ErrInvalidState)
+// and friends keep working.
+type ValidationError struct {
+	Entity string
+	ID     string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("storage: invalid %s %q: %v", e.Entity, e.ID, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// TaskFromModel converts a domain task to its storage row.
+func TaskFromModel(in model.Task) Task {
+	return Task{
+		ID:          in.ID,
+		Title:       in.Title,
+		Description: in.Description,
+		State:       string(in.State),
+		Priority:    string(in.Priority),
+		Energy:      string(in.Energy),
+		ScheduledAt: copyTime(in.ScheduledAt),
+		DueAt:       copyTime(in.DueAt),
+		CreatedAt:   in.CreatedAt,
+		CompletedAt: copyTime(in.CompletedAt),
+		Tags:        copyStrings(in.Tags),
+	}
+}
+
+// TaskToModel converts a storage row to the domain task.
+func TaskToModel(in Task) model.Task {
+	return model.Task{
+		ID:          in.ID,
+		Title:       in.Title,
+		Description: in.Description,
+		State:       model.TaskState(in.State),
+		Priority:    model.Priority(in.Priority),
+		Energy:      model.Energy(in.Energy),
+		Tags:        copyStrings(in.Tags),
+		ScheduledAt: copyTime(in.ScheduledAt),
+		DueAt:       copyTime(in.DueAt),
+		CreatedAt:   in.CreatedAt,
+		CompletedAt: copyTime(in.CompletedAt),
+	}
+}
+
+// RecurrenceFromModel converts a domain rule for taskID to its storage row.
+// The rule's anchor becomes start_at and its location the timezone, which
+// NextAfter evaluates the rule in.
+func RecurrenceFromModel(id, taskID string, in model.RecurrenceRule, createdAt time.Time) RecurrenceRule {
+	return RecurrenceRule{
+		ID:              id,
+		TaskID:          taskID,
+		RuleType:        string(in.Type),
+		IntervalValue:   in.Interval,
+		Timezone:        zoneName(in.Anchor.Location()),
+		StartAt:         in.Anchor.UTC(),
+		Enabled:         true,
+		CreatedAt:       createdAt.UTC(),
+		Weekdays:        copyWeekdays(in.Weekdays),
+		AfterCompleteIn: in.AfterCompleteIn,
+		RRule:           in.RRule,
+		Until:           utcOrNil(in.Until),
+		Count:           in.Count,
+		Exceptions:      exceptionsFromModel(in.Exceptions),
+	}
+}
+
+// RecurrenceToModel converts a storage row to the domain rule, anchored at
+// start_at in the row's timezone. An empty timezone means UTC.
+func RecurrenceToModel(in RecurrenceRule) (model.RecurrenceRule, error) {
+	loc, err := time.LoadLocation(in.Timezone)
+	if err != nil {
+		return model.RecurrenceRule{}, fmt.Errorf("%w %q", ErrUnknownTimezone, in.Timezone)
+	}
+	out := model.RecurrenceRule{
+		Type:            model.RecurrenceType(in.RuleType),
+		Interval:        in.IntervalValue,
+		Anchor:          in.StartAt.In(loc),
+		Weekdays:        copyWeekdays(in.Weekdays),
+		AfterCompleteIn: in.AfterCompleteIn,
+		RRule:           in.RRule,
+		Count:           in.Count,
+	}
+	if in.Until != nil {
+		out.Until = in.Until.In(loc)
+	}
+	for _, ex := range in.Exceptions {
+		domain := model.RecurrenceException{Kind: model.ExceptionKind(ex.Kind), At: ex.At.In(loc)}
+		if ex.To != nil {
+			domain.To = ex.To.In(loc)
+		}
+		out.Exceptions = append(out.Exceptions, domain)
+	}
+	return out, nil
+}
+
+func exceptionsFromModel(in []model.RecurrenceException) []RecurrenceException {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]RecurrenceException, 0, len(in))
+	for _, ex := range in {
+		out = append(out, RecurrenceException{Kind: string(ex.Kind), At: ex.At.UTC(), To: utcOrNil(ex.To)})
+	}
+	return out
+}
+
+// utcOrNil is the stored form of an optional domain time, where the zero
+// time means unset.
+func utcOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	v := t.UTC()
+	return &v
+}
+
+// zoneName is the IANA name stored for loc. time.Local calls itself "Local",
+// which another machine would read as its own zone, so it is named the way
+// Go chose it: from $TZ, else the /etc/localtime link.
+func zoneName(loc *time.Location) string {
+	name := loc.String()
+	if name != "Local" {
+		return name
+	}
+	candidates := make([]string, 0, 2)
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		if tz == "" {
+			return "UTC"
+		}
+		candidates = append(candidates, strings.TrimPrefix(tz, ":"))
+	} else if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, zone, ok := strings.Cut(target, "zoneinfo/"); ok {
+			candidates = append(candidates, zone)
+		}
+	}
+	for _, c := range candidates {
+		if _, err := time.LoadLocation(c); err == nil && c != "Local" {
+			return c
+		}
+	}
+	return name
+}
+
+// FormatWeekdays spells days as the comma-separated list stored in the
+// weekdays column, for example "mon,wed,fri".
+func FormatWeekdays(days []time.Weekday) string {
+	names := make([]string, 0, len(days))
+	for _, d := range days {
+		if d < time.Sunday || d > time.Saturday {
+			names = append(names, strconv.Itoa(int(d)))
+			continue
+		}
+		names = append(names, weekdayNames[d])
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseWeekdays reads a list written by FormatWeekdays. Full day names are
+// accepted too; the empty string is no days.
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]time.Weekday, 0, len(parts))
+	for _, part := range parts {
+		name := strings.ToLower(strings.TrimSpace(part))
+		day := -1
+		for d, short := range weekdayNames {
+			if name == short || name == strings.ToLower(time.Weekday(d).String()) {
+				day = d
+			}
+		}
+		if day < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidWeekday, part)
+		}
+		out = append(out, time.Weekday(day))
+	}
+	return out, nil
+}
+
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func validateTask(in Task) error {
+	if err := TaskToModel(in).Validate(); err != nil {
+		return &ValidationError{Entity: "task", ID: in.ID, Err: err}
+	}
+	return nil
+}
+
+func validateRecurrence(in RecurrenceRule) error {
+	rule, err := RecurrenceToModel(in)
+	if err == nil {
+		err = rule.Validate()
+	}
+	if err != nil {
+		return &ValidationError{Entity: "recurrence", ID: in.ID, Err: err}
+	}
+	return nil
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+func copyWeekdays(in []time.Weekday) []time.Weekday {
+	if in == nil {
+		return nil
+	}
+	return append(make([]time.Weekday, 0, len(in)), in...)
+}
+
+func copyStrings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(in)), in...)
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+)
+
+func TestTaskModelRoundTrip(t *testing.T) {
+	created := time.Date(2026, 2, 9, 8, 0, 0, 0, time.UTC)
+	scheduled := created.Add(2 * time.Hour)
+	due := created.Add(48 * time.Hour)
+	completed := created.Add(3 * time.Hour)
+
+	cases := []model.Task{
+		{
+			ID: "task-1", Title: "Plan sprint", Description: "draft goals",
+			State: model.TaskStatePlanned, Priority: model.PriorityHigh, Energy: model.EnergyDeep,
+			Tags: []string{"work", "planning"}, ScheduledAt: &scheduled, DueAt: &due, CreatedAt: created,
+		},
+		{
+			ID: "task-2", Title: "Ship release",
+			State: model.TaskStateDone, Priority: model.PriorityCritical, Energy: model.EnergyLow,
+			Tags: []string{}, CreatedAt: created, CompletedAt: &completed,
+		},
+		{
+			ID: "task-3", Title: "Capture idea",
+			State: model.TaskStateInbox, Priority: model.PriorityLow, Energy: model.EnergyLight,
+			CreatedAt: created,
+		},
+	}
+	for _, want := range cases {
+		row := TaskFromModel(want)
+		got := TaskToModel(row)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("round trip mismatch:\n got %#v\nwant %#v", got, want)
+		}
+		if want.ScheduledAt != nil && row.ScheduledAt == want.ScheduledAt {
+			t.Fatal("expected ScheduledAt to be copied, not aliased")
+		}
+		if !reflect.DeepEqual(TaskFromModel(got), row) {
+			t.Fatalf("storage round trip mismatch for %s", want.ID)
+		}
+	}
+}
+
+func TestRecurrenceModelRoundTrip(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load zone: %v", err)
+	}
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatalf("load zone: %v", err)
+	}
+	created := time.Date(2026, 2, 9, 8, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		rule model.RecurrenceRule
+	}{
+		{"weekdays default", model.RecurrenceRule{Type: model.RecurrenceEveryWeekday, Interval: 1, Anchor: time.Date(2026, 2, 9, 9, 0, 0, 0, newYork)}},
+		{"weekdays chosen", model.RecurrenceRule{Type: model.RecurrenceEveryWeekday, Interval: 1, Anchor: time.Date(2026, 2, 9, 7, 30, 0, 0, kolkata),
+			Weekdays: []time.Weekday{time.Saturday, time.Monday, time.Wednesday}}},
+		{"every n days", model.RecurrenceRule{Type: model.RecurrenceEveryNDays, Interval: 3, Anchor: time.Date(2026, 2, 9, 9, 0, 0, 123456789, time.UTC)}},
+		{"every n weeks", model.RecurrenceRule{Type: model.RecurrenceEveryNWeeks, Interval: 2, Anchor: time.Date(2026, 3, 8, 1, 30, 0, 0, newYork)}},
+		{"last day of month", model.RecurrenceRule{Type: model.RecurrenceLastDayOfMonth, Interval: 1, Anchor: time.Date(2026, 1, 31, 18, 0, 0, 0, kolkata)}},
+		{"after completion by interval", model.RecurrenceRule{Type: model.RecurrenceAfterComplete, Interval: 2, Anchor: created}},
+		{"after completion by duration", model.RecurrenceRule{Type: model.RecurrenceAfterComplete, Interval: 1, Anchor: created.In(newYork),
+			AfterCompleteIn: 36*time.Hour + 15*time.Minute + time.Nanosecond}},
+		{"rrule", model.RecurrenceRule{Type: model.RecurrenceRRule, Interval: 1, Anchor: time.Date(2026, 2, 10, 9, 0, 0, 0, newYork),
+			RRule: "RRULE:FREQ=MONTHLY;BYDAY=2TU;COUNT=6\nEXDATE;TZID=America/New_York:20260310T090000"}},
+		{"until with exceptions", model.RecurrenceRule{Type: model.RecurrenceEveryNDays, Interval: 1, Anchor: time.Date(2026, 2, 9, 9, 0, 0, 0, newYork),
+			Until: time.Date(2026, 3, 31, 9, 0, 0, 0, newYork),
+			Exceptions: []model.RecurrenceException{
+				{Kind: model.ExceptionSkip, At: time.Date(2026, 2, 10, 9, 0, 0, 0, newYork)},
+				{Kind: model.ExceptionMove, At: time.Date(2026, 2, 11, 9, 0, 0, 0, newYork), To: time.Date(2026, 2, 11, 14, 0, 0, 0, newYork)},
+				{Kind: model.ExceptionPause, At: time.Date(2026, 2, 13, 0, 0, 0, 0, newYork), To: time.Date(2026, 2, 20, 0, 0, 0, 0, newYork)},
+			}}},
+		{"count", model.RecurrenceRule{Type: model.RecurrenceEveryWeekday, Interval: 1, Anchor: time.Date(2026, 2, 9, 7, 30, 0, 0, kolkata), Count: 5}},
+	}
+
+	repo := setupRepo(t)
+	ctx := t.Context()
+	if err := repo.CreateTask(ctx, Task{ID: "task-rt", Title: "Recurring", State: "Planned", Priority: "Low", Energy: "Low", CreatedAt: created}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			row := RecurrenceFromModel(fmt.Sprintf("rec-%d", i), "task-rt", tc.rule, created)
+			if err := repo.CreateRecurrence(ctx, row); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			stored, err := repo.GetRecurrence(ctx, row.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if !reflect.DeepEqual(stored, row) {
+				t.Fatalf("stored row mismatch:\n got %#v\nwant %#v", stored, row)
+			}
+			got, err := RecurrenceToModel(stored)
+			if err != nil {
+				t.Fatalf("to model: %v", err)
+			}
+			want := tc.rule
+			if got.Type != want.Type || got.Interval != want.Interval || got.AfterCompleteIn != want.AfterCompleteIn ||
+				got.RRule != want.RRule || !reflect.DeepEqual(got.Weekdays, want.Weekdays) ||
+				!got.Until.Equal(want.Until) || got.Count != want.Count || !reflect.DeepEqual(got.Exceptions, want.Exceptions) {
+				t.Fatalf("round trip mismatch:\n got %#v\nwant %#v", got, want)
+			}
+			if !got.Anchor.Equal(want.Anchor) || got.Anchor.Location().String() != want.Anchor.Location().String() ||
+				got.Anchor.Format(time.RFC3339Nano) != want.Anchor.Format(time.RFC3339Nano) {
+				t.Fatalf("anchor %s, want %s", got.Anchor.Format(time.RFC3339Nano), want.Anchor.Format(time.RFC3339Nano))
+			}
+			done := created.Add(time.Hour)
+			gotNext, err := got.Preview(created, &done, 4)
+			if err != nil {
+				t.Fatalf("preview: %v", err)
+			}
+			wantNext, _ := want.Preview(created, &done, 4)
+			if !reflect.DeepEqual(gotNext, wantNext) {
+				t.Fatalf("preview %v, want %v", gotNext, wantNext)
+			}
+		})
+	}
+}
+
+func TestRecurrenceFromModelNamesLocalZone(t *testing.T) {
+	t.Setenv("TZ", "Asia/Kolkata")
+	rule := model.RecurrenceRule{Type: model.RecurrenceEveryNDays, Interval: 1, Anchor: time.Date(2026, 2, 9, 9, 0, 0, 0, time.Local)}
+	if got := RecurrenceFromModel("rec-l", "task-l", rule, rule.Anchor).Timezone; got != "Asia/Kolkata" {
+		t.Fatalf("expected the local zone stored by name, got %q", got)
+	}
+}
+
+func TestRecurrenceRowsAreValidated(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := t.Context()
+	created := time.Date(2026, 2, 9, 8, 0, 0, 0, time.UTC)
+	if err := repo.CreateTask(ctx, Task{ID: "task-v", Title: "Recurring", State: "Planned", Priority: "Low", Energy: "Low", CreatedAt: created}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	valid := RecurrenceRule{ID: "rec-v", TaskID: "task-v", RuleType: "every_weekday", IntervalValue: 1, Timezone: "UTC", StartAt: created, Enabled: true, CreatedAt: created}
+
+	cases := []struct {
+		name   string
+		mutate func(*RecurrenceRule)
+		want   error
+	}{
+		{"legacy weekday spelling", func(r *RecurrenceRule) { r.RuleType = "weekday" }, model.ErrInvalidRecurrenceType},
+		{"zero interval", func(r *RecurrenceRule) { r.IntervalValue = 0 }, model.ErrInvalidInterval},
+		{"unknown timezone", func(r *RecurrenceRule) { r.Timezone = "Mars/Olympus" }, ErrUnknownTimezone},
+		{"rrule without a rule", func(r *RecurrenceRule) { r.RuleType = "rrule" }, model.ErrInvalidRRule},
+		{"malformed rrule", func(r *RecurrenceRule) { r.RuleType, r.RRule = "rrule", "FREQ=FORTNIGHTLY" }, model.ErrInvalidRRule},
+		{"rrule on a built-in type", func(r *RecurrenceRule) { r.RRule = "FREQ=DAILY" }, model.ErrInvalidRRule},
+		{"until and count", func(r *RecurrenceRule) { r.Until, r.Count = &created, 3 }, model.ErrInvalidRecurrenceEnd},
+		{"unknown exception", func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: "shift", At: created}}
+		}, model.ErrInvalidException},
+		{"move without a target", func(r *RecurrenceRule) {
+			r.Exceptions = []RecurrenceException{{Kind: "move", At: created}}
+		}, model.ErrInvalidException},
+	}
+	for _, tc := range cases {
+		rule := valid
+		tc.mutate(&rule)
+		err := repo.CreateRecurrence(ctx, rule)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected validation error wrapping %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	for _, in := range []string{"mon,wed,fri", "Monday, friday", "sun"} {
+		days, err := ParseWeekdays(in)
+		if err != nil || len(days) == 0 {
+			t.Fatalf("parse %q: %v, %v", in, days, err)
+		}
+	}
+	if got := FormatWeekdays([]time.Weekday{time.Monday, time.Friday}); got != "mon,fri" {
+		t.Fatalf("format = %q", got)
+	}
+	if _, err := ParseWeekdays("mon,funday"); !errors.Is(err, ErrInvalidWeekday) {
+		t.Fatalf("expected ErrInvalidWeekday, got %v", err)
+	}
+}
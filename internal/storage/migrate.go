@@ -1,38 +1,319 @@
 package storage
 
 import (
+	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
+	"path"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 //go:embed migrations/*.sql
 var migrationFiles embed.FS
 
+var (
+	ErrMigrationChecksum = errors.New("storage: applied migration checksum mismatch")
+	ErrUnknownMigration  = errors.New("storage: unknown migration version")
+)
+
+type migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type appliedMigration struct {
+	Version  int
+	Checksum string
+}
+
+// MigrateUp applies every pending migration.
 func MigrateUp(db *sql.DB) error {
-	return applyMigrations(db, ".up.sql")
+	return migrateTo(db, migrationFiles, -1)
 }
 
+// MigrateDown reverts the most recently applied migration.
 func MigrateDown(db *sql.DB) error {
-	return applyMigrations(db, ".down.sql")
+	current, err := MigrationVersion(db)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return err
+	}
+	target := 0
+	for _, m := range migrations {
+		if m.Version < current {
+			target = m.Version
+		}
+	}
+	return migrateTo(db, migrationFiles, target)
+}
+
+// MigrateTo moves the schema up or down to version. Version 0 reverts every
+// migration.
+func MigrateTo(db *sql.DB, version int) error {
+	if version < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownMigration, version)
+	}
+	return migrateTo(db, migrationFiles, version)
+}
+
+// MigrationVersion reports the highest applied migration version, or 0 when
+// none has been applied.
+func MigrationVersion(db *sql.DB) (int, error) {
+	if err := ensureMigrationLedger(db); err != nil {
+		return 0, err
+	}
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read migration version: %w", err)
+	}
+	return int(version.Int64), nil
 }
 
-func applyMigrations(db *sql.DB, suffix string) error {
-	entries, err := fs.Glob(migrationFiles, "migrations/*"+suffix)
+// migrateTo applies or reverts migrations from fsys until the ledger matches
+// target. A negative target means the latest available version.
+func migrateTo(db *sql.DB, fsys fs.FS, target int) error {
+	migrations, err := loadMigrations(fsys)
 	if err != nil {
-		return fmt.Errorf("glob migrations: %w", err)
+		return err
 	}
-	sort.Strings(entries)
-	for _, name := range entries {
-		sqlBytes, readErr := migrationFiles.ReadFile(name)
-		if readErr != nil {
-			return fmt.Errorf("read migration %s: %w", name, readErr)
+	if err := ensureMigrationLedger(db); err != nil {
+		return err
+	}
+	applied, err := loadAppliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	if err := adoptUnversionedSchema(db, migrations, applied); err != nil {
+		return err
+	}
+	if err := verifyAppliedMigrations(migrations, applied); err != nil {
+		return err
+	}
+
+	if target < 0 {
+		target = 0
+		if len(migrations) > 0 {
+			target = migrations[len(migrations)-1].Version
+		}
+	} else if target > 0 && !hasMigration(migrations, target) {
+		return fmt.Errorf("%w: %d", ErrUnknownMigration, target)
+	}
+
+	for _, m := range migrations {
+		if m.Version > target || applied[m.Version] != "" {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return err
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= target || applied[m.Version] == "" {
+			continue
+		}
+		if err := revertMigration(db, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ensureMigrationLedger(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("create migration ledger: %w", err)
+	}
+	return nil
+}
+
+// adoptUnversionedSchema records version 1 as applied for a database
+// created before schema_migrations existed: the baseline tables are there
+// but the ledger is empty. Running 0001 again would fail on them.
+func adoptUnversionedSchema(db *sql.DB, migrations []migration, applied map[int]string) error {
+	if len(applied) > 0 || len(migrations) == 0 || migrations[0].Version != 1 {
+		return nil
+	}
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks'`).Scan(&tables); err != nil {
+		return fmt.Errorf("detect unversioned schema: %w", err)
+	}
+	if tables == 0 {
+		return nil
+	}
+	m := migrations[0]
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`,
+		m.Version, m.Name, m.Checksum, mustTime(time.Now())); err != nil {
+		return fmt.Errorf("record unversioned schema as %04d_%s: %w", m.Version, m.Name, err)
+	}
+	applied[m.Version] = m.Checksum
+	return nil
+}
+
+func loadAppliedMigrations(db *sql.DB) (map[int]string, error) {
+	rows, err := db.Query(`SELECT version, checksum FROM schema_migrations ORDER BY version ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("read migration ledger: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[int]string)
+	for rows.Next() {
+		var item appliedMigration
+		if err := rows.Scan(&item.Version, &item.Checksum); err != nil {
+			return nil, fmt.Errorf("scan migration ledger: %w", err)
+		}
+		out[item.Version] = item.Checksum
+	}
+	return out, rows.Err()
+}
+
+func verifyAppliedMigrations(migrations []migration, applied map[int]string) error {
+	known := make(map[int]migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	for _, version := range versions {
+		m, ok := known[version]
+		if !ok {
+			return fmt.Errorf("%w: %d is applied but not embedded", ErrUnknownMigration, version)
 		}
-		if _, execErr := db.Exec(string(sqlBytes)); execErr != nil {
-			return fmt.Errorf("apply migration %s: %w", name, execErr)
+		if m.Checksum != applied[version] {
+			return fmt.Errorf("%w: %04d_%s", ErrMigrationChecksum, m.Version, m.Name)
 		}
 	}
 	return nil
 }
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin migration %04d: %w", m.Version, err)
+	}
+	if _, err := tx.Exec(m.Up); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("apply migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`,
+		m.Version, m.Name, m.Checksum, mustTime(time.Now())); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("record migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	return tx.Commit()
+}
+
+func revertMigration(db *sql.DB, m migration) error {
+	if strings.TrimSpace(m.Down) == "" {
+		return fmt.Errorf("revert migration %04d_%s: no down migration", m.Version, m.Name)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin revert %04d: %w", m.Version, err)
+	}
+	if _, err := tx.Exec(m.Down); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("revert migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.Version); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("unrecord migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	return tx.Commit()
+}
+
+// loadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys,
+// sorted by version.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("glob migrations: %w", err)
+	}
+	byVersion := make(map[int]*migration)
+	for _, name := range entries {
+		base := path.Base(name)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		stem := strings.TrimSuffix(strings.TrimSuffix(base, ".sql"), "."+direction)
+		prefix, label, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name", base)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", base, prefix)
+		}
+		sqlBytes, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", name, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if m.Name != label {
+			return nil, fmt.Errorf("migration %04d: mismatched names %q and %q", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(sqlBytes)
+			sum := sha256.Sum256(sqlBytes)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(sqlBytes)
+		}
+	}
+
+	out := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s: missing up file", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func hasMigration(migrations []migration, version int) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
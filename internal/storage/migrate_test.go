@@ -2,8 +2,10 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -53,3 +55,214 @@ func TestMigrateRoundTripCompatibility(t *testing.T) {
 		t.Fatalf("unexpected title after roundtrip: %q", got.Title)
 	}
 }
+
+func openMigrateDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count); err != nil {
+		t.Fatalf("lookup table %s: %v", name, err)
+	}
+	return count == 1
+}
+
+func TestMigrateUpIsIdempotentAndRecordsVersion(t *testing.T) {
+	db := openMigrateDB(t)
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("first migrate up: %v", err)
+	}
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("second migrate up: %v", err)
+	}
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	version, err := MigrationVersion(db)
+	if err != nil {
+		t.Fatalf("migration version: %v", err)
+	}
+	if want := migrations[len(migrations)-1].Version; version != want {
+		t.Fatalf("version = %d, want %d", version, want)
+	}
+}
+
+func TestMigrateUpAdoptsDatabaseWithoutLedger(t *testing.T) {
+	db := openMigrateDB(t)
+	baseline, err := migrationFiles.ReadFile("migrations/0001_init.up.sql")
+	if err != nil {
+		t.Fatalf("read baseline: %v", err)
+	}
+	if _, err := db.Exec(string(baseline)); err != nil {
+		t.Fatalf("create pre-ledger schema: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO tasks (id, title, state, priority, energy, created_at) VALUES ('t1', 'kept', 'Inbox', 'Low', 'Low', '2026-02-09T08:00:00Z')`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	var name string
+	if err := db.QueryRow(`SELECT name FROM schema_migrations WHERE version = 1`).Scan(&name); err != nil || name != "init" {
+		t.Fatalf("expected the baseline stamped as version 1, got %q, %v", name, err)
+	}
+	var title string
+	if err := db.QueryRow(`SELECT title FROM tasks WHERE id = 't1'`).Scan(&title); err != nil || title != "kept" {
+		t.Fatalf("expected existing rows kept, got %q, %v", title, err)
+	}
+	if !tableExists(t, db, "task_events") {
+		t.Fatal("expected later migrations applied")
+	}
+}
+
+func TestMigrateAppliesOnlyPendingVersions(t *testing.T) {
+	db := openMigrateDB(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"migrations/0001_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+	if err := migrateTo(db, fsys, -1); err != nil {
+		t.Fatalf("migrate v1: %v", err)
+	}
+
+	fsys["migrations/0002_b.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE b (id INTEGER);")}
+	fsys["migrations/0002_b.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE b;")}
+	if err := migrateTo(db, fsys, -1); err != nil {
+		t.Fatalf("migrate v2 (would fail if 0001 re-ran): %v", err)
+	}
+	if !tableExists(t, db, "a") || !tableExists(t, db, "b") {
+		t.Fatal("expected tables a and b after migrating")
+	}
+
+	if err := migrateTo(db, fsys, 1); err != nil {
+		t.Fatalf("migrate to v1: %v", err)
+	}
+	if !tableExists(t, db, "a") || tableExists(t, db, "b") {
+		t.Fatal("expected only table a after migrating down to v1")
+	}
+	version, err := MigrationVersion(db)
+	if err != nil || version != 1 {
+		t.Fatalf("expected version 1, got %d (err=%v)", version, err)
+	}
+}
+
+func TestMigrateRefusesChecksumMismatch(t *testing.T) {
+	db := openMigrateDB(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_a.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER);")},
+	}
+	if err := migrateTo(db, fsys, -1); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	fsys["migrations/0001_a.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE a (id INTEGER, name TEXT);")}
+	err := migrateTo(db, fsys, -1)
+	if !errors.Is(err, ErrMigrationChecksum) {
+		t.Fatalf("expected ErrMigrationChecksum, got %v", err)
+	}
+}
+
+func TestMigrateFailureRollsBackMigration(t *testing.T) {
+	db := openMigrateDB(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_a.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"migrations/0002_b.up.sql": {Data: []byte("CREATE TABLE b (id INTEGER); INSERT INTO missing VALUES (1);")},
+	}
+	if err := migrateTo(db, fsys, -1); err == nil {
+		t.Fatal("expected failing migration error")
+	}
+	if tableExists(t, db, "b") {
+		t.Fatal("expected partial migration 0002 to be rolled back")
+	}
+	version, err := MigrationVersion(db)
+	if err != nil || version != 1 {
+		t.Fatalf("expected version 1 after failure, got %d (err=%v)", version, err)
+	}
+}
+
+func TestMigrateToUnknownVersion(t *testing.T) {
+	db := openMigrateDB(t)
+	if err := MigrateTo(db, 9999); !errors.Is(err, ErrUnknownMigration) {
+		t.Fatalf("expected ErrUnknownMigration, got %v", err)
+	}
+}
+
+func TestMigrateRenamesLegacyWeekdayRules(t *testing.T) {
+	db := openMigrateDB(t)
+	if err := MigrateTo(db, 5); err != nil {
+		t.Fatalf("migrate to 5: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO tasks (id, title, state, priority, energy, created_at) VALUES ('t1', 'standup', 'Planned', 'Low', 'Low', '2026-02-09T08:00:00Z')`,
+		`INSERT INTO recurrence_rules (id, task_id, rule_type, interval_value, timezone, start_at, created_at)
+			VALUES ('r1', 't1', 'weekday', 1, 'Europe/Berlin', '2026-02-09T08:00:00Z', '2026-02-09T08:00:00Z')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo, err := NewSQLiteRepository(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	rule, err := repo.GetRecurrence(t.Context(), "r1")
+	if err != nil || rule.RuleType != "every_weekday" || rule.Timezone != "Europe/Berlin" || rule.Weekdays != nil || rule.AfterCompleteIn != 0 {
+		t.Fatalf("unexpected migrated rule %+v, %v", rule, err)
+	}
+
+	if err := MigrateTo(db, 5); err != nil {
+		t.Fatalf("migrate down to 5: %v", err)
+	}
+	var ruleType string
+	if err := db.QueryRow(`SELECT rule_type FROM recurrence_rules WHERE id = 'r1'`).Scan(&ruleType); err != nil || ruleType != "weekday" {
+		t.Fatalf("expected the legacy spelling back, got %q, %v", ruleType, err)
+	}
+}
+
+func TestMigrateDownDropsRRuleRules(t *testing.T) {
+	db := openMigrateDB(t)
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO tasks (id, title, state, priority, energy, created_at) VALUES ('t1', 'review', 'Planned', 'Low', 'Low', '2026-02-09T08:00:00Z')`,
+		`INSERT INTO recurrence_rules (id, task_id, rule_type, interval_value, timezone, start_at, created_at)
+			VALUES ('r1', 't1', 'every_n_days', 2, 'UTC', '2026-02-09T08:00:00Z', '2026-02-09T08:00:00Z')`,
+		`INSERT INTO recurrence_rules (id, task_id, rule_type, interval_value, timezone, start_at, created_at, rrule)
+			VALUES ('r2', 't1', 'rrule', 1, 'UTC', '2026-02-09T08:00:00Z', '2026-02-09T08:00:00Z', 'FREQ=MONTHLY;BYDAY=2TU')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	if err := MigrateTo(db, 6); err != nil {
+		t.Fatalf("migrate down to 6: %v", err)
+	}
+	var ids []string
+	rows, err := db.Query(`SELECT id FROM recurrence_rules ORDER BY id`)
+	if err != nil {
+		t.Fatalf("list rules: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 1 || ids[0] != "r1" {
+		t.Fatalf("expected only the built-in rule to survive, got %v", ids)
+	}
+}
@@ -0,0 +1,26 @@
+-- Full-text index over task titles and descriptions. The default go-sqlite3
+-- build ships FTS4 but not FTS5, so the index uses fts4 with the same MATCH
+-- syntax; ranking is computed from matchinfo() by the repository.
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts4(
+    task_id,
+    title,
+    description,
+    notindexed=task_id,
+    tokenize=unicode61
+);
+
+INSERT INTO tasks_fts (task_id, title, description)
+SELECT id, title, description FROM tasks;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_fts (task_id, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF id, title, description ON tasks BEGIN
+    DELETE FROM tasks_fts WHERE task_id = old.id;
+    INSERT INTO tasks_fts (task_id, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM tasks_fts WHERE task_id = old.id;
+END;
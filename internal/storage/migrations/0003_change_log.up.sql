@@ -0,0 +1,11 @@
+-- Every repository write appends a row so other processes sharing the
+-- database can notice and reload. origin identifies the writing repository
+-- instance, letting a reader skip its own writes.
+CREATE TABLE IF NOT EXISTS change_log (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL CHECK (entity IN ('task', 'reminder', 'tag', 'recurrence')),
+    entity_id TEXT NOT NULL,
+    op TEXT NOT NULL CHECK (op IN ('create', 'update', 'delete')),
+    origin TEXT NOT NULL,
+    changed_at TEXT NOT NULL
+);
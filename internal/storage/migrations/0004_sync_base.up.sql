@@ -0,0 +1,9 @@
+-- The last state each record was synced at, per sync target. It is the
+-- common ancestor for three-way merges; doc is the record as JSON.
+CREATE TABLE IF NOT EXISTS sync_base (
+    target TEXT NOT NULL,
+    entity TEXT NOT NULL CHECK (entity IN ('task', 'reminder', 'tag', 'recurrence')),
+    entity_id TEXT NOT NULL,
+    doc TEXT NOT NULL,
+    PRIMARY KEY (target, entity, entity_id)
+);
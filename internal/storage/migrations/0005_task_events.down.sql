@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS task_events_no_delete;
+DROP TRIGGER IF EXISTS task_events_no_update;
+DROP INDEX IF EXISTS idx_task_events_occurred;
+DROP INDEX IF EXISTS idx_task_events_task;
+DROP TABLE IF EXISTS task_events;
@@ -0,0 +1,24 @@
+-- Append-only history of task changes, one row per changed field. task_id
+-- has no foreign key so a task's history outlives the task.
+CREATE TABLE IF NOT EXISTS task_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id TEXT NOT NULL,
+    field TEXT NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL CHECK (source IN ('tui', 'cli', 'api', 'sync', 'system')),
+    occurred_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task ON task_events (task_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_task_events_occurred ON task_events (occurred_at);
+
+CREATE TRIGGER IF NOT EXISTS task_events_no_update BEFORE UPDATE ON task_events
+BEGIN
+    SELECT RAISE(ABORT, 'task_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_events_no_delete BEFORE DELETE ON task_events
+BEGIN
+    SELECT RAISE(ABORT, 'task_events is append-only');
+END;
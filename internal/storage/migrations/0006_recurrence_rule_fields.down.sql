@@ -0,0 +1,27 @@
+-- Restores the 0001 table. Weekday lists and after-completion delays are lost.
+CREATE TABLE recurrence_rules_old (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL,
+    rule_type TEXT NOT NULL CHECK (rule_type IN (
+        'weekday',
+        'every_n_days',
+        'every_n_weeks',
+        'last_day_of_month',
+        'after_completion'
+    )),
+    interval_value INTEGER NOT NULL DEFAULT 1,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    start_at TEXT NOT NULL,
+    next_occurrence_at TEXT,
+    enabled INTEGER NOT NULL DEFAULT 1 CHECK (enabled IN (0, 1)),
+    created_at TEXT NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE
+);
+
+INSERT INTO recurrence_rules_old (id, task_id, rule_type, interval_value, timezone, start_at, next_occurrence_at, enabled, created_at)
+SELECT id, task_id, CASE rule_type WHEN 'every_weekday' THEN 'weekday' ELSE rule_type END,
+       interval_value, timezone, start_at, next_occurrence_at, enabled, created_at
+FROM recurrence_rules;
+
+DROP TABLE recurrence_rules;
+ALTER TABLE recurrence_rules_old RENAME TO recurrence_rules;
@@ -0,0 +1,34 @@
+-- SQLite cannot alter a CHECK constraint, so the table is rebuilt. The
+-- weekday rule type takes the domain spelling 'every_weekday', and the rule's
+-- weekdays and after-completion delay get their own columns.
+CREATE TABLE recurrence_rules_new (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL,
+    rule_type TEXT NOT NULL CHECK (rule_type IN (
+        'every_weekday',
+        'every_n_days',
+        'every_n_weeks',
+        'last_day_of_month',
+        'after_completion'
+    )),
+    interval_value INTEGER NOT NULL DEFAULT 1,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    start_at TEXT NOT NULL,
+    next_occurrence_at TEXT,
+    enabled INTEGER NOT NULL DEFAULT 1 CHECK (enabled IN (0, 1)),
+    created_at TEXT NOT NULL,
+    -- weekdays is a comma-separated list of mon..sun; empty means Monday to Friday.
+    weekdays TEXT NOT NULL DEFAULT '',
+    -- after_complete_ns is the after_completion delay in nanoseconds; 0 means
+    -- interval_value days.
+    after_complete_ns INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE
+);
+
+INSERT INTO recurrence_rules_new (id, task_id, rule_type, interval_value, timezone, start_at, next_occurrence_at, enabled, created_at)
+SELECT id, task_id, CASE rule_type WHEN 'weekday' THEN 'every_weekday' ELSE rule_type END,
+       interval_value, timezone, start_at, next_occurrence_at, enabled, created_at
+FROM recurrence_rules;
+
+DROP TABLE recurrence_rules;
+ALTER TABLE recurrence_rules_new RENAME TO recurrence_rules;
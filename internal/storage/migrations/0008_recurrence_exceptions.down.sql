@@ -0,0 +1,4 @@
+-- Series end conditions and exceptions are lost.
+DROP TABLE IF EXISTS recurrence_exceptions;
+ALTER TABLE recurrence_rules DROP COLUMN max_count;
+ALTER TABLE recurrence_rules DROP COLUMN until_at;
@@ -0,0 +1,17 @@
+-- End conditions for a series, and exceptions that skip, move or pause
+-- single occurrences without ending it.
+-- until_at ends the series at its last occurrence on or before it.
+ALTER TABLE recurrence_rules ADD COLUMN until_at TEXT;
+-- max_count ends the series after that many occurrences; 0 means never.
+ALTER TABLE recurrence_rules ADD COLUMN max_count INTEGER NOT NULL DEFAULT 0 CHECK (max_count >= 0);
+
+CREATE TABLE IF NOT EXISTS recurrence_exceptions (
+    rule_id TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('skip', 'move', 'pause')),
+    -- at is the skipped or moved occurrence, or the start of a pause.
+    at TEXT NOT NULL,
+    -- to_at is the moved occurrence's new time, or the end of a pause.
+    to_at TEXT,
+    PRIMARY KEY (rule_id, kind, at),
+    FOREIGN KEY (rule_id) REFERENCES recurrence_rules (id) ON DELETE CASCADE
+);
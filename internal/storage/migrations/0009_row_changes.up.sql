@@ -0,0 +1,16 @@
+-- When each row was last written, kept after change_log is trimmed and after
+-- the row is deleted. Sync stamps locally edited fields with it.
+CREATE TABLE IF NOT EXISTS row_changes (
+    entity TEXT NOT NULL CHECK (entity IN ('task', 'reminder', 'tag', 'recurrence')),
+    entity_id TEXT NOT NULL,
+    changed_at TEXT NOT NULL,
+    PRIMARY KEY (entity, entity_id)
+);
+
+INSERT OR REPLACE INTO row_changes (entity, entity_id, changed_at)
+SELECT c.entity, c.entity_id, c.changed_at
+FROM change_log c
+WHERE c.seq = (
+    SELECT MAX(l.seq) FROM change_log l
+    WHERE l.entity = c.entity AND l.entity_id = c.entity_id
+);
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+)
+
+// ErrNotCompleted is returned by NextOccurrence for a task that is not Done.
+var ErrNotCompleted = errors.New("storage: task is not completed")
+
+// CreateTaskWithRecurrence creates task and, when rule is not nil, the
+// recurrence rule that repeats it, in one transaction. A task without an ID
+// gets one from model.NewID, as does the rule.
+func CreateTaskWithRecurrence(ctx context.Context, repo Repository, task model.Task, rule *model.RecurrenceRule, now time.Time) (Task, error) {
+	if task.ID == "" {
+		task.ID = model.NewID("task")
+	}
+	created := TaskFromModel(task)
+	err := repo.WithTx(ctx, func(tx Repository) error {
+		if err := tx.CreateTask(ctx, created); err != nil {
+			return fmt.Errorf("create task: %w", err)
+		}
+		if rule != nil {
+			if err := tx.CreateRecurrence(ctx, RecurrenceFromModel(model.NewID("rec"), created.ID, *rule, now)); err != nil {
+				return fmt.Errorf("create recurrence: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	return created, nil
+}
+
+// CompleteTask marks the task Done at completedAt and, when it recurs,
+// creates its next occurrence in the same transaction. A task that is
+// already Done is returned unchanged. next is nil when nothing was created.
+func CompleteTask(ctx context.Context, repo Repository, id string, completedAt time.Time) (done Task, next *Task, err error) {
+	err = repo.WithTx(ctx, func(tx Repository) error {
+		var err error
+		done, err = tx.GetTask(ctx, id)
+		if err != nil {
+			return fmt.Errorf("load task %s: %w", id, err)
+		}
+		if done.State == string(model.TaskStateDone) {
+			return nil
+		}
+		at := completedAt.UTC()
+		done.State = string(model.TaskStateDone)
+		done.CompletedAt = &at
+		if err := tx.UpdateTask(ctx, done); err != nil {
+			return fmt.Errorf("update task %s: %w", id, err)
+		}
+		next, err = NextOccurrence(ctx, tx, done)
+		return err
+	})
+	if err != nil {
+		return Task{}, nil, err
+	}
+	return done, next, nil
+}
+
+// NextOccurrence creates the task following a completed recurring instance.
+// The new task keeps the title, description, tags, priority and energy, and
+// is scheduled at the earliest next occurrence of the task's enabled rules;
+// its due date and reminders keep their offsets from the scheduled time. The
+// rules move to the new task with next_occurrence_at advanced, so the
+// completed instance stays in history without them. A rule with no further
+// occurrence is disabled and stays behind. It returns nil when no enabled
+// rule continues, and should run in the transaction that completed the task.
+func NextOccurrence(ctx context.Context, tx Repository, task Task) (*Task, error) {
+	if task.State != string(model.TaskStateDone) || task.CompletedAt == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotCompleted, task.ID)
+	}
+	enabled := true
+	rules, err := tx.ListRecurrences(ctx, RecurrenceListFilter{TaskID: task.ID, Enabled: &enabled})
+	if err != nil {
+		return nil, fmt.Errorf("list recurrences for %s: %w", task.ID, err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	completedAt := task.CompletedAt.UTC()
+	prev := occurrenceTime(task, rules, completedAt)
+	// A late completion skips the occurrences missed in the meantime.
+	from := prev
+	if completedAt.After(from) {
+		from = completedAt
+	}
+	nextAt := make([]time.Time, len(rules))
+	var earliest time.Time
+	for i, rule := range rules {
+		domain, err := RecurrenceToModel(rule)
+		if err != nil {
+			return nil, &ValidationError{Entity: "recurrence", ID: rule.ID, Err: err}
+		}
+		at, err := domain.NextAfter(from, &completedAt)
+		if errors.Is(err, model.ErrRecurrenceEnded) {
+			// A finished series stays with its last instance, switched off.
+			rule.Enabled, rule.NextAt = false, nil
+			if err := tx.UpdateRecurrence(ctx, rule); err != nil {
+				return nil, fmt.Errorf("end recurrence %s: %w", rule.ID, err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, &ValidationError{Entity: "recurrence", ID: rule.ID, Err: err}
+		}
+		nextAt[i] = at.UTC()
+		if earliest.IsZero() || nextAt[i].Before(earliest) {
+			earliest = nextAt[i]
+		}
+	}
+	if earliest.IsZero() {
+		return nil, nil
+	}
+
+	shift := earliest.Sub(prev)
+	next := Task{
+		ID:          model.NewID("task"),
+		Title:       task.Title,
+		Description: task.Description,
+		State:       string(model.TaskStatePlanned),
+		Priority:    task.Priority,
+		Energy:      task.Energy,
+		ScheduledAt: shiftTime(task.ScheduledAt, shift),
+		DueAt:       shiftTime(task.DueAt, shift),
+		CreatedAt:   completedAt,
+		Tags:        copyStrings(task.Tags),
+	}
+	if next.ScheduledAt == nil && next.DueAt == nil {
+		next.ScheduledAt = &earliest
+	}
+	if err := tx.CreateTask(ctx, next); err != nil {
+		return nil, fmt.Errorf("create next occurrence of %s: %w", task.ID, err)
+	}
+
+	reminders, err := tx.ListReminders(ctx, ReminderListFilter{TaskID: task.ID, Enabled: &enabled})
+	if err != nil {
+		return nil, fmt.Errorf("list reminders for %s: %w", task.ID, err)
+	}
+	for _, rem := range reminders {
+		carried := Reminder{
+			ID:         model.NewID("rem"),
+			TaskID:     next.ID,
+			TriggerAt:  rem.TriggerAt.Add(shift).UTC(),
+			Type:       rem.Type,
+			RepeatRule: rem.RepeatRule,
+			Enabled:    true,
+			CreatedAt:  completedAt,
+		}
+		if err := tx.CreateReminder(ctx, carried); err != nil {
+			return nil, fmt.Errorf("carry reminder %s: %w", rem.ID, err)
+		}
+		rem.Enabled = false
+		if err := tx.UpdateReminder(ctx, rem); err != nil {
+			return nil, fmt.Errorf("disable reminder %s: %w", rem.ID, err)
+		}
+	}
+
+	for i, rule := range rules {
+		if nextAt[i].IsZero() {
+			continue
+		}
+		rule.TaskID = next.ID
+		rule.NextAt = &nextAt[i]
+		if err := tx.UpdateRecurrence(ctx, rule); err != nil {
+			return nil, fmt.Errorf("advance recurrence %s: %w", rule.ID, err)
+		}
+	}
+	return &next, nil
+}
+
+// occurrenceTime is when the completed instance was meant to happen: its
+// scheduled time, else its due time, else the rules' recorded next
+// occurrence, else the completion itself.
+func occurrenceTime(task Task, rules []RecurrenceRule, completedAt time.Time) time.Time {
+	switch {
+	case task.ScheduledAt != nil:
+		return task.ScheduledAt.UTC()
+	case task.DueAt != nil:
+		return task.DueAt.UTC()
+	}
+	var at time.Time
+	for _, rule := range rules {
+		if rule.NextAt != nil && (at.IsZero() || rule.NextAt.Before(at)) {
+			at = rule.NextAt.UTC()
+		}
+	}
+	if at.IsZero() {
+		return completedAt
+	}
+	return at
+}
+
+func shiftTime(t *time.Time, d time.Duration) *time.Time {
+	if t == nil {
+		return nil
+	}
+	out := t.Add(d).UTC()
+	return &out
+}
+
+// ReplanOccurrence moves the open task of a rule just changed from before to
+// after when the occurrence it is planned for is no longer on the schedule,
+// for example because it was skipped, moved or paused. The task goes to the
+// first occurrence at or after its planned time and its due date and
+// reminders keep their offsets. A task already moved off the old schedule by
+// hand is left alone. It returns the moved task, or nil, and should run in
+// the transaction that changed the rule.
+func ReplanOccurrence(ctx context.Context, tx Repository, before, after RecurrenceRule) (*Task, error) {
+	if !after.Enabled || after.RuleType == string(model.RecurrenceAfterComplete) {
+		return nil, nil
+	}
+	task, err := tx.GetTask(ctx, after.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("load task %s: %w", after.TaskID, err)
+	}
+	if task.State == string(model.TaskStateDone) || (task.ScheduledAt == nil && task.DueAt == nil) {
+		return nil, nil
+	}
+	planned := occurrenceTime(task, nil, time.Time{})
+
+	old, err := RecurrenceToModel(before)
+	if err != nil {
+		return nil, nil
+	}
+	if at, err := old.NextAfter(planned.Add(-time.Nanosecond), nil); err != nil || !at.Equal(planned) {
+		return nil, nil
+	}
+	// An occurrence that had been moved is looked up from its original time,
+	// so dropping the move puts it back.
+	from := planned
+	for _, ex := range old.Exceptions {
+		if ex.Kind == model.ExceptionMove && ex.To.Equal(planned) && ex.At.Before(from) {
+			from = ex.At.UTC()
+		}
+	}
+	domain, err := RecurrenceToModel(after)
+	if err != nil {
+		return nil, &ValidationError{Entity: "recurrence", ID: after.ID, Err: err}
+	}
+	next, err := domain.NextAfter(from.Add(-time.Nanosecond), nil)
+	if errors.Is(err, model.ErrRecurrenceEnded) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &ValidationError{Entity: "recurrence", ID: after.ID, Err: err}
+	}
+	next = next.UTC()
+	if next.Equal(planned) {
+		return nil, nil
+	}
+
+	shift := next.Sub(planned)
+	task.ScheduledAt = shiftTime(task.ScheduledAt, shift)
+	task.DueAt = shiftTime(task.DueAt, shift)
+	if err := tx.UpdateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("replan task %s: %w", task.ID, err)
+	}
+	enabled := true
+	reminders, err := tx.ListReminders(ctx, ReminderListFilter{TaskID: task.ID, Enabled: &enabled})
+	if err != nil {
+		return nil, fmt.Errorf("list reminders for %s: %w", task.ID, err)
+	}
+	for _, rem := range reminders {
+		rem.TriggerAt = rem.TriggerAt.Add(shift).UTC()
+		if err := tx.UpdateReminder(ctx, rem); err != nil {
+			return nil, fmt.Errorf("move reminder %s: %w", rem.ID, err)
+		}
+	}
+	after.NextAt = &next
+	if err := tx.UpdateRecurrence(ctx, after); err != nil {
+		return nil, fmt.Errorf("advance recurrence %s: %w", after.ID, err)
+	}
+	return &task, nil
+}
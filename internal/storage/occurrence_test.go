@@ -0,0 +1,375 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/model"
+)
+
+func TestCompleteTaskCreatesNextOccurrence(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	scheduled := parseRFC3339(t, "2026-03-02T09:00:00Z")
+	due := parseRFC3339(t, "2026-03-02T11:00:00Z")
+	task := Task{
+		ID: "t1", Title: "water plants", Description: "balcony first", State: "Planned",
+		Priority: "High", Energy: "Deep", ScheduledAt: &scheduled, DueAt: &due,
+		CreatedAt: scheduled.Add(-time.Hour), Tags: []string{"home", "garden"},
+	}
+	if err := repo.CreateTask(ctx, task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	rule := RecurrenceRule{ID: "r1", TaskID: "t1", RuleType: "every_n_days", IntervalValue: 1, Timezone: "UTC", StartAt: scheduled, Enabled: true, CreatedAt: scheduled}
+	if err := repo.CreateRecurrence(ctx, rule); err != nil {
+		t.Fatalf("create recurrence: %v", err)
+	}
+	rem := Reminder{ID: "m1", TaskID: "t1", TriggerAt: scheduled.Add(-15 * time.Minute), Type: "Hard", Enabled: true, CreatedAt: scheduled}
+	if err := repo.CreateReminder(ctx, rem); err != nil {
+		t.Fatalf("create reminder: %v", err)
+	}
+
+	completedAt := parseRFC3339(t, "2026-03-02T10:00:00Z")
+	done, next, err := CompleteTask(ctx, repo, "t1", completedAt)
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if done.State != "Done" || done.CompletedAt == nil || !done.CompletedAt.Equal(completedAt) {
+		t.Fatalf("expected t1 done at %s, got %+v", completedAt, done)
+	}
+	if next == nil {
+		t.Fatalf("expected a next occurrence")
+	}
+
+	got, err := repo.GetTask(ctx, next.ID)
+	if err != nil {
+		t.Fatalf("get next: %v", err)
+	}
+	wantAt := parseRFC3339(t, "2026-03-03T09:00:00Z")
+	if got.State != "Planned" || got.Title != "water plants" || got.Description != "balcony first" ||
+		got.Priority != "High" || got.Energy != "Deep" || len(got.Tags) != 2 || got.CompletedAt != nil {
+		t.Fatalf("expected the task fields carried over, got %+v", got)
+	}
+	if got.ScheduledAt == nil || !got.ScheduledAt.Equal(wantAt) || got.DueAt == nil || !got.DueAt.Equal(wantAt.Add(2*time.Hour)) {
+		t.Fatalf("expected next scheduled %s and due two hours later, got %v / %v", wantAt, got.ScheduledAt, got.DueAt)
+	}
+
+	movedRule, err := repo.GetRecurrence(ctx, "r1")
+	if err != nil {
+		t.Fatalf("get rule: %v", err)
+	}
+	if movedRule.TaskID != next.ID || movedRule.NextAt == nil || !movedRule.NextAt.Equal(wantAt) {
+		t.Fatalf("expected the rule on %s with next %s, got %+v", next.ID, wantAt, movedRule)
+	}
+
+	rems, err := repo.ListReminders(ctx, ReminderListFilter{TaskID: next.ID})
+	if err != nil || len(rems) != 1 || !rems[0].TriggerAt.Equal(wantAt.Add(-15*time.Minute)) || !rems[0].Enabled || rems[0].Type != "Hard" {
+		t.Fatalf("expected the reminder 15 minutes before the next occurrence, got %+v, %v", rems, err)
+	}
+	if old, _ := repo.GetReminder(ctx, "m1"); old.Enabled {
+		t.Fatalf("expected the completed instance's reminder disabled, got %+v", old)
+	}
+
+	if old, err := repo.GetTask(ctx, "t1"); err != nil || old.State != "Done" {
+		t.Fatalf("expected the completed instance kept, got %+v, %v", old, err)
+	}
+	events, err := repo.ListTaskEvents(ctx, TaskEventFilter{Field: EventRecurrence})
+	if err != nil || len(events) != 3 || events[1].TaskID != "t1" || events[1].NewValue != "" || events[2].TaskID != next.ID || events[2].OldValue != "" {
+		t.Fatalf("expected the rule to leave t1's history and join the next task's, got %+v, %v", events, err)
+	}
+
+	_, again, err := CompleteTask(ctx, repo, "t1", completedAt.Add(time.Hour))
+	if err != nil || again != nil {
+		t.Fatalf("expected completing twice to create nothing, got %+v, %v", again, err)
+	}
+	tasks, err := repo.ListTasks(ctx, TaskListFilter{})
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("expected two tasks, got %d, %v", len(tasks), err)
+	}
+}
+
+func TestNextOccurrenceSchedule(t *testing.T) {
+	scheduled := parseRFC3339(t, "2026-03-02T09:00:00Z")
+	// 09:00 in New York the day before clocks spring forward.
+	beforeDST := parseRFC3339(t, "2026-03-07T14:00:00Z")
+	backFromVacation := parseRFC3339(t, "2026-03-06T00:00:00Z")
+	endOfMarch := parseRFC3339(t, "2026-03-31T00:00:00Z")
+	cases := []struct {
+		name      string
+		rule      RecurrenceRule
+		task      Task
+		completed string
+		want      string
+		wantNone  bool
+		disabled  bool
+	}{
+		{
+			name:      "late completion skips missed occurrences",
+			rule:      RecurrenceRule{RuleType: "every_n_days", IntervalValue: 1},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-05T12:00:00Z",
+			want:      "2026-03-06T09:00:00Z",
+		},
+		{
+			name:      "early completion moves to the following occurrence",
+			rule:      RecurrenceRule{RuleType: "every_n_weeks", IntervalValue: 2},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-01T20:00:00Z",
+			want:      "2026-03-16T09:00:00Z",
+		},
+		{
+			name:      "after completion waits from the completion",
+			rule:      RecurrenceRule{RuleType: "after_completion", IntervalValue: 1, AfterCompleteIn: 36 * time.Hour},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-04T18:30:00Z",
+			want:      "2026-03-06T06:30:00Z",
+		},
+		{
+			name:      "due-only task keeps a due date",
+			rule:      RecurrenceRule{RuleType: "every_weekday", IntervalValue: 1},
+			task:      Task{DueAt: &scheduled},
+			completed: "2026-03-02T08:00:00Z",
+			want:      "2026-03-03T09:00:00Z",
+		},
+		{
+			name:      "rrule picks the second Tuesday",
+			rule:      RecurrenceRule{RuleType: "rrule", IntervalValue: 1, RRule: "FREQ=MONTHLY;BYDAY=2TU"},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-02T10:00:00Z",
+			want:      "2026-03-10T09:00:00Z",
+		},
+		{
+			name:      "local wall clock is kept across DST",
+			rule:      RecurrenceRule{RuleType: "every_n_days", IntervalValue: 1, Timezone: "America/New_York", StartAt: beforeDST},
+			task:      Task{ScheduledAt: &beforeDST},
+			completed: "2026-03-07T15:00:00Z",
+			want:      "2026-03-08T13:00:00Z",
+		},
+		{
+			name: "vacation pause is passed over",
+			rule: RecurrenceRule{RuleType: "every_n_days", IntervalValue: 1, Exceptions: []RecurrenceException{
+				{Kind: "pause", At: parseRFC3339(t, "2026-03-03T00:00:00Z"), To: &backFromVacation},
+			}},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-02T10:00:00Z",
+			want:      "2026-03-06T09:00:00Z",
+		},
+		{
+			name:      "until is the last occurrence",
+			rule:      RecurrenceRule{RuleType: "every_n_weeks", IntervalValue: 1, Until: &endOfMarch},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-30T10:00:00Z",
+			wantNone:  true,
+		},
+		{
+			name:      "count ends the series",
+			rule:      RecurrenceRule{RuleType: "every_n_days", IntervalValue: 1, Count: 1},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-02T10:00:00Z",
+			wantNone:  true,
+		},
+		{
+			name:      "disabled rule does not recur",
+			rule:      RecurrenceRule{RuleType: "every_n_days", IntervalValue: 1},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-02T10:00:00Z",
+			wantNone:  true,
+			disabled:  true,
+		},
+		{
+			name:      "finished rrule is switched off",
+			rule:      RecurrenceRule{RuleType: "rrule", IntervalValue: 1, RRule: "FREQ=DAILY;COUNT=1"},
+			task:      Task{ScheduledAt: &scheduled},
+			completed: "2026-03-02T10:00:00Z",
+			wantNone:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setupRepo(t)
+			ctx := context.Background()
+			task := tc.task
+			task.ID, task.Title, task.State, task.CreatedAt = "t1", "stand-up", "Planned", scheduled
+			task.Priority, task.Energy = "Medium", "Light"
+			if err := repo.CreateTask(ctx, task); err != nil {
+				t.Fatalf("create task: %v", err)
+			}
+			rule := tc.rule
+			rule.ID, rule.TaskID, rule.Enabled, rule.CreatedAt = "r1", "t1", !tc.disabled, scheduled
+			if rule.Timezone == "" {
+				rule.Timezone, rule.StartAt = "UTC", scheduled
+			}
+			if err := repo.CreateRecurrence(ctx, rule); err != nil {
+				t.Fatalf("create recurrence: %v", err)
+			}
+
+			_, next, err := CompleteTask(ctx, repo, "t1", parseRFC3339(t, tc.completed))
+			if err != nil {
+				t.Fatalf("complete: %v", err)
+			}
+			if tc.wantNone {
+				if next != nil {
+					t.Fatalf("expected no next occurrence, got %+v", next)
+				}
+				if left, err := repo.GetRecurrence(ctx, "r1"); err != nil || left.TaskID != "t1" || left.Enabled {
+					t.Fatalf("expected the rule left disabled on t1, got %+v, %v", left, err)
+				}
+				return
+			}
+			at := next.ScheduledAt
+			if tc.task.ScheduledAt == nil {
+				if at != nil {
+					t.Fatalf("expected the next occurrence to stay unscheduled, got %v", at)
+				}
+				at = next.DueAt
+			}
+			if at == nil || !at.Equal(parseRFC3339(t, tc.want)) {
+				t.Fatalf("expected next at %s, got %v", tc.want, at)
+			}
+		})
+	}
+}
+
+func TestCreateTaskWithRecurrence(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-03-02T09:00:00Z")
+	rule := &model.RecurrenceRule{Type: model.RecurrenceEveryNDays, Interval: 2, Anchor: now}
+	task, err := CreateTaskWithRecurrence(ctx, repo, model.Task{Title: "water plants", State: model.TaskStateInbox,
+		Priority: model.DefaultPriority, Energy: model.DefaultEnergy, CreatedAt: now}, rule, now)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(task.ID, "task-") {
+		t.Fatalf("expected a generated task ID, got %q", task.ID)
+	}
+	rules, err := repo.ListRecurrences(ctx, RecurrenceListFilter{TaskID: task.ID})
+	if err != nil || len(rules) != 1 || rules[0].IntervalValue != 2 {
+		t.Fatalf("expected the rule stored with the task, got %+v, %v", rules, err)
+	}
+
+	// A rule that fails validation leaves no task behind.
+	bad := &model.RecurrenceRule{Type: model.RecurrenceEveryNDays, Anchor: now}
+	if _, err := CreateTaskWithRecurrence(ctx, repo, model.Task{ID: "t-bad", Title: "broken", State: model.TaskStateInbox,
+		Priority: model.DefaultPriority, Energy: model.DefaultEnergy, CreatedAt: now}, bad, now); err == nil {
+		t.Fatal("expected an invalid rule to fail")
+	}
+	if _, err := repo.GetTask(ctx, "t-bad"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the task rolled back, got %v", err)
+	}
+}
+
+func TestNextOccurrenceRequiresCompletedTask(t *testing.T) {
+	repo := setupRepo(t)
+	_, err := NextOccurrence(context.Background(), repo, Task{ID: "t1", State: "Planned"})
+	if !errors.Is(err, ErrNotCompleted) {
+		t.Fatalf("expected ErrNotCompleted, got %v", err)
+	}
+}
+
+func TestReplanOccurrence(t *testing.T) {
+	scheduled := parseRFC3339(t, "2026-03-03T09:00:00Z")
+	cases := []struct {
+		name string
+		// edit changes the rule the task was planned under.
+		edit  func(*RecurrenceRule)
+		moved *Task
+		want  string
+	}{
+		{
+			name: "skipping the planned occurrence moves to the next",
+			edit: func(r *RecurrenceRule) { r.Exceptions = []RecurrenceException{{Kind: "skip", At: scheduled}} },
+			want: "2026-03-04T09:00:00Z",
+		},
+		{
+			name: "moving the planned occurrence follows it",
+			edit: func(r *RecurrenceRule) {
+				to := parseRFC3339(t, "2026-03-03T15:30:00Z")
+				r.Exceptions = []RecurrenceException{{Kind: "move", At: scheduled, To: &to}}
+			},
+			want: "2026-03-03T15:30:00Z",
+		},
+		{
+			name: "a pause moves to the first day back",
+			edit: func(r *RecurrenceRule) {
+				back := parseRFC3339(t, "2026-03-07T00:00:00Z")
+				r.Exceptions = []RecurrenceException{{Kind: "pause", At: parseRFC3339(t, "2026-03-03T00:00:00Z"), To: &back}}
+			},
+			want: "2026-03-07T09:00:00Z",
+		},
+		{
+			name: "another occurrence leaves the task alone",
+			edit: func(r *RecurrenceRule) {
+				r.Exceptions = []RecurrenceException{{Kind: "skip", At: scheduled.AddDate(0, 0, 1)}}
+			},
+			want: "2026-03-03T09:00:00Z",
+		},
+		{
+			name:  "a task moved by hand is left alone",
+			edit:  func(r *RecurrenceRule) { r.Exceptions = []RecurrenceException{{Kind: "skip", At: scheduled}} },
+			moved: &Task{ScheduledAt: ptrTime(parseRFC3339(t, "2026-03-03T11:00:00Z"))},
+			want:  "2026-03-03T11:00:00Z",
+		},
+		{
+			name: "an ended series leaves the task alone",
+			edit: func(r *RecurrenceRule) {
+				until := scheduled.Add(-time.Hour)
+				r.Until = &until
+			},
+			want: "2026-03-03T09:00:00Z",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setupRepo(t)
+			ctx := context.Background()
+			at := scheduled
+			if tc.moved != nil {
+				at = *tc.moved.ScheduledAt
+			}
+			if err := repo.CreateTask(ctx, Task{ID: "t1", Title: "stand-up", State: "Planned", Priority: "Medium", Energy: "Light", ScheduledAt: &at, CreatedAt: scheduled}); err != nil {
+				t.Fatalf("create task: %v", err)
+			}
+			if err := repo.CreateReminder(ctx, Reminder{ID: "m1", TaskID: "t1", TriggerAt: at.Add(-10 * time.Minute), Type: "Hard", Enabled: true, CreatedAt: scheduled}); err != nil {
+				t.Fatalf("create reminder: %v", err)
+			}
+			before := RecurrenceRule{ID: "r1", TaskID: "t1", RuleType: "every_n_days", IntervalValue: 1, Timezone: "UTC", StartAt: parseRFC3339(t, "2026-03-01T09:00:00Z"), Enabled: true, CreatedAt: scheduled}
+			if err := repo.CreateRecurrence(ctx, before); err != nil {
+				t.Fatalf("create recurrence: %v", err)
+			}
+			after := before
+			tc.edit(&after)
+
+			var moved *Task
+			err := repo.WithTx(ctx, func(tx Repository) error {
+				if err := tx.UpdateRecurrence(ctx, after); err != nil {
+					return err
+				}
+				var err error
+				moved, err = ReplanOccurrence(ctx, tx, before, after)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("replan: %v", err)
+			}
+			want := parseRFC3339(t, tc.want)
+			if (moved != nil) != !want.Equal(at) {
+				t.Fatalf("expected moved = %v, got %+v", !want.Equal(at), moved)
+			}
+			task, _ := repo.GetTask(ctx, "t1")
+			if task.ScheduledAt == nil || !task.ScheduledAt.Equal(want) {
+				t.Fatalf("expected the task at %s, got %v", want, task.ScheduledAt)
+			}
+			if rem, _ := repo.GetReminder(ctx, "m1"); !rem.TriggerAt.Equal(want.Add(-10 * time.Minute)) {
+				t.Fatalf("expected the reminder ten minutes before %s, got %s", want, rem.TriggerAt)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 var ErrNotFound = errors.New("storage: not found")
@@ -13,6 +14,7 @@ type Repository interface {
 	UpdateTask(ctx context.Context, in Task) error
 	DeleteTask(ctx context.Context, id string) error
 	ListTasks(ctx context.Context, filter TaskListFilter) ([]Task, error)
+	SearchTasks(ctx context.Context, query string) ([]TaskSearchResult, error)
 
 	CreateReminder(ctx context.Context, in Reminder) error
 	GetReminder(ctx context.Context, id string) (Reminder, error)
@@ -26,9 +28,36 @@ type Repository interface {
 	DeleteTag(ctx context.Context, id string) error
 	ListTags(ctx context.Context, filter TagListFilter) ([]Tag, error)
 
+	SetTaskTags(ctx context.Context, taskID string, names []string) error
+	AttachTaskTag(ctx context.Context, taskID string, name string) error
+	DetachTaskTag(ctx context.Context, taskID string, name string) error
+	ListTaskTags(ctx context.Context, taskID string) ([]Tag, error)
+
 	CreateRecurrence(ctx context.Context, in RecurrenceRule) error
 	GetRecurrence(ctx context.Context, id string) (RecurrenceRule, error)
 	UpdateRecurrence(ctx context.Context, in RecurrenceRule) error
 	DeleteRecurrence(ctx context.Context, id string) error
 	ListRecurrences(ctx context.Context, filter RecurrenceListFilter) ([]RecurrenceRule, error)
+
+	GetSchedulerState(ctx context.Context) (SchedulerState, error)
+	SaveSchedulerState(ctx context.Context, in SchedulerState) error
+
+	// Origin identifies this repository's own entries in the change log.
+	Origin() string
+	LatestChangeSeq(ctx context.Context) (int64, error)
+	ChangesSince(ctx context.Context, seq int64) ([]Change, error)
+	LastChangedAt(ctx context.Context, entity ChangeEntity, ids []string) (map[string]time.Time, error)
+
+	// WithSource returns a repository whose writes are recorded in task
+	// history as coming from source.
+	WithSource(source EventSource) Repository
+	ListTaskEvents(ctx context.Context, filter TaskEventFilter) ([]TaskEvent, error)
+	CountSnoozes(ctx context.Context, taskIDs []string) (map[string]int, error)
+
+	ListSyncBase(ctx context.Context, target string) ([]SyncBase, error)
+	SaveSyncBase(ctx context.Context, in SyncBase) error
+
+	// WithTx runs fn against a Repository whose writes commit together when fn
+	// returns nil and are rolled back otherwise.
+	WithTx(ctx context.Context, fn func(Repository) error) error
 }
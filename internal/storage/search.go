@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Column weights for tasks_fts(task_id, title, description): title hits count
+// double, task_id is not indexed.
+var searchColumnWeights = []float64{0, 2, 1}
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// SearchTasks runs a full-text query over task titles and descriptions. Every
+// word in query must match, as a prefix, in either column. Results are ordered
+// best match first.
+func (r *SQLiteRepository) SearchTasks(ctx context.Context, query string) ([]TaskSearchResult, error) {
+	match := ftsMatchExpression(query)
+	if match == "" {
+		return []TaskSearchResult{}, nil
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT task_id, snippet(tasks_fts, '[', ']', '…', -1, 12), matchinfo(tasks_fts, 'pcnalx')
+		FROM tasks_fts WHERE tasks_fts MATCH ?`, match)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := make(map[string]TaskSearchResult)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id, snippet string
+		var info []byte
+		if err := rows.Scan(&id, &snippet, &info); err != nil {
+			return nil, err
+		}
+		hits[id] = TaskSearchResult{Snippet: snippet, Rank: bm25(info, searchColumnWeights)}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []TaskSearchResult{}, nil
+	}
+
+	tasks, err := r.tasksByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]TaskSearchResult, 0, len(tasks))
+	for _, task := range tasks {
+		hit := hits[task.ID]
+		hit.Task = task
+		out = append(out, hit)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Rank != out[j].Rank {
+			return out[i].Rank > out[j].Rank
+		}
+		return out[i].Task.Title < out[j].Task.Title
+	})
+	return out, nil
+}
+
+func (r *SQLiteRepository) tasksByID(ctx context.Context, ids []string) ([]Task, error) {
+	clauses, args := appendInClause(nil, nil, "id", ids)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, description, state, priority, energy, scheduled_at, due_at, created_at, completed_at
+		FROM tasks WHERE `+clauses[0], args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Task, 0, len(ids))
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadTaskTags(ctx, r.db, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ftsMatchExpression turns free text into a MATCH expression of prefix terms.
+// Only letters and digits survive and terms are lowercased, so user input can
+// never form FTS operators (AND, OR, NOT, NEAR are only special in upper case).
+func ftsMatchExpression(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, strings.ToLower(word)+"*")
+	}
+	return strings.Join(terms, " ")
+}
+
+// bm25 scores one row from a matchinfo 'pcnalx' blob.
+func bm25(info []byte, weights []float64) float64 {
+	if len(info)%4 != 0 {
+		return 0
+	}
+	vals := make([]uint32, len(info)/4)
+	for i := range vals {
+		vals[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+	if len(vals) < 3 {
+		return 0
+	}
+	phrases, cols, docs := int(vals[0]), int(vals[1]), float64(vals[2])
+	avgLen := vals[3 : 3+cols]
+	rowLen := vals[3+cols : 3+2*cols]
+	hits := vals[3+2*cols:]
+	if len(hits) < 3*cols*phrases {
+		return 0
+	}
+
+	score := 0.0
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < cols && c < len(weights); c++ {
+			x := hits[3*(c+p*cols):]
+			tf, df := float64(x[0]), float64(x[2])
+			if tf == 0 || weights[c] == 0 {
+				continue
+			}
+			idf := math.Max(math.Log((docs-df+0.5)/(df+0.5)), 1e-6)
+			norm := 1.0
+			if avgLen[c] > 0 {
+				norm = 1 - bm25B + bm25B*float64(rowLen[c])/float64(avgLen[c])
+			}
+			score += weights[c] * idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+	return score
+}
@@ -9,12 +9,31 @@ import (
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/sandeepkv93/taskd/internal/model"
 )
 
 const sqliteTimeLayout = time.RFC3339Nano
 
+var (
+	ErrInvalidTagName = errors.New("storage: invalid tag name")
+	ErrInvalidFilter  = errors.New("storage: invalid filter")
+	ErrInTransaction  = errors.New("storage: not allowed inside a transaction")
+)
+
+// SQLiteRepository runs every method against db. The repository handed to a
+// WithTx callback shares the open *sql.Tx as db and has no conn.
 type SQLiteRepository struct {
-	db *sql.DB
+	db     dbtx
+	conn   *sql.DB
+	origin string
+	source EventSource
+}
+
+// dbtx is the subset of *sql.DB and *sql.Tx used by multi-statement helpers.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
 func NewSQLiteRepository(db *sql.DB) (*SQLiteRepository, error) {
@@ -24,7 +43,7 @@ func NewSQLiteRepository(db *sql.DB) (*SQLiteRepository, error) {
 	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
 		return nil, fmt.Errorf("enable foreign keys: %w", err)
 	}
-	return &SQLiteRepository{db: db}, nil
+	return &SQLiteRepository{db: db, conn: db, origin: model.NewID("origin"), source: SourceSystem}, nil
 }
 
 func OpenSQLite(path string) (*SQLiteRepository, error) {
@@ -41,17 +60,38 @@ func OpenSQLite(path string) (*SQLiteRepository, error) {
 }
 
 func (r *SQLiteRepository) Close() error {
-	return r.db.Close()
+	if r.conn == nil {
+		return ErrInTransaction
+	}
+	return r.conn.Close()
+}
+
+// WithTx runs fn with a Repository bound to a single transaction. The
+// transaction commits when fn returns nil and rolls back when fn returns an
+// error or panics. Calling WithTx on a transactional repository joins the
+// outer transaction.
+func (r *SQLiteRepository) WithTx(ctx context.Context, fn func(Repository) error) error {
+	return r.inTx(ctx, func(tx dbtx) error {
+		return fn(&SQLiteRepository{db: tx, origin: r.origin, source: r.source})
+	})
 }
 
 func (r *SQLiteRepository) CreateTask(ctx context.Context, in Task) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO tasks (id, title, description, state, priority, energy, scheduled_at, due_at, created_at, completed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		in.ID, in.Title, in.Description, in.State, in.Priority, in.Energy,
-		nullTime(in.ScheduledAt), nullTime(in.DueAt), mustTime(in.CreatedAt), nullTime(in.CompletedAt),
-	)
-	return err
+	if err := validateTask(in); err != nil {
+		return err
+	}
+	return r.write(ctx, ChangeTask, in.ID, ChangeCreate, func(tx dbtx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO tasks (id, title, description, state, priority, energy, scheduled_at, due_at, created_at, completed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			in.ID, in.Title, in.Description, in.State, in.Priority, in.Energy,
+			nullTime(in.ScheduledAt), nullTime(in.DueAt), mustTime(in.CreatedAt), nullTime(in.CompletedAt),
+		)
+		if err != nil {
+			return err
+		}
+		return replaceTaskTags(ctx, tx, in.ID, in.Tags)
+	})
 }
 
 func (r *SQLiteRepository) GetTask(ctx context.Context, id string) (Task, error) {
@@ -65,39 +105,122 @@ func (r *SQLiteRepository) GetTask(ctx context.Context, id string) (Task, error)
 		}
 		return Task{}, err
 	}
-	return task, nil
+	tasks := []Task{task}
+	if err := loadTaskTags(ctx, r.db, tasks); err != nil {
+		return Task{}, err
+	}
+	return tasks[0], nil
 }
 
 func (r *SQLiteRepository) UpdateTask(ctx context.Context, in Task) error {
-	res, err := r.db.ExecContext(ctx, `
-		UPDATE tasks
-		SET title = ?, description = ?, state = ?, priority = ?, energy = ?, scheduled_at = ?, due_at = ?, completed_at = ?
-		WHERE id = ?`,
-		in.Title, in.Description, in.State, in.Priority, in.Energy,
-		nullTime(in.ScheduledAt), nullTime(in.DueAt), nullTime(in.CompletedAt), in.ID,
-	)
-	if err != nil {
+	if err := validateTask(in); err != nil {
 		return err
 	}
-	return checkRowsAffected(res)
+	return r.write(ctx, ChangeTask, in.ID, ChangeUpdate, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE tasks
+			SET title = ?, description = ?, state = ?, priority = ?, energy = ?, scheduled_at = ?, due_at = ?, completed_at = ?
+			WHERE id = ?`,
+			in.Title, in.Description, in.State, in.Priority, in.Energy,
+			nullTime(in.ScheduledAt), nullTime(in.DueAt), nullTime(in.CompletedAt), in.ID,
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkRowsAffected(res); err != nil {
+			return err
+		}
+		if in.Tags == nil {
+			return nil
+		}
+		return replaceTaskTags(ctx, tx, in.ID, in.Tags)
+	})
 }
 
 func (r *SQLiteRepository) DeleteTask(ctx context.Context, id string) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+	return r.write(ctx, ChangeTask, id, ChangeDelete, func(tx dbtx) error {
+		// The task's reminders and rules go with it; log them too so other
+		// processes drop their copies.
+		reminders, err := queryIDs(ctx, tx, `SELECT id FROM reminders WHERE task_id = ? ORDER BY id`, id)
+		if err != nil {
+			return err
+		}
+		rules, err := queryIDs(ctx, tx, `SELECT id FROM recurrence_rules WHERE task_id = ? ORDER BY id`, id)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		if err := checkRowsAffected(res); err != nil {
+			return err
+		}
+		for _, child := range reminders {
+			if err := r.logChange(ctx, tx, ChangeReminder, child, ChangeDelete); err != nil {
+				return err
+			}
+		}
+		for _, child := range rules {
+			if err := r.logChange(ctx, tx, ChangeRecurrence, child, ChangeDelete); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// queryIDs returns the single string column query selects.
+func queryIDs(ctx context.Context, tx dbtx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
 	}
-	return checkRowsAffected(res)
+	return ids, rows.Err()
 }
 
 func (r *SQLiteRepository) ListTasks(ctx context.Context, filter TaskListFilter) ([]Task, error) {
+	orderBy, ok := taskSortClauses[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidFilter, filter.Sort)
+	}
 	query := `SELECT id, title, description, state, priority, energy, scheduled_at, due_at, created_at, completed_at FROM tasks`
-	args := make([]any, 0, 3)
+	clauses := make([]string, 0, 8)
+	args := make([]any, 0, 8)
 	if filter.State != "" {
-		query += ` WHERE state = ?`
+		clauses = append(clauses, "state = ?")
 		args = append(args, filter.State)
 	}
-	query += ` ORDER BY created_at DESC`
+	clauses, args = appendInClause(clauses, args, "state", filter.States)
+	clauses, args = appendInClause(clauses, args, "priority", filter.Priorities)
+	clauses, args = appendInClause(clauses, args, "energy", filter.Energies)
+	if tag := normalizeTagName(filter.Tag); tag != "" {
+		clauses = append(clauses, `EXISTS (
+			SELECT 1 FROM task_tags tt JOIN tags tg ON tg.id = tt.tag_id
+			WHERE tt.task_id = tasks.id AND tg.name = ?)`)
+		args = append(args, tag)
+	}
+	clauses, args = appendRangeClause(clauses, args, "scheduled_at", filter.Scheduled)
+	clauses, args = appendRangeClause(clauses, args, "due_at", filter.Due)
+	clauses, args = appendRangeClause(clauses, args, "completed_at", filter.Completed)
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		clauses = append(clauses, `(title LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY " + orderBy
 	query += applyPagination(&args, filter.Limit, filter.Offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -114,16 +237,24 @@ func (r *SQLiteRepository) ListTasks(ctx context.Context, filter TaskListFilter)
 		}
 		out = append(out, task)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadTaskTags(ctx, r.db, out); err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
 func (r *SQLiteRepository) CreateReminder(ctx context.Context, in Reminder) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO reminders (id, task_id, trigger_time, type, repeat_rule, last_fired_at, enabled, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		in.ID, in.TaskID, mustTime(in.TriggerAt), in.Type, in.RepeatRule, nullTime(in.LastFired), boolInt(in.Enabled), mustTime(in.CreatedAt),
-	)
-	return err
+	return r.write(ctx, ChangeReminder, in.ID, ChangeCreate, func(tx dbtx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO reminders (id, task_id, trigger_time, type, repeat_rule, last_fired_at, enabled, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			in.ID, in.TaskID, mustTime(in.TriggerAt), in.Type, in.RepeatRule, nullTime(in.LastFired), boolInt(in.Enabled), mustTime(in.CreatedAt),
+		)
+		return err
+	})
 }
 
 func (r *SQLiteRepository) GetReminder(ctx context.Context, id string) (Reminder, error) {
@@ -141,24 +272,28 @@ func (r *SQLiteRepository) GetReminder(ctx context.Context, id string) (Reminder
 }
 
 func (r *SQLiteRepository) UpdateReminder(ctx context.Context, in Reminder) error {
-	res, err := r.db.ExecContext(ctx, `
-		UPDATE reminders
-		SET task_id = ?, trigger_time = ?, type = ?, repeat_rule = ?, last_fired_at = ?, enabled = ?
-		WHERE id = ?`,
-		in.TaskID, mustTime(in.TriggerAt), in.Type, in.RepeatRule, nullTime(in.LastFired), boolInt(in.Enabled), in.ID,
-	)
-	if err != nil {
-		return err
-	}
-	return checkRowsAffected(res)
+	return r.write(ctx, ChangeReminder, in.ID, ChangeUpdate, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE reminders
+			SET task_id = ?, trigger_time = ?, type = ?, repeat_rule = ?, last_fired_at = ?, enabled = ?
+			WHERE id = ?`,
+			in.TaskID, mustTime(in.TriggerAt), in.Type, in.RepeatRule, nullTime(in.LastFired), boolInt(in.Enabled), in.ID,
+		)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(res)
+	})
 }
 
 func (r *SQLiteRepository) DeleteReminder(ctx context.Context, id string) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM reminders WHERE id = ?`, id)
-	if err != nil {
-		return err
-	}
-	return checkRowsAffected(res)
+	return r.write(ctx, ChangeReminder, id, ChangeDelete, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM reminders WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(res)
+	})
 }
 
 func (r *SQLiteRepository) ListReminders(ctx context.Context, filter ReminderListFilter) ([]Reminder, error) {
@@ -197,12 +332,14 @@ func (r *SQLiteRepository) ListReminders(ctx context.Context, filter ReminderLis
 }
 
 func (r *SQLiteRepository) CreateTag(ctx context.Context, in Tag) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO tags (id, name, created_at)
-		VALUES (?, ?, ?)`,
-		in.ID, in.Name, mustTime(in.CreatedAt),
-	)
-	return err
+	return r.write(ctx, ChangeTag, in.ID, ChangeCreate, func(tx dbtx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO tags (id, name, created_at)
+			VALUES (?, ?, ?)`,
+			in.ID, in.Name, mustTime(in.CreatedAt),
+		)
+		return err
+	})
 }
 
 func (r *SQLiteRepository) GetTag(ctx context.Context, id string) (Tag, error) {
@@ -218,19 +355,23 @@ func (r *SQLiteRepository) GetTag(ctx context.Context, id string) (Tag, error) {
 }
 
 func (r *SQLiteRepository) UpdateTag(ctx context.Context, in Tag) error {
-	res, err := r.db.ExecContext(ctx, `UPDATE tags SET name = ? WHERE id = ?`, in.Name, in.ID)
-	if err != nil {
-		return err
-	}
-	return checkRowsAffected(res)
+	return r.write(ctx, ChangeTag, in.ID, ChangeUpdate, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE tags SET name = ? WHERE id = ?`, in.Name, in.ID)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(res)
+	})
 }
 
 func (r *SQLiteRepository) DeleteTag(ctx context.Context, id string) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
-	if err != nil {
-		return err
-	}
-	return checkRowsAffected(res)
+	return r.write(ctx, ChangeTag, id, ChangeDelete, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(res)
+	})
 }
 
 func (r *SQLiteRepository) ListTags(ctx context.Context, filter TagListFilter) ([]Tag, error) {
@@ -253,18 +394,85 @@ func (r *SQLiteRepository) ListTags(ctx context.Context, filter TagListFilter) (
 	return out, rows.Err()
 }
 
+func (r *SQLiteRepository) SetTaskTags(ctx context.Context, taskID string, names []string) error {
+	return r.write(ctx, ChangeTask, taskID, ChangeUpdate, func(tx dbtx) error {
+		if err := requireTask(ctx, tx, taskID); err != nil {
+			return err
+		}
+		return replaceTaskTags(ctx, tx, taskID, names)
+	})
+}
+
+func (r *SQLiteRepository) AttachTaskTag(ctx context.Context, taskID string, name string) error {
+	normalized := normalizeTagName(name)
+	if normalized == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidTagName, name)
+	}
+	return r.write(ctx, ChangeTask, taskID, ChangeUpdate, func(tx dbtx) error {
+		if err := requireTask(ctx, tx, taskID); err != nil {
+			return err
+		}
+		return attachTaskTag(ctx, tx, taskID, normalized)
+	})
+}
+
+func (r *SQLiteRepository) DetachTaskTag(ctx context.Context, taskID string, name string) error {
+	return r.write(ctx, ChangeTask, taskID, ChangeUpdate, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `
+			DELETE FROM task_tags
+			WHERE task_id = ? AND tag_id IN (SELECT id FROM tags WHERE name = ?)`,
+			taskID, normalizeTagName(name),
+		)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(res)
+	})
+}
+
+func (r *SQLiteRepository) ListTaskTags(ctx context.Context, taskID string) ([]Tag, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tg.id, tg.name, tg.created_at
+		FROM tags tg JOIN task_tags tt ON tt.tag_id = tg.id
+		WHERE tt.task_id = ?
+		ORDER BY tg.name ASC`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Tag, 0)
+	for rows.Next() {
+		item, scanErr := scanTag(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
 func (r *SQLiteRepository) CreateRecurrence(ctx context.Context, in RecurrenceRule) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO recurrence_rules (id, task_id, rule_type, interval_value, timezone, start_at, next_occurrence_at, enabled, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		in.ID, in.TaskID, in.RuleType, in.IntervalValue, in.Timezone, mustTime(in.StartAt), nullTime(in.NextAt), boolInt(in.Enabled), mustTime(in.CreatedAt),
-	)
-	return err
+	if err := validateRecurrence(in); err != nil {
+		return err
+	}
+	return r.write(ctx, ChangeRecurrence, in.ID, ChangeCreate, func(tx dbtx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO recurrence_rules (id, task_id, rule_type, interval_value, timezone, start_at, next_occurrence_at, enabled, created_at, weekdays, after_complete_ns, rrule, until_at, max_count)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			in.ID, in.TaskID, in.RuleType, in.IntervalValue, in.Timezone, mustTime(in.StartAt), nullTime(in.NextAt), boolInt(in.Enabled), mustTime(in.CreatedAt),
+			FormatWeekdays(in.Weekdays), int64(in.AfterCompleteIn), in.RRule, nullTime(in.Until), in.Count,
+		)
+		if err != nil {
+			return err
+		}
+		return replaceRecurrenceExceptions(ctx, tx, in.ID, in.Exceptions)
+	})
 }
 
 func (r *SQLiteRepository) GetRecurrence(ctx context.Context, id string) (RecurrenceRule, error) {
 	row := r.db.QueryRowContext(ctx, `
-		SELECT id, task_id, rule_type, interval_value, timezone, start_at, next_occurrence_at, enabled, created_at
+		SELECT `+recurrenceColumns+`
 		FROM recurrence_rules WHERE id = ?`, id)
 	item, err := scanRecurrence(row)
 	if err != nil {
@@ -273,32 +481,51 @@ func (r *SQLiteRepository) GetRecurrence(ctx context.Context, id string) (Recurr
 		}
 		return RecurrenceRule{}, err
 	}
-	return item, nil
+	rules := []RecurrenceRule{item}
+	if err := loadRecurrenceExceptions(ctx, r.db, rules); err != nil {
+		return RecurrenceRule{}, err
+	}
+	return rules[0], nil
 }
 
 func (r *SQLiteRepository) UpdateRecurrence(ctx context.Context, in RecurrenceRule) error {
-	res, err := r.db.ExecContext(ctx, `
-		UPDATE recurrence_rules
-		SET task_id = ?, rule_type = ?, interval_value = ?, timezone = ?, start_at = ?, next_occurrence_at = ?, enabled = ?
-		WHERE id = ?`,
-		in.TaskID, in.RuleType, in.IntervalValue, in.Timezone, mustTime(in.StartAt), nullTime(in.NextAt), boolInt(in.Enabled), in.ID,
-	)
-	if err != nil {
+	if err := validateRecurrence(in); err != nil {
 		return err
 	}
-	return checkRowsAffected(res)
+	return r.write(ctx, ChangeRecurrence, in.ID, ChangeUpdate, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE recurrence_rules
+			SET task_id = ?, rule_type = ?, interval_value = ?, timezone = ?, start_at = ?, next_occurrence_at = ?, enabled = ?,
+				weekdays = ?, after_complete_ns = ?, rrule = ?, until_at = ?, max_count = ?
+			WHERE id = ?`,
+			in.TaskID, in.RuleType, in.IntervalValue, in.Timezone, mustTime(in.StartAt), nullTime(in.NextAt), boolInt(in.Enabled),
+			FormatWeekdays(in.Weekdays), int64(in.AfterCompleteIn), in.RRule, nullTime(in.Until), in.Count, in.ID,
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkRowsAffected(res); err != nil {
+			return err
+		}
+		if in.Exceptions == nil {
+			return nil
+		}
+		return replaceRecurrenceExceptions(ctx, tx, in.ID, in.Exceptions)
+	})
 }
 
 func (r *SQLiteRepository) DeleteRecurrence(ctx context.Context, id string) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM recurrence_rules WHERE id = ?`, id)
-	if err != nil {
-		return err
-	}
-	return checkRowsAffected(res)
+	return r.write(ctx, ChangeRecurrence, id, ChangeDelete, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM recurrence_rules WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		return checkRowsAffected(res)
+	})
 }
 
 func (r *SQLiteRepository) ListRecurrences(ctx context.Context, filter RecurrenceListFilter) ([]RecurrenceRule, error) {
-	query := `SELECT id, task_id, rule_type, interval_value, timezone, start_at, next_occurrence_at, enabled, created_at FROM recurrence_rules`
+	query := `SELECT ` + recurrenceColumns + ` FROM recurrence_rules`
 	clauses := make([]string, 0, 2)
 	args := make([]any, 0, 4)
 	if filter.TaskID != "" {
@@ -329,7 +556,260 @@ func (r *SQLiteRepository) ListRecurrences(ctx context.Context, filter Recurrenc
 		}
 		out = append(out, item)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadRecurrenceExceptions(ctx, r.db, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// taskSortClauses maps sort keys to ORDER BY clauses. Timestamps are compared
+// through julianday because RFC3339Nano text does not sort lexically.
+var taskSortClauses = map[TaskSort]string{
+	"":                    "julianday(created_at) DESC, id ASC",
+	TaskSortCreatedDesc:   "julianday(created_at) DESC, id ASC",
+	TaskSortCreatedAsc:    "julianday(created_at) ASC, id ASC",
+	TaskSortScheduledAsc:  "scheduled_at IS NULL, julianday(scheduled_at) ASC, julianday(created_at) ASC, id ASC",
+	TaskSortDueAsc:        "due_at IS NULL, julianday(due_at) ASC, julianday(created_at) ASC, id ASC",
+	TaskSortCompletedDesc: "completed_at IS NULL, julianday(completed_at) DESC, id ASC",
+	TaskSortPriorityDesc: `CASE priority WHEN 'Critical' THEN 4 WHEN 'High' THEN 3 WHEN 'Medium' THEN 2 ELSE 1 END DESC,
+		julianday(created_at) DESC, id ASC`,
+	TaskSortTitleAsc: "title COLLATE NOCASE ASC, id ASC",
+}
+
+func (r *SQLiteRepository) GetSchedulerState(ctx context.Context) (SchedulerState, error) {
+	var lastTick sql.NullString
+	var updated string
+	var out SchedulerState
+	err := r.db.QueryRowContext(ctx, `
+		SELECT last_tick_at, checkpoint_cursor, updated_at FROM scheduler_state WHERE id = 1`,
+	).Scan(&lastTick, &out.CheckpointCursor, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SchedulerState{}, nil
+	}
+	if err != nil {
+		return SchedulerState{}, err
+	}
+	if out.LastTickAt, err = parseNullableTime(lastTick); err != nil {
+		return SchedulerState{}, err
+	}
+	if out.UpdatedAt, err = parseRequiredTime(updated); err != nil {
+		return SchedulerState{}, err
+	}
+	return out, nil
+}
+
+func (r *SQLiteRepository) SaveSchedulerState(ctx context.Context, in SchedulerState) error {
+	updated := in.UpdatedAt
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO scheduler_state (id, last_tick_at, checkpoint_cursor, updated_at)
+		VALUES (1, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			last_tick_at = excluded.last_tick_at,
+			checkpoint_cursor = excluded.checkpoint_cursor,
+			updated_at = excluded.updated_at`,
+		nullTime(in.LastTickAt), in.CheckpointCursor, mustTime(updated),
+	)
+	return err
+}
+
+func appendInClause(clauses []string, args []any, column string, values []string) ([]string, []any) {
+	if len(values) == 0 {
+		return clauses, args
+	}
+	placeholders := make([]string, 0, len(values))
+	for _, v := range values {
+		placeholders = append(placeholders, "?")
+		args = append(args, v)
+	}
+	return append(clauses, column+" IN ("+strings.Join(placeholders, ", ")+")"), args
+}
+
+func appendRangeClause(clauses []string, args []any, column string, r TimeRange) ([]string, []any) {
+	if r.From != nil {
+		clauses = append(clauses, "julianday("+column+") >= julianday(?)")
+		args = append(args, nullTime(r.From))
+	}
+	if r.To != nil {
+		clauses = append(clauses, "julianday("+column+") < julianday(?)")
+		args = append(args, nullTime(r.To))
+	}
+	return clauses, args
+}
+
+func escapeLike(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(v)
+}
+
+// inTx runs fn in a new transaction, or directly when r is already bound to
+// one.
+func (r *SQLiteRepository) inTx(ctx context.Context, fn func(tx dbtx) error) error {
+	if r.conn == nil {
+		return fn(r.db)
+	}
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func requireTask(ctx context.Context, q dbtx, taskID string) error {
+	var exists int
+	err := q.QueryRowContext(ctx, `SELECT 1 FROM tasks WHERE id = ?`, taskID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func normalizeTagName(name string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
+
+// ensureTag returns the ID of the tag called name, inserting it when missing.
+func ensureTag(ctx context.Context, q dbtx, name string) (string, error) {
+	var id string
+	err := q.QueryRowContext(ctx, `SELECT id FROM tags WHERE name = ?`, name).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	id = model.NewID("tag")
+	if _, err := q.ExecContext(ctx, `INSERT INTO tags (id, name, created_at) VALUES (?, ?, ?)`,
+		id, name, mustTime(time.Now())); err != nil {
+		return "", fmt.Errorf("create tag %q: %w", name, err)
+	}
+	return id, nil
+}
+
+func attachTaskTag(ctx context.Context, q dbtx, taskID string, name string) error {
+	tagID, err := ensureTag(ctx, q, name)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `INSERT OR IGNORE INTO task_tags (task_id, tag_id, created_at) VALUES (?, ?, ?)`,
+		taskID, tagID, mustTime(time.Now()))
+	return err
+}
+
+func replaceTaskTags(ctx context.Context, q dbtx, taskID string, names []string) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM task_tags WHERE task_id = ?`, taskID); err != nil {
+		return err
+	}
+	for _, name := range names {
+		normalized := normalizeTagName(name)
+		if normalized == "" {
+			continue
+		}
+		if err := attachTaskTag(ctx, q, taskID, normalized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTaskTags fills Tags on each task with its tag names in name order.
+func loadTaskTags(ctx context.Context, q dbtx, tasks []Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(tasks))
+	args := make([]any, 0, len(tasks))
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		placeholders = append(placeholders, "?")
+		args = append(args, task.ID)
+		index[task.ID] = i
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT tt.task_id, tg.name
+		FROM task_tags tt JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.task_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY tg.name ASC`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var taskID, name string
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return err
+		}
+		i := index[taskID]
+		tasks[i].Tags = append(tasks[i].Tags, name)
+	}
+	return rows.Err()
+}
+
+func replaceRecurrenceExceptions(ctx context.Context, q dbtx, ruleID string, exceptions []RecurrenceException) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM recurrence_exceptions WHERE rule_id = ?`, ruleID); err != nil {
+		return err
+	}
+	for _, ex := range exceptions {
+		if _, err := q.ExecContext(ctx, `INSERT INTO recurrence_exceptions (rule_id, kind, at, to_at) VALUES (?, ?, ?, ?)`,
+			ruleID, ex.Kind, mustTime(ex.At), nullTime(ex.To)); err != nil {
+			return fmt.Errorf("store %s exception: %w", ex.Kind, err)
+		}
+	}
+	return nil
+}
+
+// loadRecurrenceExceptions fills Exceptions on each rule in time order.
+func loadRecurrenceExceptions(ctx context.Context, q dbtx, rules []RecurrenceRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(rules))
+	args := make([]any, 0, len(rules))
+	index := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		placeholders = append(placeholders, "?")
+		args = append(args, rule.ID)
+		index[rule.ID] = i
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT rule_id, kind, at, to_at FROM recurrence_exceptions
+		WHERE rule_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY julianday(at) ASC, kind ASC`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ruleID, at string
+		var to sql.NullString
+		var ex RecurrenceException
+		if err := rows.Scan(&ruleID, &ex.Kind, &at, &to); err != nil {
+			return err
+		}
+		if ex.At, err = parseRequiredTime(at); err != nil {
+			return err
+		}
+		if ex.To, err = parseNullableTime(to); err != nil {
+			return err
+		}
+		i := index[ruleID]
+		rules[i].Exceptions = append(rules[i].Exceptions, ex)
+	}
+	return rows.Err()
 }
 
 func nullTime(v *time.Time) any {
@@ -372,6 +852,10 @@ func applyPagination(args *[]any, limit, offset int) string {
 		*args = append(*args, limit)
 	}
 	if offset > 0 {
+		if limit <= 0 {
+			// SQLite only accepts OFFSET after a LIMIT; -1 means unbounded.
+			sql += " LIMIT -1"
+		}
 		sql += " OFFSET ?"
 		*args = append(*args, offset)
 	}
@@ -456,13 +940,18 @@ func scanTag(s scanner) (Tag, error) {
 	return out, nil
 }
 
+const recurrenceColumns = `id, task_id, rule_type, interval_value, timezone, start_at, next_occurrence_at, enabled, created_at, weekdays, after_complete_ns, rrule, until_at, max_count`
+
 func scanRecurrence(s scanner) (RecurrenceRule, error) {
 	var out RecurrenceRule
 	var start string
 	var next sql.NullString
 	var enabled int
 	var created string
-	if err := s.Scan(&out.ID, &out.TaskID, &out.RuleType, &out.IntervalValue, &out.Timezone, &start, &next, &enabled, &created); err != nil {
+	var weekdays string
+	var afterComplete int64
+	var until sql.NullString
+	if err := s.Scan(&out.ID, &out.TaskID, &out.RuleType, &out.IntervalValue, &out.Timezone, &start, &next, &enabled, &created, &weekdays, &afterComplete, &out.RRule, &until, &out.Count); err != nil {
 		return RecurrenceRule{}, err
 	}
 	startAt, err := parseRequiredTime(start)
@@ -477,10 +966,19 @@ func scanRecurrence(s scanner) (RecurrenceRule, error) {
 	if err != nil {
 		return RecurrenceRule{}, err
 	}
+	days, err := ParseWeekdays(weekdays)
+	if err != nil {
+		return RecurrenceRule{}, err
+	}
+	if out.Until, err = parseNullableTime(until); err != nil {
+		return RecurrenceRule{}, err
+	}
 	out.StartAt = startAt
 	out.NextAt = nextAt
 	out.Enabled = enabled == 1
 	out.CreatedAt = createdAt
+	out.Weekdays = days
+	out.AfterCompleteIn = time.Duration(afterComplete)
 	return out, nil
 }
 
@@ -3,9 +3,13 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/sandeepkv93/taskd/internal/model"
 )
 
 func setupRepo(t *testing.T) *SQLiteRepository {
@@ -260,3 +264,810 @@ func TestRecurrenceCRUDAndList(t *testing.T) {
 		t.Fatalf("expected ErrNotFound, got: %v", err)
 	}
 }
+
+func TestRecurrenceExceptionsReplaceAndPreserveNil(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	start := parseRFC3339(t, "2026-02-10T09:00:00Z")
+	until := parseRFC3339(t, "2026-03-10T09:00:00Z")
+	back := parseRFC3339(t, "2026-02-20T00:00:00Z")
+
+	if err := repo.CreateTask(ctx, Task{ID: "task-ex", Title: "Swim", State: "Planned", Priority: "Low", Energy: "Low", CreatedAt: now}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	rec := RecurrenceRule{
+		ID: "rec-ex", TaskID: "task-ex", RuleType: "every_n_days", IntervalValue: 1, Timezone: "UTC",
+		StartAt: start, Enabled: true, CreatedAt: now, Until: &until,
+		Exceptions: []RecurrenceException{
+			{Kind: "pause", At: parseRFC3339(t, "2026-02-14T00:00:00Z"), To: &back},
+			{Kind: "skip", At: parseRFC3339(t, "2026-02-11T09:00:00Z")},
+		},
+	}
+	if err := repo.CreateRecurrence(ctx, rec); err != nil {
+		t.Fatalf("create recurrence: %v", err)
+	}
+	got, err := repo.GetRecurrence(ctx, rec.ID)
+	if err != nil {
+		t.Fatalf("get recurrence: %v", err)
+	}
+	if got.Until == nil || !got.Until.Equal(until) || len(got.Exceptions) != 2 || got.Exceptions[0].Kind != "skip" || got.Exceptions[1].To == nil {
+		t.Fatalf("unexpected recurrence: %#v", got)
+	}
+
+	rec.Exceptions = nil
+	rec.Until, rec.Count = nil, 10
+	if err := repo.UpdateRecurrence(ctx, rec); err != nil {
+		t.Fatalf("update recurrence: %v", err)
+	}
+	list, err := repo.ListRecurrences(ctx, RecurrenceListFilter{TaskID: "task-ex"})
+	if err != nil {
+		t.Fatalf("list recurrences: %v", err)
+	}
+	if len(list) != 1 || list[0].Until != nil || list[0].Count != 10 || len(list[0].Exceptions) != 2 {
+		t.Fatalf("expected the exceptions kept by a nil update: %#v", list)
+	}
+
+	rec.Exceptions = []RecurrenceException{}
+	if err := repo.UpdateRecurrence(ctx, rec); err != nil {
+		t.Fatalf("clear exceptions: %v", err)
+	}
+	if got, _ := repo.GetRecurrence(ctx, rec.ID); len(got.Exceptions) != 0 {
+		t.Fatalf("expected exceptions cleared, got %#v", got.Exceptions)
+	}
+
+	rec.Exceptions = []RecurrenceException{{Kind: "skip", At: start}}
+	if err := repo.UpdateRecurrence(ctx, rec); err != nil {
+		t.Fatalf("replace exceptions: %v", err)
+	}
+	if err := repo.DeleteTask(ctx, "task-ex"); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	var left int
+	if err := repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM recurrence_exceptions`).Scan(&left); err != nil || left != 0 {
+		t.Fatalf("expected exceptions deleted with their rule, got %d, %v", left, err)
+	}
+}
+
+func TestTaskTagsAttachDetachAndFilter(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+
+	if err := repo.CreateTask(ctx, Task{
+		ID: "task-a", Title: "Pay rent", State: "Inbox", Priority: "High", Energy: "Low",
+		CreatedAt: now, Tags: []string{"#finance", "home"},
+	}); err != nil {
+		t.Fatalf("create task a: %v", err)
+	}
+	if err := repo.CreateTask(ctx, Task{
+		ID: "task-b", Title: "Write docs", State: "Inbox", Priority: "Low", Energy: "Light",
+		CreatedAt: now.Add(time.Minute),
+	}); err != nil {
+		t.Fatalf("create task b: %v", err)
+	}
+
+	got, err := repo.GetTask(ctx, "task-a")
+	if err != nil {
+		t.Fatalf("get task a: %v", err)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "finance" || got.Tags[1] != "home" {
+		t.Fatalf("unexpected tags on create: %#v", got.Tags)
+	}
+
+	if err := repo.AttachTaskTag(ctx, "task-b", "finance"); err != nil {
+		t.Fatalf("attach tag: %v", err)
+	}
+	if err := repo.AttachTaskTag(ctx, "task-b", "finance"); err != nil {
+		t.Fatalf("re-attach tag should be idempotent: %v", err)
+	}
+	tags, err := repo.ListTags(ctx, TagListFilter{})
+	if err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("expected finance and home tag rows only, got %#v", tags)
+	}
+
+	finance, err := repo.ListTasks(ctx, TaskListFilter{Tag: "finance"})
+	if err != nil {
+		t.Fatalf("list by tag: %v", err)
+	}
+	if len(finance) != 2 {
+		t.Fatalf("expected 2 finance tasks, got %#v", finance)
+	}
+	home, err := repo.ListTasks(ctx, TaskListFilter{Tag: "home", State: "Inbox"})
+	if err != nil {
+		t.Fatalf("list by tag and state: %v", err)
+	}
+	if len(home) != 1 || home[0].ID != "task-a" {
+		t.Fatalf("unexpected home tasks: %#v", home)
+	}
+
+	if err := repo.DetachTaskTag(ctx, "task-b", "finance"); err != nil {
+		t.Fatalf("detach tag: %v", err)
+	}
+	if err := repo.DetachTaskTag(ctx, "task-b", "finance"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound detaching twice, got %v", err)
+	}
+	bTags, err := repo.ListTaskTags(ctx, "task-b")
+	if err != nil {
+		t.Fatalf("list task tags: %v", err)
+	}
+	if len(bTags) != 0 {
+		t.Fatalf("expected no tags on task-b, got %#v", bTags)
+	}
+}
+
+func TestSetTaskTagsReplacesAndUpdateTaskPreservesNil(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+
+	task := Task{ID: "task-s", Title: "Plan trip", State: "Planned", Priority: "Medium", Energy: "Light", CreatedAt: now}
+	if err := repo.CreateTask(ctx, task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := repo.SetTaskTags(ctx, task.ID, []string{"travel", "family", "travel"}); err != nil {
+		t.Fatalf("set tags: %v", err)
+	}
+
+	task.Title = "Plan summer trip"
+	if err := repo.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("update without tags: %v", err)
+	}
+	got, err := repo.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "family" || got.Tags[1] != "travel" {
+		t.Fatalf("expected tags preserved on nil update, got %#v", got.Tags)
+	}
+
+	got.Tags = []string{}
+	if err := repo.UpdateTask(ctx, got); err != nil {
+		t.Fatalf("update clearing tags: %v", err)
+	}
+	cleared, err := repo.ListTaskTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("list task tags: %v", err)
+	}
+	if len(cleared) != 0 {
+		t.Fatalf("expected cleared tags, got %#v", cleared)
+	}
+
+	if err := repo.SetTaskTags(ctx, "missing", []string{"x"}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for missing task, got %v", err)
+	}
+	if err := repo.AttachTaskTag(ctx, task.ID, "  #  "); !errors.Is(err, ErrInvalidTagName) {
+		t.Fatalf("expected ErrInvalidTagName, got %v", err)
+	}
+}
+
+func TestListTasksRichFilterAndSort(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	base := parseRFC3339(t, "2026-02-09T00:00:00Z")
+	at := func(h int, ms int) *time.Time {
+		v := base.Add(time.Duration(h)*time.Hour + time.Duration(ms)*time.Millisecond)
+		return &v
+	}
+
+	seed := []Task{
+		{ID: "standup", Title: "Daily standup", Description: "share blockers", State: "Planned", Priority: "High", Energy: "Social", ScheduledAt: at(9, 0)},
+		{ID: "review", Title: "Review PR", Description: "check 100% coverage", State: "Planned", Priority: "Medium", Energy: "Light", ScheduledAt: at(14, 500)},
+		{ID: "taxes", Title: "Submit taxes", Description: "finance", State: "Planned", Priority: "Critical", Energy: "Low", DueAt: at(-20, 0)},
+		{ID: "tomorrow", Title: "Plan sprint", State: "Inbox", Priority: "Low", Energy: "Deep", ScheduledAt: at(33, 0)},
+		{ID: "shipped", Title: "Ship release", State: "Done", Priority: "High", Energy: "Deep", DueAt: at(-30, 0), CompletedAt: at(-2, 0)},
+	}
+	for i, task := range seed {
+		task.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("create %s: %v", task.ID, err)
+		}
+	}
+
+	ids := func(tasks []Task) []string {
+		out := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+	cases := []struct {
+		name   string
+		filter TaskListFilter
+		want   []string
+	}{
+		{"default newest first", TaskListFilter{}, []string{"shipped", "tomorrow", "taxes", "review", "standup"}},
+		{"scheduled today", TaskListFilter{Scheduled: TimeRange{From: at(0, 0), To: at(24, 0)}, Sort: TaskSortScheduledAsc}, []string{"standup", "review"}},
+		{"scheduled sub-second bound", TaskListFilter{Scheduled: TimeRange{From: at(14, 499), To: at(14, 501)}}, []string{"review"}},
+		{"overdue and open", TaskListFilter{Due: TimeRange{To: at(0, 0)}, States: []string{"Inbox", "Planned", "Snoozed"}}, []string{"taxes"}},
+		{"completed range", TaskListFilter{Completed: TimeRange{From: at(-3, 0)}}, []string{"shipped"}},
+		{"priority set", TaskListFilter{Priorities: []string{"High", "Critical"}, Sort: TaskSortPriorityDesc}, []string{"taxes", "shipped", "standup"}},
+		{"energy set", TaskListFilter{Energies: []string{"Deep"}, Sort: TaskSortCreatedAsc}, []string{"tomorrow", "shipped"}},
+		{"search title case-insensitive", TaskListFilter{Search: "SPRINT"}, []string{"tomorrow"}},
+		{"search description literal percent", TaskListFilter{Search: "100%"}, []string{"review"}},
+		{"due ascending nulls last", TaskListFilter{Sort: TaskSortDueAsc, Limit: 2}, []string{"shipped", "taxes"}},
+		{"title ascending", TaskListFilter{Sort: TaskSortTitleAsc, Offset: 3}, []string{"Ship release", "Submit taxes"}},
+	}
+	for _, tc := range cases {
+		got, err := repo.ListTasks(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: list: %v", tc.name, err)
+		}
+		gotIDs := ids(got)
+		if tc.filter.Sort == TaskSortTitleAsc {
+			gotIDs = gotIDs[:0]
+			for _, task := range got {
+				gotIDs = append(gotIDs, task.Title)
+			}
+		}
+		if strings.Join(gotIDs, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: got %v want %v", tc.name, gotIDs, tc.want)
+		}
+	}
+
+	if _, err := repo.ListTasks(ctx, TaskListFilter{Sort: "bogus"}); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("expected ErrInvalidFilter, got %v", err)
+	}
+}
+
+func TestSearchTasksRanksAndTracksChanges(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	seed := []Task{
+		{ID: "invoice", Title: "Send invoice", Description: "march invoice for the acme account", Tags: []string{"finance"}},
+		{ID: "notes", Title: "Meeting notes", Description: "mention the invoice backlog"},
+		{ID: "gym", Title: "Gym", Description: "leg day"},
+	}
+	for _, task := range seed {
+		task.State, task.Priority, task.Energy, task.CreatedAt = "Inbox", "Medium", "Light", now
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("create %s: %v", task.ID, err)
+		}
+	}
+
+	results, err := repo.SearchTasks(ctx, "invoice")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 || results[0].Task.ID != "invoice" || results[1].Task.ID != "notes" {
+		t.Fatalf("unexpected ranking: %#v", results)
+	}
+	if results[0].Rank <= results[1].Rank {
+		t.Fatalf("expected title hit to outrank description hit: %v <= %v", results[0].Rank, results[1].Rank)
+	}
+	if !strings.Contains(results[0].Snippet, "[invoice]") && !strings.Contains(results[0].Snippet, "[Invoice]") {
+		t.Fatalf("expected highlighted snippet, got %q", results[0].Snippet)
+	}
+	if len(results[0].Task.Tags) != 1 || results[0].Task.Tags[0] != "finance" {
+		t.Fatalf("expected tags on search result, got %#v", results[0].Task.Tags)
+	}
+
+	results, err = repo.SearchTasks(ctx, `ac "leg`)
+	if err != nil {
+		t.Fatalf("search with quotes: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected all terms to be required, got %#v", results)
+	}
+
+	gym, err := repo.GetTask(ctx, "gym")
+	if err != nil {
+		t.Fatalf("get gym: %v", err)
+	}
+	gym.Description = "pay the gym invoice"
+	if err := repo.UpdateTask(ctx, gym); err != nil {
+		t.Fatalf("update gym: %v", err)
+	}
+	if err := repo.DeleteTask(ctx, "notes"); err != nil {
+		t.Fatalf("delete notes: %v", err)
+	}
+	results, err = repo.SearchTasks(ctx, "invo")
+	if err != nil {
+		t.Fatalf("prefix search: %v", err)
+	}
+	got := make([]string, 0, len(results))
+	for _, r := range results {
+		got = append(got, r.Task.ID)
+	}
+	if strings.Join(got, ",") != "invoice,gym" {
+		t.Fatalf("expected index to follow update and delete, got %v", got)
+	}
+
+	if _, err := repo.SearchTasks(ctx, "NOT invoice OR"); err != nil {
+		t.Fatalf("expected operators to be treated as words: %v", err)
+	}
+	if results, err := repo.SearchTasks(ctx, "  ?! "); err != nil || len(results) != 0 {
+		t.Fatalf("expected empty result for blank query, got %#v, %v", results, err)
+	}
+}
+
+func TestWithTxCommitsAndRollsBack(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	next := now.Add(24 * time.Hour)
+	task := func(id string) Task {
+		return Task{ID: id, Title: "recurring " + id, State: "Planned", Priority: "Medium", Energy: "Light", CreatedAt: now}
+	}
+	reminder := func(id, taskID string) Reminder {
+		return Reminder{ID: id, TaskID: taskID, TriggerAt: next, Type: "Hard", Enabled: true, CreatedAt: now}
+	}
+	if err := repo.CreateTask(ctx, task("base")); err != nil {
+		t.Fatalf("create base: %v", err)
+	}
+	if err := repo.CreateReminder(ctx, reminder("rem-taken", "base")); err != nil {
+		t.Fatalf("create base reminder: %v", err)
+	}
+
+	// Each failure happens after some writes already succeeded in the tx.
+	injected := errors.New("injected failure")
+	failures := []struct {
+		name string
+		fn   func(Repository) error
+	}{
+		{"callback error", func(tx Repository) error {
+			if err := tx.CreateTask(ctx, task("next-1")); err != nil {
+				return err
+			}
+			if err := tx.CreateReminder(ctx, reminder("rem-1", "next-1")); err != nil {
+				return err
+			}
+			return injected
+		}},
+		{"statement error", func(tx Repository) error {
+			if err := tx.CreateTask(ctx, task("next-2")); err != nil {
+				return err
+			}
+			return tx.CreateReminder(ctx, reminder("rem-taken", "next-2"))
+		}},
+		{"nested error", func(tx Repository) error {
+			if err := tx.CreateTask(ctx, task("next-3")); err != nil {
+				return err
+			}
+			return tx.WithTx(ctx, func(inner Repository) error {
+				if err := inner.AttachTaskTag(ctx, "next-3", "chores"); err != nil {
+					return err
+				}
+				return injected
+			})
+		}},
+	}
+	for _, tc := range failures {
+		err := repo.WithTx(ctx, tc.fn)
+		if err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic to propagate")
+			}
+		}()
+		_ = repo.WithTx(ctx, func(tx Repository) error {
+			if err := tx.CreateTask(ctx, task("next-4")); err != nil {
+				return err
+			}
+			panic("crash midway")
+		})
+	}()
+
+	tasks, err := repo.ListTasks(ctx, TaskListFilter{})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "base" {
+		t.Fatalf("expected rolled back tasks to be absent, got %#v", tasks)
+	}
+	reminders, err := repo.ListReminders(ctx, ReminderListFilter{})
+	if err != nil {
+		t.Fatalf("list reminders: %v", err)
+	}
+	if len(reminders) != 1 {
+		t.Fatalf("expected rolled back reminders to be absent, got %#v", reminders)
+	}
+	if tags, err := repo.ListTags(ctx, TagListFilter{}); err != nil || len(tags) != 0 {
+		t.Fatalf("expected rolled back tags to be absent, got %#v, %v", tags, err)
+	}
+	if hits, err := repo.SearchTasks(ctx, "recurring next"); err != nil || len(hits) != 0 {
+		t.Fatalf("expected search index rolled back, got %#v, %v", hits, err)
+	}
+
+	err = repo.WithTx(ctx, func(tx Repository) error {
+		base, err := tx.GetTask(ctx, "base")
+		if err != nil {
+			return err
+		}
+		completed := now
+		base.State, base.CompletedAt = "Done", &completed
+		if err := tx.UpdateTask(ctx, base); err != nil {
+			return err
+		}
+		if err := tx.CreateTask(ctx, task("next-5")); err != nil {
+			return err
+		}
+		return tx.CreateReminder(ctx, reminder("rem-5", "next-5"))
+	})
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	base, err := repo.GetTask(ctx, "base")
+	if err != nil || base.State != "Done" {
+		t.Fatalf("expected committed completion, got %#v, %v", base, err)
+	}
+	if _, err := repo.GetReminder(ctx, "rem-5"); err != nil {
+		t.Fatalf("expected committed reminder: %v", err)
+	}
+}
+
+func TestTaskWritesEnforceModelValidation(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	valid := Task{ID: "task-v", Title: "Valid", State: "Planned", Priority: "Medium", Energy: "Light", CreatedAt: now}
+	if err := repo.CreateTask(ctx, valid); err != nil {
+		t.Fatalf("create valid task: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(*Task)
+		want   error
+	}{
+		{"done without completed_at", func(task *Task) { task.State = "Done" }, model.ErrCompletedAtRequired},
+		{"completed_at while open", func(task *Task) { task.CompletedAt = &now }, model.ErrCompletedAtNotDone},
+		{"unknown state", func(task *Task) { task.State = "Someday" }, model.ErrInvalidState},
+		{"blank title", func(task *Task) { task.Title = "  " }, model.ErrTaskTitleRequired},
+	}
+	for _, tc := range cases {
+		task := valid
+		tc.mutate(&task)
+		err := repo.UpdateTask(ctx, task)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || !errors.Is(err, tc.want) {
+			t.Fatalf("%s: update error = %v, want ValidationError wrapping %v", tc.name, err, tc.want)
+		}
+		if verr.Entity != "task" || verr.ID != "task-v" {
+			t.Fatalf("%s: unexpected validation error fields: %#v", tc.name, verr)
+		}
+
+		task.ID = "task-new"
+		if err := repo.CreateTask(ctx, task); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: create error = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	if _, err := repo.GetTask(ctx, "task-new"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected invalid task not to be inserted, got %v", err)
+	}
+	stored, err := repo.GetTask(ctx, "task-v")
+	if err != nil || stored.State != "Planned" || stored.Title != "Valid" {
+		t.Fatalf("expected stored task unchanged, got %#v, %v", stored, err)
+	}
+}
+
+func TestSchedulerStateSaveAndLoad(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+
+	initial, err := repo.GetSchedulerState(ctx)
+	if err != nil {
+		t.Fatalf("get initial state: %v", err)
+	}
+	if initial.LastTickAt != nil || initial.CheckpointCursor != "" || initial.UpdatedAt.IsZero() {
+		t.Fatalf("unexpected seeded state: %#v", initial)
+	}
+
+	tick := parseRFC3339(t, "2026-02-09T08:30:00.123456789Z")
+	updated := parseRFC3339(t, "2026-02-09T08:30:01Z")
+	if err := repo.SaveSchedulerState(ctx, SchedulerState{LastTickAt: &tick, CheckpointCursor: "rem-1", UpdatedAt: updated}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+	got, err := repo.GetSchedulerState(ctx)
+	if err != nil {
+		t.Fatalf("get state: %v", err)
+	}
+	if got.LastTickAt == nil || !got.LastTickAt.Equal(tick) || got.CheckpointCursor != "rem-1" || !got.UpdatedAt.Equal(updated) {
+		t.Fatalf("unexpected state after save: %#v", got)
+	}
+
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM scheduler_state`); err != nil {
+		t.Fatalf("clear state: %v", err)
+	}
+	if err := repo.SaveSchedulerState(ctx, SchedulerState{CheckpointCursor: "rem-2"}); err != nil {
+		t.Fatalf("save into empty table: %v", err)
+	}
+	got, err = repo.GetSchedulerState(ctx)
+	if err != nil || got.CheckpointCursor != "rem-2" || got.LastTickAt != nil {
+		t.Fatalf("unexpected state after re-insert: %#v, %v", got, err)
+	}
+}
+
+func TestChangeLogRecordsWritesFromEachProcess(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "taskd-shared.db")
+	open := func() *SQLiteRepository {
+		db, err := sql.Open("sqlite3", dbPath)
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+		if err := MigrateUp(db); err != nil {
+			t.Fatalf("migrate up: %v", err)
+		}
+		repo, err := NewSQLiteRepository(db)
+		if err != nil {
+			t.Fatalf("new repo: %v", err)
+		}
+		return repo
+	}
+	first, second := open(), open()
+	if first.Origin() == second.Origin() {
+		t.Fatalf("expected distinct origins, both %q", first.Origin())
+	}
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+
+	start, err := second.LatestChangeSeq(ctx)
+	if err != nil || start != 0 {
+		t.Fatalf("expected empty change log, got %d, %v", start, err)
+	}
+	task := Task{ID: "shared", Title: "shared task", State: "Planned", Priority: "Medium", Energy: "Light", CreatedAt: now}
+	if err := first.CreateTask(ctx, task); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := first.WithTx(ctx, func(tx Repository) error {
+		return tx.AttachTaskTag(ctx, "shared", "home")
+	}); err != nil {
+		t.Fatalf("attach tag: %v", err)
+	}
+	if err := second.DeleteTask(ctx, "shared"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := first.UpdateTask(ctx, task); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected failed update to be rejected, got %v", err)
+	}
+
+	changes, err := second.ChangesSince(ctx, start)
+	if err != nil {
+		t.Fatalf("changes since: %v", err)
+	}
+	// Attaching a tag is recorded as an update of the task it lands on.
+	want := []struct {
+		entity ChangeEntity
+		op     ChangeOp
+		origin string
+	}{
+		{ChangeTask, ChangeCreate, first.Origin()},
+		{ChangeTask, ChangeUpdate, first.Origin()},
+		{ChangeTask, ChangeDelete, second.Origin()},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i, w := range want {
+		got := changes[i]
+		if got.Entity != w.entity || got.Op != w.op || got.Origin != w.origin {
+			t.Fatalf("change %d: got %+v, want %+v", i, got, w)
+		}
+		if i > 0 && got.Seq <= changes[i-1].Seq {
+			t.Fatalf("expected increasing seq, got %+v", changes)
+		}
+	}
+	latest, err := first.LatestChangeSeq(ctx)
+	if err != nil || latest != changes[len(changes)-1].Seq {
+		t.Fatalf("expected latest seq %d, got %d, %v", changes[len(changes)-1].Seq, latest, err)
+	}
+	if rest, err := first.ChangesSince(ctx, latest); err != nil || len(rest) != 0 {
+		t.Fatalf("expected nothing after latest, got %+v, %v", rest, err)
+	}
+}
+
+func TestTaskEventsRecordEveryChangeWithItsSource(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	start := time.Now().Add(-time.Second)
+	cli := repo.WithSource(SourceCLI)
+	api := repo.WithSource(SourceAPI)
+
+	task := Task{ID: "t1", Title: "ship it", State: "Planned", Priority: "Medium", Energy: "Light", CreatedAt: now}
+	if err := cli.CreateTask(ctx, task); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	snoozeUntil := now.Add(2 * time.Hour)
+	if err := api.WithTx(ctx, func(tx Repository) error {
+		task.State = "Snoozed"
+		task.ScheduledAt = &snoozeUntil
+		task.Priority = "High"
+		return tx.UpdateTask(ctx, task)
+	}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := cli.AttachTaskTag(ctx, "t1", "Work"); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if err := repo.CreateReminder(ctx, Reminder{ID: "r1", TaskID: "t1", TriggerAt: snoozeUntil, Type: "Hard", Enabled: true, CreatedAt: now}); err != nil {
+		t.Fatalf("reminder: %v", err)
+	}
+	// Writes that fail roll their events back with them.
+	if err := api.WithTx(ctx, func(tx Repository) error {
+		task.Title = "never saved"
+		if err := tx.UpdateTask(ctx, task); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	}); err == nil {
+		t.Fatalf("expected the transaction to fail")
+	}
+	if err := cli.DeleteTask(ctx, "t1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	events, err := repo.ListTaskEvents(ctx, TaskEventFilter{TaskID: "t1"})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	type row struct {
+		field, old, new string
+		source          EventSource
+	}
+	want := []row{
+		{EventCreated, "", "ship it", SourceCLI},
+		{"priority", "Medium", "High", SourceAPI},
+		{"scheduled_at", "", "2026-02-09T14:00:00Z", SourceAPI},
+		{"state", "Planned", "Snoozed", SourceAPI},
+		{"tags", "", "Work", SourceCLI},
+		{EventReminder, "", "r1: Hard at 2026-02-09T14:00:00Z", SourceSystem},
+		{EventDeleted, "ship it", "", SourceCLI},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		got := row{events[i].Field, events[i].OldValue, events[i].NewValue, events[i].Source}
+		if got != w {
+			t.Fatalf("event %d = %+v, want %+v", i, got, w)
+		}
+		if events[i].OccurredAt.Before(start) {
+			t.Fatalf("event %d has a stale timestamp %v", i, events[i].OccurredAt)
+		}
+	}
+
+	later := time.Now().Add(time.Hour)
+	if none, err := repo.ListTaskEvents(ctx, TaskEventFilter{Occurred: TimeRange{From: &later}}); err != nil || len(none) != 0 {
+		t.Fatalf("expected no future events, got %+v, %v", none, err)
+	}
+	snoozes, err := repo.ListTaskEvents(ctx, TaskEventFilter{Field: "state", Occurred: TimeRange{From: &start, To: &later}})
+	if err != nil || len(snoozes) != 1 || snoozes[0].NewValue != "Snoozed" {
+		t.Fatalf("unexpected state events %+v, %v", snoozes, err)
+	}
+	newest, err := repo.ListTaskEvents(ctx, TaskEventFilter{TaskID: "t1", NewestFirst: true, Limit: 1})
+	if err != nil || len(newest) != 1 || newest[0].Field != EventDeleted {
+		t.Fatalf("unexpected newest event %+v, %v", newest, err)
+	}
+
+	if _, err := repo.db.ExecContext(ctx, `UPDATE task_events SET new_value = 'x'`); err == nil {
+		t.Fatalf("expected task_events updates to be rejected")
+	}
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM task_events`); err == nil {
+		t.Fatalf("expected task_events deletes to be rejected")
+	}
+}
+
+func TestCountSnoozesCountsEverySnoozeOfTheGivenTasks(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	for _, id := range []string{"t1", "t2", "t3"} {
+		if err := repo.CreateTask(ctx, Task{ID: id, Title: id, State: "Planned", Priority: "Medium", Energy: "Light", CreatedAt: now}); err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+	}
+	update := func(id, state string, at time.Time) {
+		t.Helper()
+		task, err := repo.GetTask(ctx, id)
+		if err != nil {
+			t.Fatalf("get %s: %v", id, err)
+		}
+		task.State, task.ScheduledAt = state, &at
+		if err := repo.UpdateTask(ctx, task); err != nil {
+			t.Fatalf("update %s: %v", id, err)
+		}
+	}
+	// t1 is snoozed, snoozed again while still snoozed, then woken up with a
+	// new time, which is not a snooze.
+	update("t1", "Snoozed", now.Add(time.Hour))
+	update("t1", "Snoozed", now.Add(2*time.Hour))
+	update("t1", "Planned", now.Add(3*time.Hour))
+	update("t1", "Planned", now.Add(4*time.Hour))
+	update("t2", "Planned", now.Add(time.Hour))
+	update("t3", "Snoozed", now.Add(time.Hour))
+
+	counts, err := repo.CountSnoozes(ctx, []string{"t1", "t2"})
+	if err != nil {
+		t.Fatalf("count snoozes: %v", err)
+	}
+	if len(counts) != 1 || counts["t1"] != 2 {
+		t.Fatalf("expected only t1 with two snoozes, got %v", counts)
+	}
+	if none, err := repo.CountSnoozes(ctx, nil); err != nil || len(none) != 0 {
+		t.Fatalf("expected no counts without tasks, got %v, %v", none, err)
+	}
+}
+
+func TestLastChangedAtOutlivesChangeLogAndDeletes(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	start := time.Now().Add(-time.Second)
+	for _, id := range []string{"t1", "t2", "t3"} {
+		if err := repo.CreateTask(ctx, Task{ID: id, Title: id, State: "Inbox", Priority: "Medium", Energy: "Light", CreatedAt: now}); err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+	}
+	if err := repo.DeleteTask(ctx, "t2"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	// Trimming the change log must not lose when rows were written.
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM change_log`); err != nil {
+		t.Fatalf("trim change log: %v", err)
+	}
+
+	got, err := repo.LastChangedAt(ctx, ChangeTask, []string{"t1", "t2", "missing"})
+	if err != nil {
+		t.Fatalf("last changed: %v", err)
+	}
+	if len(got) != 2 || got["t1"].Before(start) || got["t2"].Before(got["t1"]) {
+		t.Fatalf("unexpected change times %v", got)
+	}
+	if other, err := repo.LastChangedAt(ctx, ChangeReminder, []string{"t1"}); err != nil || len(other) != 0 {
+		t.Fatalf("expected no reminder changes, got %v, %v", other, err)
+	}
+}
+
+func TestDeleteTaskLogsCascadedRows(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+	now := parseRFC3339(t, "2026-02-09T12:00:00Z")
+	if err := repo.CreateTask(ctx, Task{ID: "t1", Title: "water plants", State: "Planned", Priority: "Medium", Energy: "Light", CreatedAt: now}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := repo.CreateReminder(ctx, Reminder{ID: "m1", TaskID: "t1", TriggerAt: now, Type: "Soft", Enabled: true, CreatedAt: now}); err != nil {
+		t.Fatalf("create reminder: %v", err)
+	}
+	if err := repo.CreateRecurrence(ctx, RecurrenceRule{ID: "r1", TaskID: "t1", RuleType: "every_n_days", IntervalValue: 1, Timezone: "UTC", StartAt: now, Enabled: true, CreatedAt: now}); err != nil {
+		t.Fatalf("create recurrence: %v", err)
+	}
+	start, err := repo.LatestChangeSeq(ctx)
+	if err != nil {
+		t.Fatalf("latest seq: %v", err)
+	}
+	if err := repo.DeleteTask(ctx, "t1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	changes, err := repo.ChangesSince(ctx, start)
+	if err != nil {
+		t.Fatalf("changes since: %v", err)
+	}
+	deleted := make(map[ChangeEntity]string, len(changes))
+	for _, c := range changes {
+		if c.Op != ChangeDelete {
+			t.Fatalf("expected only deletes, got %+v", c)
+		}
+		deleted[c.Entity] = c.EntityID
+	}
+	if len(changes) != 3 || deleted[ChangeTask] != "t1" || deleted[ChangeReminder] != "m1" || deleted[ChangeRecurrence] != "r1" {
+		t.Fatalf("expected the task, its reminder and its rule deleted, got %+v", changes)
+	}
+	for entity, id := range deleted {
+		if got, err := repo.LastChangedAt(ctx, entity, []string{id}); err != nil || len(got) != 1 {
+			t.Fatalf("expected a change time for %s %s, got %v, %v", entity, id, got, err)
+		}
+	}
+}
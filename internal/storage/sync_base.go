@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// SyncBase is the copy of a record as it was when last synced with Target.
+// Doc is opaque to storage.
+type SyncBase struct {
+	Target   string
+	Entity   ChangeEntity
+	EntityID string
+	Doc      []byte
+}
+
+// ListSyncBase returns every base record kept for target.
+func (r *SQLiteRepository) ListSyncBase(ctx context.Context, target string) ([]SyncBase, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT target, entity, entity_id, doc
+		FROM sync_base WHERE target = ? ORDER BY entity, entity_id`, target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]SyncBase, 0)
+	for rows.Next() {
+		var b SyncBase
+		var doc string
+		if err := rows.Scan(&b.Target, &b.Entity, &b.EntityID, &doc); err != nil {
+			return nil, err
+		}
+		b.Doc = []byte(doc)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// SaveSyncBase inserts or replaces a base record. It is bookkeeping, not a
+// data change, so it is not written to the change log.
+func (r *SQLiteRepository) SaveSyncBase(ctx context.Context, in SyncBase) error {
+	if in.Target == "" || in.Entity == "" || in.EntityID == "" {
+		return errors.New("storage: sync base needs a target, entity and id")
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO sync_base (target, entity, entity_id, doc)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (target, entity, entity_id) DO UPDATE SET doc = excluded.doc`,
+		in.Target, in.Entity, in.EntityID, string(in.Doc),
+	)
+	return err
+}
@@ -0,0 +1,270 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// fields is a record's values by field name, each compact JSON.
+type fields map[string]json.RawMessage
+
+// entity adapts one kind of row to fields. Entities are synced in order, so
+// tasks come before the reminders and recurrences that reference them. Tags
+// travel as names on their tasks; the tags table follows from those.
+type entity struct {
+	kind storage.ChangeEntity
+	dir  string
+	list func(ctx context.Context, repo storage.Repository) (map[string]fields, error)
+	// put creates the row when exists is false and replaces it otherwise.
+	put func(ctx context.Context, repo storage.Repository, id string, in fields, exists bool) error
+	del func(ctx context.Context, repo storage.Repository, id string) error
+}
+
+var entities = []entity{
+	{kind: storage.ChangeTask, dir: "tasks", list: listTasks, put: putTask, del: deleteTask},
+	{kind: storage.ChangeRecurrence, dir: "recurrences", list: listRecurrences, put: putRecurrence, del: deleteRecurrence},
+	{kind: storage.ChangeReminder, dir: "reminders", list: listReminders, put: putReminder, del: deleteReminder},
+}
+
+type taskFields struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"`
+	Priority    string     `json:"priority"`
+	Energy      string     `json:"energy"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+	DueAt       *time.Time `json:"due_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Tags        []string   `json:"tags"`
+}
+
+type reminderFields struct {
+	TaskID     string     `json:"task_id"`
+	TriggerAt  time.Time  `json:"trigger_at"`
+	Type       string     `json:"type"`
+	RepeatRule string     `json:"repeat_rule"`
+	LastFired  *time.Time `json:"last_fired"`
+	Enabled    bool       `json:"enabled"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type recurrenceFields struct {
+	TaskID        string     `json:"task_id"`
+	RuleType      string     `json:"rule_type"`
+	IntervalValue int        `json:"interval"`
+	Timezone      string     `json:"timezone"`
+	StartAt       time.Time  `json:"start_at"`
+	NextAt        *time.Time `json:"next_at"`
+	Enabled       bool       `json:"enabled"`
+	CreatedAt     time.Time  `json:"created_at"`
+	// Files written before these fields existed lack them; merge then keeps
+	// the local value.
+	Weekdays        []time.Weekday    `json:"weekdays"`
+	AfterCompleteIn time.Duration     `json:"after_complete_in"`
+	RRule           string            `json:"rrule"`
+	Until           *time.Time        `json:"until"`
+	Count           int               `json:"count"`
+	Exceptions      []exceptionFields `json:"exceptions"`
+}
+
+type exceptionFields struct {
+	Kind string     `json:"kind"`
+	At   time.Time  `json:"at"`
+	To   *time.Time `json:"to"`
+}
+
+func listTasks(ctx context.Context, repo storage.Repository) (map[string]fields, error) {
+	tasks, err := repo.ListTasks(ctx, storage.TaskListFilter{Sort: storage.TaskSortCreatedAsc})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]fields, len(tasks))
+	for _, t := range tasks {
+		tags := append([]string{}, t.Tags...)
+		sort.Strings(tags)
+		f, err := toFields(taskFields{
+			Title: t.Title, Description: t.Description, State: t.State,
+			Priority: t.Priority, Energy: t.Energy,
+			ScheduledAt: utcPtr(t.ScheduledAt), DueAt: utcPtr(t.DueAt),
+			CreatedAt: t.CreatedAt.UTC(), CompletedAt: utcPtr(t.CompletedAt),
+			Tags: tags,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out[t.ID] = f
+	}
+	return out, nil
+}
+
+func putTask(ctx context.Context, repo storage.Repository, id string, in fields, exists bool) error {
+	var f taskFields
+	if err := fromFields(in, &f); err != nil {
+		return err
+	}
+	task := storage.Task{
+		ID: id, Title: f.Title, Description: f.Description, State: f.State,
+		Priority: f.Priority, Energy: f.Energy,
+		ScheduledAt: f.ScheduledAt, DueAt: f.DueAt,
+		CreatedAt: f.CreatedAt, CompletedAt: f.CompletedAt,
+		Tags: append([]string{}, f.Tags...),
+	}
+	if exists {
+		return repo.UpdateTask(ctx, task)
+	}
+	return repo.CreateTask(ctx, task)
+}
+
+func deleteTask(ctx context.Context, repo storage.Repository, id string) error {
+	return ignoreNotFound(repo.DeleteTask(ctx, id))
+}
+
+func listReminders(ctx context.Context, repo storage.Repository) (map[string]fields, error) {
+	reminders, err := repo.ListReminders(ctx, storage.ReminderListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]fields, len(reminders))
+	for _, r := range reminders {
+		f, err := toFields(reminderFields{
+			TaskID: r.TaskID, TriggerAt: r.TriggerAt.UTC(), Type: r.Type,
+			RepeatRule: r.RepeatRule, LastFired: utcPtr(r.LastFired),
+			Enabled: r.Enabled, CreatedAt: r.CreatedAt.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		out[r.ID] = f
+	}
+	return out, nil
+}
+
+func putReminder(ctx context.Context, repo storage.Repository, id string, in fields, exists bool) error {
+	var f reminderFields
+	if err := fromFields(in, &f); err != nil {
+		return err
+	}
+	rem := storage.Reminder{
+		ID: id, TaskID: f.TaskID, TriggerAt: f.TriggerAt, Type: f.Type,
+		RepeatRule: f.RepeatRule, LastFired: f.LastFired,
+		Enabled: f.Enabled, CreatedAt: f.CreatedAt,
+	}
+	if exists {
+		return repo.UpdateReminder(ctx, rem)
+	}
+	return repo.CreateReminder(ctx, rem)
+}
+
+func deleteReminder(ctx context.Context, repo storage.Repository, id string) error {
+	return ignoreNotFound(repo.DeleteReminder(ctx, id))
+}
+
+func listRecurrences(ctx context.Context, repo storage.Repository) (map[string]fields, error) {
+	rules, err := repo.ListRecurrences(ctx, storage.RecurrenceListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]fields, len(rules))
+	for _, r := range rules {
+		// An empty list, not null, so that clearing exceptions syncs.
+		exceptions := make([]exceptionFields, 0, len(r.Exceptions))
+		for _, ex := range r.Exceptions {
+			exceptions = append(exceptions, exceptionFields{Kind: ex.Kind, At: ex.At.UTC(), To: utcPtr(ex.To)})
+		}
+		f, err := toFields(recurrenceFields{
+			TaskID: r.TaskID, RuleType: r.RuleType, IntervalValue: r.IntervalValue,
+			Timezone: r.Timezone, StartAt: r.StartAt.UTC(), NextAt: utcPtr(r.NextAt),
+			Enabled: r.Enabled, CreatedAt: r.CreatedAt.UTC(),
+			Weekdays: r.Weekdays, AfterCompleteIn: r.AfterCompleteIn, RRule: r.RRule,
+			Until: utcPtr(r.Until), Count: r.Count, Exceptions: exceptions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out[r.ID] = f
+	}
+	return out, nil
+}
+
+func putRecurrence(ctx context.Context, repo storage.Repository, id string, in fields, exists bool) error {
+	var f recurrenceFields
+	if err := fromFields(in, &f); err != nil {
+		return err
+	}
+	rule := storage.RecurrenceRule{
+		ID: id, TaskID: f.TaskID, RuleType: f.RuleType, IntervalValue: f.IntervalValue,
+		Timezone: f.Timezone, StartAt: f.StartAt, NextAt: f.NextAt,
+		Enabled: f.Enabled, CreatedAt: f.CreatedAt,
+		Weekdays: f.Weekdays, AfterCompleteIn: f.AfterCompleteIn, RRule: f.RRule,
+		Until: f.Until, Count: f.Count,
+	}
+	if f.Exceptions != nil {
+		rule.Exceptions = make([]storage.RecurrenceException, 0, len(f.Exceptions))
+		for _, ex := range f.Exceptions {
+			rule.Exceptions = append(rule.Exceptions, storage.RecurrenceException{Kind: ex.Kind, At: ex.At, To: ex.To})
+		}
+	}
+	if exists {
+		return repo.UpdateRecurrence(ctx, rule)
+	}
+	return repo.CreateRecurrence(ctx, rule)
+}
+
+func deleteRecurrence(ctx context.Context, repo storage.Repository, id string) error {
+	return ignoreNotFound(repo.DeleteRecurrence(ctx, id))
+}
+
+// toFields splits a tagged struct into its JSON fields.
+func toFields(v any) (fields, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out fields
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func fromFields(in fields, v any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func sameFields(a, b fields) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, v := range a {
+		w, ok := b[name]
+		if !ok || !sameValue(v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.UTC()
+	return &v
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// ErrInvalidRecord marks a file in the sync directory that cannot be used.
+var ErrInvalidRecord = errors.New("syncer: invalid record")
+
+// Dir syncs with a directory holding one JSON file per record, laid out as
+// tasks/<id>.json, recurrences/<id>.json and reminders/<id>.json. Sharing the
+// directory through git or Syncthing lets several machines converge.
+type Dir struct {
+	repo  storage.Repository
+	path  string
+	clock clock.Clock
+}
+
+// NewDir returns a Syncer for the directory at path. A nil clock means the
+// system clock.
+func NewDir(repo storage.Repository, path string, c clock.Clock) *Dir {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return &Dir{repo: repo.WithSource(storage.SourceSync), path: path, clock: clock.OrReal(c)}
+}
+
+func (d *Dir) Target() string {
+	return "dir:" + d.path
+}
+
+func (d *Dir) Sync(ctx context.Context, progress func(Progress)) (Result, error) {
+	var res Result
+	bases, err := d.loadBases(ctx)
+	if err != nil {
+		return res, err
+	}
+	for _, e := range entities {
+		dir := filepath.Join(d.path, e.dir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return res, fmt.Errorf("syncer: %w", err)
+		}
+		local, err := e.list(ctx, d.repo)
+		if err != nil {
+			return res, fmt.Errorf("syncer: list %ss: %w", e.kind, err)
+		}
+		remote, err := readRecords(dir, e.kind, &res)
+		if err != nil {
+			return res, err
+		}
+
+		ids := unionIDs(local, remote, bases[e.kind])
+		changedAt, err := d.repo.LastChangedAt(ctx, e.kind, ids)
+		if err != nil {
+			return res, fmt.Errorf("syncer: read %s change times: %w", e.kind, err)
+		}
+		for i, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return res, err
+			}
+			at, ok := changedAt[id]
+			if !ok {
+				at = d.clock.Now().UTC()
+			}
+			if err := d.syncRecord(ctx, e, id, bases[e.kind][id], local[id], remote[id], at, &res); err != nil {
+				res.Errors = append(res.Errors, fmt.Errorf("%s %s: %w", e.kind, id, err))
+			}
+			if progress != nil {
+				progress(Progress{Entity: e.kind, Done: i + 1, Total: len(ids)})
+			}
+		}
+	}
+	return res, nil
+}
+
+// syncRecord merges one record and writes the result to the directory, the
+// database and the base. The file goes first: if the database write then
+// fails, the next sync sees the merged file as a remote edit and retries.
+func (d *Dir) syncRecord(ctx context.Context, e entity, id string, base *Record, local fields, remote *Record, changedAt time.Time, res *Result) error {
+	if !validID(id) {
+		return fmt.Errorf("%w: id %q", ErrInvalidRecord, id)
+	}
+	merged, conflicts := merge(base, localRecord(e.kind, id, base, local, changedAt), remote)
+	res.Conflicts = append(res.Conflicts, conflicts...)
+	unchanged := base != nil && sameRecord(&merged, base) && sameRecord(&merged, remote)
+	if unchanged && (local == nil) == (merged.DeletedAt != nil) {
+		return nil
+	}
+
+	if remote == nil || !sameRecord(&merged, remote) {
+		if err := writeRecord(filepath.Join(d.path, e.dir, id+".json"), &merged); err != nil {
+			return err
+		}
+		res.Pushed++
+	}
+
+	return d.repo.WithTx(ctx, func(tx storage.Repository) error {
+		switch {
+		case merged.DeletedAt != nil && local != nil:
+			if err := e.del(ctx, tx, id); err != nil {
+				return err
+			}
+			res.Pulled++
+		case merged.DeletedAt == nil:
+			values := make(fields, len(merged.Fields))
+			for name, f := range merged.Fields {
+				values[name] = f.Value
+			}
+			if local == nil || !sameFields(local, values) {
+				if err := e.put(ctx, tx, id, values, local != nil); err != nil {
+					return err
+				}
+				res.Pulled++
+			}
+		}
+		doc, err := encodeRecord(&merged)
+		if err != nil {
+			return err
+		}
+		return tx.SaveSyncBase(ctx, storage.SyncBase{Target: d.Target(), Entity: e.kind, EntityID: id, Doc: doc})
+	})
+}
+
+// localRecord describes the database row as a Record. Fields that still match
+// the base keep the base's timestamp; the rest are stamped with changedAt,
+// the row's last write. A row missing since the base was taken was deleted.
+func localRecord(kind storage.ChangeEntity, id string, base *Record, local fields, changedAt time.Time) *Record {
+	if local == nil {
+		if base == nil {
+			return nil
+		}
+		rec := *base
+		if rec.DeletedAt == nil {
+			rec.DeletedAt = &changedAt
+		}
+		return &rec
+	}
+	rec := &Record{Schema: recordSchema, Entity: kind, ID: id, Fields: make(map[string]Field, len(local))}
+	for name, v := range local {
+		if base != nil && base.DeletedAt == nil {
+			if b, ok := base.Fields[name]; ok && sameValue(b.Value, v) {
+				rec.Fields[name] = b
+				continue
+			}
+		}
+		rec.Fields[name] = Field{Value: v, At: changedAt}
+	}
+	return rec
+}
+
+func (d *Dir) loadBases(ctx context.Context) (map[storage.ChangeEntity]map[string]*Record, error) {
+	rows, err := d.repo.ListSyncBase(ctx, d.Target())
+	if err != nil {
+		return nil, fmt.Errorf("syncer: load base: %w", err)
+	}
+	out := make(map[storage.ChangeEntity]map[string]*Record)
+	for _, row := range rows {
+		rec, err := decodeRecord(row.Doc)
+		if err != nil {
+			return nil, fmt.Errorf("syncer: base %s %s: %w", row.Entity, row.EntityID, err)
+		}
+		if out[row.Entity] == nil {
+			out[row.Entity] = make(map[string]*Record)
+		}
+		out[row.Entity][row.EntityID] = rec
+	}
+	return out, nil
+}
+
+// readRecords loads every record file in dir. Files that do not decode are
+// reported in res and otherwise ignored.
+func readRecords(dir string, kind storage.ChangeEntity, res *Result) (map[string]*Record, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("syncer: %w", err)
+	}
+	out := make(map[string]*Record, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("syncer: %w", err)
+		}
+		rec, err := decodeRecord(data)
+		id := strings.TrimSuffix(name, ".json")
+		switch {
+		case err != nil:
+			err = fmt.Errorf("%w: %s: %v", ErrInvalidRecord, path, err)
+		case rec.ID != id || rec.Entity != kind:
+			err = fmt.Errorf("%w: %s holds %s %q", ErrInvalidRecord, path, rec.Entity, rec.ID)
+		}
+		if err != nil {
+			res.Errors = append(res.Errors, err)
+			continue
+		}
+		out[id] = rec
+	}
+	return out, nil
+}
+
+// writeRecord replaces path atomically so a folder syncer never ships half a
+// file.
+func writeRecord(path string, rec *Record) error {
+	data, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func unionIDs(local map[string]fields, remote map[string]*Record, base map[string]*Record) []string {
+	seen := make(map[string]bool)
+	for id := range local {
+		seen[id] = true
+	}
+	for id := range remote {
+		seen[id] = true
+	}
+	for id := range base {
+		seen[id] = true
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func validID(id string) bool {
+	return id != "" && !strings.HasPrefix(id, ".") && !strings.ContainsAny(id, `/\`)
+}
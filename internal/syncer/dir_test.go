@@ -0,0 +1,200 @@
+package syncer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func setupReplica(t *testing.T, name string) *storage.SQLiteRepository {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo, err := storage.NewSQLiteRepository(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	return repo
+}
+
+func mustSync(t *testing.T, s Syncer) Result {
+	t.Helper()
+	res, err := s.Sync(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("sync %s: %v", s.Target(), err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("sync %s: record errors %v", s.Target(), res.Errors)
+	}
+	return res
+}
+
+func editTask(t *testing.T, repo storage.Repository, id string, fn func(*storage.Task)) {
+	t.Helper()
+	ctx := context.Background()
+	task, err := repo.GetTask(ctx, id)
+	if err != nil {
+		t.Fatalf("get %s: %v", id, err)
+	}
+	task.Tags = nil
+	fn(&task)
+	if err := repo.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("update %s: %v", id, err)
+	}
+}
+
+func TestDirSyncConvergesTwoReplicas(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	a, b := setupReplica(t, "a"), setupReplica(t, "b")
+	syncA, syncB := NewDir(a, dir, nil), NewDir(b, dir, nil)
+	now := time.Date(2026, 2, 11, 9, 0, 0, 0, time.UTC)
+
+	due := now.Add(48 * time.Hour)
+	if err := a.CreateTask(ctx, storage.Task{ID: "t1", Title: "renew passport", State: "Planned", Priority: "Medium", Energy: "Light", DueAt: &due, CreatedAt: now, Tags: []string{"errands", "admin"}}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := a.CreateReminder(ctx, storage.Reminder{ID: "r1", TaskID: "t1", TriggerAt: now.Add(time.Hour), Type: "Hard", Enabled: true, CreatedAt: now}); err != nil {
+		t.Fatalf("create reminder: %v", err)
+	}
+
+	var seen []Progress
+	res, err := syncA.Sync(ctx, func(p Progress) { seen = append(seen, p) })
+	if err != nil || res.Pushed != 2 || res.Pulled != 0 {
+		t.Fatalf("first push: %+v, %v", res, err)
+	}
+	wantProgress := []Progress{{storage.ChangeTask, 1, 1}, {storage.ChangeReminder, 1, 1}}
+	if !reflect.DeepEqual(seen, wantProgress) {
+		t.Fatalf("progress = %+v", seen)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tasks", "t1.json")); err != nil {
+		t.Fatalf("expected a task file: %v", err)
+	}
+	if res := mustSync(t, syncB); res.Pulled != 2 || res.Pushed != 0 {
+		t.Fatalf("first pull: %+v", res)
+	}
+	got, err := b.GetTask(ctx, "t1")
+	if err != nil || got.Title != "renew passport" || got.DueAt == nil || !got.DueAt.Equal(due) || !reflect.DeepEqual(got.Tags, []string{"admin", "errands"}) {
+		t.Fatalf("pulled task %+v, %v", got, err)
+	}
+	if res := mustSync(t, syncA); res.Pushed != 0 || res.Pulled != 0 {
+		t.Fatalf("expected nothing to do, got %+v", res)
+	}
+
+	// Edits to different fields merge without conflict.
+	editTask(t, a, "t1", func(task *storage.Task) { task.Title = "renew passport today" })
+	editTask(t, b, "t1", func(task *storage.Task) { task.Priority = "High" })
+	mustSync(t, syncA)
+	if res := mustSync(t, syncB); len(res.Conflicts) != 0 {
+		t.Fatalf("unexpected conflicts %+v", res.Conflicts)
+	}
+	mustSync(t, syncA)
+	for _, repo := range []storage.Repository{a, b} {
+		task, _ := repo.GetTask(ctx, "t1")
+		if task.Title != "renew passport today" || task.Priority != "High" {
+			t.Fatalf("replicas did not converge: %+v", task)
+		}
+	}
+
+	// Both edit the description; b's later edit wins on both replicas.
+	editTask(t, a, "t1", func(task *storage.Task) { task.Description = "from a" })
+	editTask(t, b, "t1", func(task *storage.Task) { task.Description = "from b" })
+	mustSync(t, syncA)
+	res = mustSync(t, syncB)
+	want := []Conflict{{Entity: storage.ChangeTask, ID: "t1", Field: "description", Winner: Local}}
+	if !reflect.DeepEqual(res.Conflicts, want) {
+		t.Fatalf("conflicts = %+v, want %+v", res.Conflicts, want)
+	}
+	if res := mustSync(t, syncA); len(res.Conflicts) != 0 || res.Pulled != 1 {
+		t.Fatalf("expected a clean pull, got %+v", res)
+	}
+	if task, _ := a.GetTask(ctx, "t1"); task.Description != "from b" {
+		t.Fatalf("expected b's description on a, got %q", task.Description)
+	}
+
+	// Deleting the task on b removes it, and its reminder, from a.
+	if err := b.DeleteTask(ctx, "t1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	mustSync(t, syncB)
+	mustSync(t, syncA)
+	if _, err := a.GetTask(ctx, "t1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected t1 deleted on a, got %v", err)
+	}
+	if _, err := a.GetReminder(ctx, "r1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected r1 deleted on a, got %v", err)
+	}
+}
+
+func TestDirSyncCarriesRecurrenceExceptions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	a, b := setupReplica(t, "a"), setupReplica(t, "b")
+	syncA, syncB := NewDir(a, dir, nil), NewDir(b, dir, nil)
+	now := time.Date(2026, 2, 11, 9, 0, 0, 0, time.UTC)
+
+	if err := a.CreateTask(ctx, storage.Task{ID: "t1", Title: "swim", State: "Planned", Priority: "Low", Energy: "Light", ScheduledAt: &now, CreatedAt: now}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	until := now.AddDate(0, 3, 0)
+	back := now.AddDate(0, 0, 14)
+	rule := storage.RecurrenceRule{
+		ID: "rec1", TaskID: "t1", RuleType: "every_n_days", IntervalValue: 2, Timezone: "UTC",
+		StartAt: now, Enabled: true, CreatedAt: now, Until: &until,
+		Exceptions: []storage.RecurrenceException{
+			{Kind: "skip", At: now.AddDate(0, 0, 2)},
+			{Kind: "pause", At: now.AddDate(0, 0, 7), To: &back},
+		},
+	}
+	if err := a.CreateRecurrence(ctx, rule); err != nil {
+		t.Fatalf("create recurrence: %v", err)
+	}
+	mustSync(t, syncA)
+	mustSync(t, syncB)
+	got, err := b.GetRecurrence(ctx, "rec1")
+	if err != nil || got.Until == nil || !got.Until.Equal(until) || len(got.Exceptions) != 2 || got.Exceptions[1].To == nil || !got.Exceptions[1].To.Equal(back) {
+		t.Fatalf("pulled recurrence %+v, %v", got, err)
+	}
+
+	// Clearing the exceptions on b clears them on a.
+	got.Exceptions = []storage.RecurrenceException{}
+	if err := b.UpdateRecurrence(ctx, got); err != nil {
+		t.Fatalf("update recurrence: %v", err)
+	}
+	mustSync(t, syncB)
+	mustSync(t, syncA)
+	if got, _ := a.GetRecurrence(ctx, "rec1"); len(got.Exceptions) != 0 {
+		t.Fatalf("expected exceptions cleared on a, got %+v", got.Exceptions)
+	}
+}
+
+func TestDirSyncReportsBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	repo := setupReplica(t, "a")
+	if err := os.MkdirAll(filepath.Join(dir, "tasks"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tasks", "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	res, err := NewDir(repo, dir, nil).Sync(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(res.Errors) != 1 || !errors.Is(res.Errors[0], ErrInvalidRecord) {
+		t.Fatalf("expected one invalid record, got %v", res.Errors)
+	}
+}
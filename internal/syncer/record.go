@@ -0,0 +1,189 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// recordSchema versions the on-disk record format.
+const recordSchema = "taskd-sync/v1"
+
+// Record is one synced row. A deleted record keeps its last fields so an
+// edit made elsewhere after the delete can bring it back.
+type Record struct {
+	Schema    string               `json:"schema"`
+	Entity    storage.ChangeEntity `json:"entity"`
+	ID        string               `json:"id"`
+	DeletedAt *time.Time           `json:"deleted_at,omitempty"`
+	Fields    map[string]Field     `json:"fields"`
+}
+
+// Field is a value and the time it was last changed.
+type Field struct {
+	Value json.RawMessage `json:"value"`
+	At    time.Time       `json:"at"`
+}
+
+func decodeRecord(data []byte) (*Record, error) {
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	// Values are compared byte for byte, so drop whatever layout they had.
+	for name, f := range rec.Fields {
+		f.Value = compact(f.Value)
+		rec.Fields[name] = f
+	}
+	if rec.Fields == nil {
+		rec.Fields = map[string]Field{}
+	}
+	return &rec, nil
+}
+
+func encodeRecord(rec *Record) ([]byte, error) {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func sameRecord(a, b *Record) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ea, errA := encodeRecord(a)
+	eb, errB := encodeRecord(b)
+	return errA == nil && errB == nil && bytes.Equal(ea, eb)
+}
+
+func compact(v json.RawMessage) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return v
+	}
+	return buf.Bytes()
+}
+
+func sameValue(a, b json.RawMessage) bool {
+	return bytes.Equal(compact(a), compact(b))
+}
+
+// merge combines local and remote against base, the last version both sides
+// agreed on; base may be nil for a record neither side has synced yet.
+func merge(base, local, remote *Record) (Record, []Conflict) {
+	switch {
+	case local == nil:
+		return *remote, nil
+	case remote == nil:
+		return *local, nil
+	}
+
+	out := Record{Schema: recordSchema, Entity: local.Entity, ID: local.ID, Fields: map[string]Field{}}
+	var conflicts []Conflict
+	var lastLocal, lastRemote time.Time
+	for _, name := range fieldNames(local, remote) {
+		var b Field
+		hasBase := false
+		if base != nil {
+			b, hasBase = base.Fields[name]
+		}
+		l, lok := local.Fields[name]
+		r, rok := remote.Fields[name]
+		lChanged := lok && (!hasBase || !sameValue(l.Value, b.Value))
+		rChanged := rok && (!hasBase || !sameValue(r.Value, b.Value))
+		if lChanged && l.At.After(lastLocal) {
+			lastLocal = l.At
+		}
+		if rChanged && r.At.After(lastRemote) {
+			lastRemote = r.At
+		}
+
+		switch {
+		case lChanged && rChanged && !sameValue(l.Value, r.Value):
+			winner := newer(l, r)
+			conflicts = append(conflicts, Conflict{Entity: out.Entity, ID: out.ID, Field: name, Winner: winner})
+			out.Fields[name] = pick(winner, l, r)
+		case lChanged && rChanged:
+			out.Fields[name] = pick(newer(l, r), l, r)
+		case lChanged || !rok:
+			out.Fields[name] = l
+		default:
+			out.Fields[name] = r
+		}
+	}
+
+	// A delete loses to an edit made after it on the other side.
+	resolveDelete := func(deletedAt *time.Time, editedAt time.Time, deleter Side) {
+		if editedAt.After(*deletedAt) {
+			conflicts = append(conflicts, Conflict{Entity: out.Entity, ID: out.ID, Field: "deleted", Winner: other(deleter)})
+			return
+		}
+		if !editedAt.IsZero() {
+			conflicts = append(conflicts, Conflict{Entity: out.Entity, ID: out.ID, Field: "deleted", Winner: deleter})
+		}
+		at := *deletedAt
+		out.DeletedAt = &at
+	}
+	switch {
+	case local.DeletedAt != nil && remote.DeletedAt != nil:
+		at := *local.DeletedAt
+		if remote.DeletedAt.After(at) {
+			at = *remote.DeletedAt
+		}
+		out.DeletedAt = &at
+	case local.DeletedAt != nil:
+		resolveDelete(local.DeletedAt, lastRemote, Local)
+	case remote.DeletedAt != nil:
+		resolveDelete(remote.DeletedAt, lastLocal, Remote)
+	}
+	return out, conflicts
+}
+
+func fieldNames(records ...*Record) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, rec := range records {
+		for name := range rec.Fields {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newer picks the later edit. Ties go to the larger value so every replica
+// settles on the same answer.
+func newer(l, r Field) Side {
+	switch {
+	case l.At.After(r.At):
+		return Local
+	case r.At.After(l.At):
+		return Remote
+	case bytes.Compare(compact(l.Value), compact(r.Value)) > 0:
+		return Local
+	default:
+		return Remote
+	}
+}
+
+func pick(side Side, l, r Field) Field {
+	if side == Local {
+		return l
+	}
+	return r
+}
+
+func other(side Side) Side {
+	if side == Local {
+		return Remote
+	}
+	return Local
+}
@@ -0,0 +1,170 @@
+package syncer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+var t0 = time.Date(2026, 2, 11, 9, 0, 0, 0, time.UTC)
+
+// rec builds a task record from name=value pairs stamped at minute offsets.
+func rec(deletedAt *int, pairs ...any) *Record {
+	r := &Record{Schema: recordSchema, Entity: storage.ChangeTask, ID: "t1", Fields: map[string]Field{}}
+	for i := 0; i < len(pairs); i += 3 {
+		value, _ := json.Marshal(pairs[i+1])
+		r.Fields[pairs[i].(string)] = Field{Value: value, At: t0.Add(time.Duration(pairs[i+2].(int)) * time.Minute)}
+	}
+	if deletedAt != nil {
+		at := t0.Add(time.Duration(*deletedAt) * time.Minute)
+		r.DeletedAt = &at
+	}
+	return r
+}
+
+func minute(n int) *int { return &n }
+
+func TestMergeThreeWay(t *testing.T) {
+	base := rec(nil, "title", "plan", 0, "priority", "Low", 0)
+	cases := []struct {
+		name      string
+		base      *Record
+		local     *Record
+		remote    *Record
+		want      *Record
+		conflicts []Conflict
+	}{
+		{
+			name:   "unchanged",
+			base:   base,
+			local:  rec(nil, "title", "plan", 0, "priority", "Low", 0),
+			remote: rec(nil, "title", "plan", 0, "priority", "Low", 0),
+			want:   rec(nil, "title", "plan", 0, "priority", "Low", 0),
+		},
+		{
+			name:   "different fields on each side",
+			base:   base,
+			local:  rec(nil, "title", "plan trip", 5, "priority", "Low", 0),
+			remote: rec(nil, "title", "plan", 0, "priority", "High", 3),
+			want:   rec(nil, "title", "plan trip", 5, "priority", "High", 3),
+		},
+		{
+			name:   "same change on both sides",
+			base:   base,
+			local:  rec(nil, "title", "plan trip", 5, "priority", "Low", 0),
+			remote: rec(nil, "title", "plan trip", 7, "priority", "Low", 0),
+			want:   rec(nil, "title", "plan trip", 7, "priority", "Low", 0),
+		},
+		{
+			name:      "conflict local newer",
+			base:      base,
+			local:     rec(nil, "title", "local", 9, "priority", "Low", 0),
+			remote:    rec(nil, "title", "remote", 4, "priority", "Low", 0),
+			want:      rec(nil, "title", "local", 9, "priority", "Low", 0),
+			conflicts: []Conflict{{Entity: storage.ChangeTask, ID: "t1", Field: "title", Winner: Local}},
+		},
+		{
+			name:      "conflict remote newer",
+			base:      base,
+			local:     rec(nil, "title", "local", 4, "priority", "Low", 0),
+			remote:    rec(nil, "title", "remote", 9, "priority", "Low", 0),
+			want:      rec(nil, "title", "remote", 9, "priority", "Low", 0),
+			conflicts: []Conflict{{Entity: storage.ChangeTask, ID: "t1", Field: "title", Winner: Remote}},
+		},
+		{
+			name:      "conflict tie goes to the larger value",
+			base:      base,
+			local:     rec(nil, "title", "b", 4, "priority", "Low", 0),
+			remote:    rec(nil, "title", "a", 4, "priority", "Low", 0),
+			want:      rec(nil, "title", "b", 4, "priority", "Low", 0),
+			conflicts: []Conflict{{Entity: storage.ChangeTask, ID: "t1", Field: "title", Winner: Local}},
+		},
+		{
+			name:   "new on both sides without base",
+			local:  rec(nil, "title", "same", 1, "priority", "Low", 1),
+			remote: rec(nil, "title", "same", 2, "priority", "High", 2),
+			want:   rec(nil, "title", "same", 2, "priority", "High", 2),
+			conflicts: []Conflict{
+				{Entity: storage.ChangeTask, ID: "t1", Field: "priority", Winner: Remote},
+			},
+		},
+		{
+			name:   "only local",
+			local:  rec(nil, "title", "mine", 1),
+			remote: nil,
+			want:   rec(nil, "title", "mine", 1),
+		},
+		{
+			name:   "field missing remotely",
+			base:   rec(nil, "title", "plan", 0),
+			local:  rec(nil, "title", "plan", 0, "energy", "Deep", 2),
+			remote: rec(nil, "title", "plan", 0),
+			want:   rec(nil, "title", "plan", 0, "energy", "Deep", 2),
+		},
+		{
+			name:   "local delete of an untouched record",
+			base:   base,
+			local:  rec(minute(6), "title", "plan", 0, "priority", "Low", 0),
+			remote: rec(nil, "title", "plan", 0, "priority", "Low", 0),
+			want:   rec(minute(6), "title", "plan", 0, "priority", "Low", 0),
+		},
+		{
+			name:      "local delete after a remote edit",
+			base:      base,
+			local:     rec(minute(6), "title", "plan", 0, "priority", "Low", 0),
+			remote:    rec(nil, "title", "plan", 0, "priority", "High", 3),
+			want:      rec(minute(6), "title", "plan", 0, "priority", "High", 3),
+			conflicts: []Conflict{{Entity: storage.ChangeTask, ID: "t1", Field: "deleted", Winner: Local}},
+		},
+		{
+			name:      "remote edit after a local delete revives the record",
+			base:      base,
+			local:     rec(minute(2), "title", "plan", 0, "priority", "Low", 0),
+			remote:    rec(nil, "title", "plan", 0, "priority", "High", 3),
+			want:      rec(nil, "title", "plan", 0, "priority", "High", 3),
+			conflicts: []Conflict{{Entity: storage.ChangeTask, ID: "t1", Field: "deleted", Winner: Remote}},
+		},
+		{
+			name:      "local edit after a remote delete revives the record",
+			base:      base,
+			local:     rec(nil, "title", "plan b", 8, "priority", "Low", 0),
+			remote:    rec(minute(2), "title", "plan", 0, "priority", "Low", 0),
+			want:      rec(nil, "title", "plan b", 8, "priority", "Low", 0),
+			conflicts: []Conflict{{Entity: storage.ChangeTask, ID: "t1", Field: "deleted", Winner: Local}},
+		},
+		{
+			name:   "deleted on both sides keeps the later delete",
+			base:   base,
+			local:  rec(minute(2), "title", "plan", 0, "priority", "Low", 0),
+			remote: rec(minute(5), "title", "plan", 0, "priority", "Low", 0),
+			want:   rec(minute(5), "title", "plan", 0, "priority", "Low", 0),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, conflicts := merge(tc.base, tc.local, tc.remote)
+			if !sameRecord(&got, tc.want) {
+				gotJSON, _ := encodeRecord(&got)
+				wantJSON, _ := encodeRecord(tc.want)
+				t.Fatalf("merged:\n%s\nwant:\n%s", gotJSON, wantJSON)
+			}
+			if !reflect.DeepEqual(conflicts, tc.conflicts) {
+				t.Fatalf("conflicts = %+v, want %+v", conflicts, tc.conflicts)
+			}
+		})
+	}
+}
+
+func TestDecodeRecordIgnoresValueLayout(t *testing.T) {
+	data := []byte(`{"schema":"taskd-sync/v1","entity":"task","id":"t1","fields":{"tags":{"value":[ "a",  "b" ],"at":"2026-02-11T09:00:00Z"}}}`)
+	got, err := decodeRecord(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if want := rec(nil, "tags", []string{"a", "b"}, 0); !sameRecord(got, want) {
+		t.Fatalf("decoded %+v", got)
+	}
+}
@@ -0,0 +1,57 @@
+// Package syncer reconciles the local database with a replica elsewhere.
+//
+// Records are exchanged as per-field values stamped with the time each field
+// was last changed. A sync merges both sides against the last version they
+// agreed on (the base kept in storage): a field changed on one side only takes
+// that side's value, and a field changed differently on both sides keeps the
+// later edit and is reported as a Conflict.
+package syncer
+
+import (
+	"context"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// Syncer exchanges changes with one replica.
+type Syncer interface {
+	// Target names the replica; base records are kept per target.
+	Target() string
+	// Sync merges local and remote changes in both directions. progress, when
+	// non-nil, is called after each record.
+	Sync(ctx context.Context, progress func(Progress)) (Result, error)
+}
+
+// Progress reports how far a sync has got through one kind of record.
+type Progress struct {
+	Entity storage.ChangeEntity
+	Done   int
+	Total  int
+}
+
+// Side names where a merged value came from.
+type Side string
+
+const (
+	Local  Side = "local"
+	Remote Side = "remote"
+)
+
+// Conflict is a field both sides changed to different values. Field is
+// "deleted" when one side deleted a record the other edited.
+type Conflict struct {
+	Entity storage.ChangeEntity
+	ID     string
+	Field  string
+	Winner Side
+}
+
+// Result summarises a sync. Pushed counts records written to the replica and
+// Pulled records changed locally. Errors holds records that could not be
+// synced; they are retried on the next sync.
+type Result struct {
+	Pushed    int
+	Pulled    int
+	Conflicts []Conflict
+	Errors    []error
+}
@@ -150,9 +150,6 @@ func (m *Model) ensureInboxState() {
 	if m.Inbox.Selected == nil {
 		m.Inbox.Selected = make(map[string]bool)
 	}
-	if m.Inbox.NextID <= 0 {
-		m.Inbox.NextID = 1
-	}
 }
 
 func (m *Model) ensureTodayState() {
@@ -205,6 +202,7 @@ func (m *Model) cycleDensity() {
 func (m Model) renderInboxView() string {
 	return views.RenderInboxPanel(views.InboxPanelData{
 		QuickAddView: m.quickAddInput.View(),
+		Preview:      m.quickAddPreview(),
 		ListView:     m.inboxList.View(),
 		CaptureMode:  m.Inbox.CaptureMode,
 	})
@@ -294,3 +292,16 @@ func waitForReminderCmd(ch <-chan scheduler.ReminderEvent) tea.Cmd {
 		return ReminderDueMsg{Event: ev}
 	}
 }
+
+func waitForDeadLetterCmd(ch <-chan scheduler.DeadLetter) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		letter, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return DeadLetterMsg{Letter: letter}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandeepkv93/taskd/internal/clock"
 	"github.com/sandeepkv93/taskd/internal/scheduler"
 )
 
@@ -175,6 +176,7 @@ func TestInboxCaptureModeFlow(t *testing.T) {
 
 func TestInboxBulkSelectScheduleAndTag(t *testing.T) {
 	m := NewModel()
+	m.clock = clock.NewFake(time.Date(2026, 2, 11, 14, 0, 0, 0, time.Local))
 	m.CurrentView = ViewInbox
 	m.addInboxItem("task one")
 	m.addInboxItem("task two")
@@ -188,7 +190,7 @@ func TestInboxBulkSelectScheduleAndTag(t *testing.T) {
 	updated, _ = next.Update(BulkScheduleInboxMsg{When: "tomorrow 09:00"})
 	next = updated.(Model)
 	for _, item := range next.Inbox.Items {
-		if item.ScheduledFor != "tomorrow 09:00" {
+		if item.ScheduledFor != "2026-02-12 09:00" {
 			t.Fatalf("expected scheduled value for %q, got %q", item.ID, item.ScheduledFor)
 		}
 	}
@@ -454,6 +456,28 @@ func TestUpdateReminderDueMsgAppendsLogAndRearms(t *testing.T) {
 	}
 }
 
+func TestUpdateDeadLetterMsgCountsMissedReminders(t *testing.T) {
+	engine := scheduler.NewEngine(1)
+	m := NewModelWithScheduler(engine)
+	letter := scheduler.DeadLetter{
+		Event:  scheduler.ReminderEvent{ID: "rem-lost"},
+		Reason: scheduler.DropBufferFull,
+	}
+
+	updated, cmd := m.Update(DeadLetterMsg{Letter: letter})
+	updated, _ = updated.(Model).Update(DeadLetterMsg{Letter: letter})
+	next := updated.(Model)
+	if next.MissedCount != 2 {
+		t.Fatalf("expected 2 missed reminders, got %d", next.MissedCount)
+	}
+	if cmd == nil {
+		t.Fatal("expected dead-letter listener rearm cmd")
+	}
+	if !next.Status.IsError || !strings.Contains(next.Status.Text, "2 reminder(s) were missed") {
+		t.Fatalf("unexpected missed reminder status: %+v", next.Status)
+	}
+}
+
 func TestReminderBehaviorHard(t *testing.T) {
 	m := NewModel()
 	ev := scheduler.ReminderEvent{ID: "r-hard", Type: "Hard", TriggerAt: time.Now().UTC()}
@@ -594,6 +618,7 @@ func TestCommandPaletteAddCommand(t *testing.T) {
 
 func TestCommandPaletteRescheduleSelected(t *testing.T) {
 	m := NewModel()
+	m.clock = clock.NewFake(time.Date(2026, 2, 11, 14, 0, 0, 0, time.Local))
 	m.CurrentView = ViewInbox
 	m.addInboxItem("one")
 	m.addInboxItem("two")
@@ -608,7 +633,7 @@ func TestCommandPaletteRescheduleSelected(t *testing.T) {
 	updated, _ = next.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	next = updated.(Model)
 
-	if next.Inbox.Items[0].ScheduledFor != "next monday" {
+	if next.Inbox.Items[0].ScheduledFor != "2026-02-16 09:00" {
 		t.Fatalf("expected selected item rescheduled, got %q", next.Inbox.Items[0].ScheduledFor)
 	}
 	if next.Inbox.Items[1].ScheduledFor != "" {
@@ -713,9 +738,10 @@ func TestDesktopNotificationOptional(t *testing.T) {
 func TestTemporalDebtScoring(t *testing.T) {
 	m := NewModel()
 	m.Today.Items = []TodayItem{
-		{ID: "a", Title: "late critical", Bucket: TodayBucketOverdue, Priority: "Critical", Notes: "snoozed yesterday"},
+		{ID: "a", Title: "late critical", Bucket: TodayBucketOverdue, Priority: "Critical", Snoozes: 2},
 		{ID: "b", Title: "late normal", Bucket: TodayBucketOverdue, Priority: "Medium"},
-		{ID: "c", Title: "normal", Bucket: TodayBucketAnytime, Priority: "Low", Notes: "snoozed once"},
+		{ID: "c", Title: "normal", Bucket: TodayBucketAnytime, Priority: "Low", Snoozes: 1},
+		{ID: "d", Title: "mentions it", Bucket: TodayBucketAnytime, Priority: "Low", Notes: "snoozed in notes only"},
 	}
 
 	score := m.computeTemporalDebtScore()
@@ -760,7 +786,7 @@ func TestEnergyAwareSuggestions(t *testing.T) {
 func TestProductivityViewIncludesDebtAndSuggestions(t *testing.T) {
 	m := NewModel()
 	m.Today.Items = []TodayItem{
-		{ID: "x", Title: "overdue email", Bucket: TodayBucketOverdue, Notes: "snoozed"},
+		{ID: "x", Title: "overdue email", Bucket: TodayBucketOverdue, Snoozes: 1},
 		{ID: "y", Title: "write docs", Bucket: TodayBucketAnytime, Notes: "docs"},
 	}
 	m.Productivity.AvailableMinutes = 30
@@ -864,3 +890,168 @@ func TestNaggingReminderSkipsRescheduleWhenCompletedTaskLoaded(t *testing.T) {
 	case <-time.After(250 * time.Millisecond):
 	}
 }
+
+func TestNaggingFollowUpsDedupeAndCancelOnAcknowledge(t *testing.T) {
+	engine := scheduler.NewEngine(8)
+	m := NewModelWithScheduler(engine)
+	now := time.Now().UTC()
+	ev := scheduler.ReminderEvent{ID: "r-nag", TaskID: "task-nag", Type: "Nagging", TriggerAt: now}
+
+	m.applyReminderBehavior(ev, now)
+	m.applyReminderBehavior(ev, now.Add(time.Minute))
+	if engine.Len() != 1 {
+		t.Fatalf("expected a single pending nagging follow-up, got %#v", engine.Pending())
+	}
+
+	updated, _ := m.Update(AcknowledgeReminderMsg{ID: "r-nag"})
+	m = updated.(Model)
+	if engine.Len() != 0 {
+		t.Fatalf("expected acknowledge to cancel the follow-up, got %#v", engine.Pending())
+	}
+
+	if err := engine.Schedule(scheduler.ReminderEvent{ID: "r-focus", TaskID: "task-focus", Type: "Hard", TriggerAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("schedule focus reminder: %v", err)
+	}
+	m.stateFilePath = ""
+	m.Focus.TaskID = "task-focus"
+	m.Focus.Phase = FocusPhaseWork
+	m.completeFocusPhase()
+	if engine.Len() != 0 {
+		t.Fatalf("expected completing the task to cancel its reminders, got %#v", engine.Pending())
+	}
+}
+
+func newFakeClockModel(t *testing.T, start time.Time) (Model, *clock.Fake, *scheduler.Engine) {
+	t.Helper()
+	fake := clock.NewFake(start)
+	engine := scheduler.NewEngine(8, scheduler.WithClock(fake))
+	engine.Start()
+	t.Cleanup(engine.Stop)
+	cfg := DefaultRuntimeConfig()
+	cfg.CompletionStatePath = ""
+	cfg.Clock = fake
+	return NewModelWithConfig(engine, nil, cfg), fake, engine
+}
+
+// deliverNext advances the fake clock by d and feeds the reminder the engine
+// emits back into the model, as the reminder listener would.
+func deliverNext(t *testing.T, m Model, fake *clock.Fake, engine *scheduler.Engine, d time.Duration) (Model, scheduler.ReminderEvent) {
+	t.Helper()
+	fake.BlockUntil(1)
+	fake.Advance(d)
+	select {
+	case ev := <-engine.C():
+		updated, _ := m.Update(ReminderDueMsg{Event: ev})
+		return updated.(Model), ev
+	case <-time.After(time.Second):
+		t.Fatalf("no reminder delivered after advancing %s", d)
+		return m, scheduler.ReminderEvent{}
+	}
+}
+
+func TestNaggingReminderRepeatsEveryTwoMinutesOnFakeClock(t *testing.T) {
+	start := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	m, fake, engine := newFakeClockModel(t, start)
+	ev := scheduler.ReminderEvent{ID: "r-nag", TaskID: "task-nag", Type: "Nagging", TriggerAt: start}
+
+	updated, _ := m.Update(ReminderDueMsg{Event: ev})
+	m = updated.(Model)
+	for i := 1; i <= 3; i++ {
+		var got scheduler.ReminderEvent
+		m, got = deliverNext(t, m, fake, engine, 2*time.Minute)
+		if want := start.Add(time.Duration(2*i) * time.Minute); !got.TriggerAt.Equal(want) {
+			t.Fatalf("nag %d fired for %s, want %s", i, got.TriggerAt, want)
+		}
+	}
+
+	updated, _ = m.Update(AcknowledgeReminderMsg{ID: "r-nag"})
+	m = updated.(Model)
+	fake.Advance(time.Hour)
+	select {
+	case got := <-engine.C():
+		t.Fatalf("acknowledged reminder fired again: %+v", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestSoftReminderFollowsUpOnceAfterTenMinutesOnFakeClock(t *testing.T) {
+	start := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	m, fake, engine := newFakeClockModel(t, start)
+	ev := scheduler.ReminderEvent{ID: "r-soft", Type: "Soft", TriggerAt: start}
+
+	updated, _ := m.Update(ReminderDueMsg{Event: ev})
+	m = updated.(Model)
+	fake.BlockUntil(1)
+	fake.Advance(9 * time.Minute)
+	select {
+	case got := <-engine.C():
+		t.Fatalf("soft follow-up fired early at %s", got.TriggerAt)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	m, got := deliverNext(t, m, fake, engine, time.Minute)
+	if !got.TriggerAt.Equal(start.Add(10 * time.Minute)) {
+		t.Fatalf("unexpected follow-up time %s", got.TriggerAt)
+	}
+	if engine.Len() != 0 {
+		t.Fatalf("expected a single soft follow-up, got %#v", engine.Pending())
+	}
+}
+
+func TestContextualReminderDefersToEveningOnFakeClock(t *testing.T) {
+	start := time.Date(2026, 2, 9, 10, 0, 0, 0, time.UTC)
+	m, fake, engine := newFakeClockModel(t, start)
+	ev := scheduler.ReminderEvent{ID: "r-ctx", Type: "Contextual", RepeatRule: "evening", TriggerAt: start}
+
+	updated, _ := m.Update(ReminderDueMsg{Event: ev})
+	m = updated.(Model)
+	if !strings.Contains(m.Status.Text, "contextual deferred: r-ctx -> 18:00") {
+		t.Fatalf("expected deferral to 18:00, got %q", m.Status.Text)
+	}
+
+	m, got := deliverNext(t, m, fake, engine, 8*time.Hour)
+	if !got.TriggerAt.Equal(time.Date(2026, 2, 9, 18, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected deferred trigger %s", got.TriggerAt)
+	}
+	if !strings.Contains(m.Status.Text, "contextual reminder: r-ctx") || engine.Len() != 0 {
+		t.Fatalf("expected delivery inside the evening window, got %q with %d pending", m.Status.Text, engine.Len())
+	}
+}
+
+func TestFocusTickFollowsFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC))
+	msgs := make(chan tea.Msg, 1)
+	cmd := focusTickCmd(fake)
+	go func() { msgs <- cmd() }()
+
+	fake.Advance(time.Second)
+	select {
+	case msg := <-msgs:
+		if _, ok := msg.(FocusTickMsg); !ok {
+			t.Fatalf("expected FocusTickMsg, got %T", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("focus tick did not fire after advancing the clock")
+	}
+}
+
+func TestRecurrenceEditorPreviewsRRule(t *testing.T) {
+	m, _, _ := newFakeClockModel(t, time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC))
+	m = pressKey(t, m, runeKey("R"))
+	for m.recurrenceEditor.RuleType != "rrule" {
+		m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	}
+	m = pressKey(t, m, runeKey("FREQ=MONTHLY;BYDAY=2TX"))
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	m = pressKey(t, m, runeKey("U"))
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.recurrenceEditor.Err != "" || len(m.recurrenceEditor.Preview) != 5 {
+		t.Fatalf("expected five previewed occurrences, got %v (%s)", m.recurrenceEditor.Preview, m.recurrenceEditor.Err)
+	}
+	if got := m.recurrenceEditor.Preview[:2]; got[0] != "2026-02-10 12:00" || got[1] != "2026-03-10 12:00" {
+		t.Fatalf("expected second Tuesdays, got %v", got)
+	}
+	if m.recurrenceEditor.IntervalText != "1" || !strings.Contains(m.renderRecurrenceEditorIfVisible(), "rule: FREQ=MONTHLY;BYDAY=2TU") {
+		t.Fatalf("expected the rule typed into its own field:\n%s", m.renderRecurrenceEditorIfVisible())
+	}
+}
@@ -0,0 +1,109 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// restoreScheduler loads the persisted checkpoint and re-arms the engine from
+// enabled reminder rows, replaying the ones missed while taskd was closed.
+func (m *Model) restoreScheduler(now time.Time) error {
+	if m.repo == nil || m.Scheduler == nil {
+		return nil
+	}
+	ctx := context.Background()
+	state, err := m.repo.GetSchedulerState(ctx)
+	if err != nil {
+		return fmt.Errorf("load scheduler checkpoint: %w", err)
+	}
+	m.checkpoint = scheduler.Checkpoint{Cursor: state.CheckpointCursor}
+	if state.LastTickAt != nil {
+		m.checkpoint.LastTickAt = state.LastTickAt.UTC()
+	}
+	enabled := true
+	reminders, err := m.repo.ListReminders(ctx, storage.ReminderListFilter{Enabled: &enabled})
+	if err != nil {
+		return fmt.Errorf("load reminders: %w", err)
+	}
+
+	events := make([]scheduler.ReminderEvent, 0, len(reminders))
+	for _, rem := range reminders {
+		if rem.LastFired != nil && !rem.LastFired.Before(rem.TriggerAt) {
+			continue
+		}
+		events = append(events, reminderEventFromStore(rem))
+	}
+	report, err := m.Scheduler.Restore(events, m.checkpoint, now.UTC(), m.catchUp)
+	if err != nil {
+		return fmt.Errorf("restore reminders: %w", err)
+	}
+
+	switch {
+	case report.Replayed > 0:
+		m.Status = StatusBar{Text: fmt.Sprintf("replaying %d missed reminder(s)", report.Replayed)}
+	case report.Skipped > 0:
+		// Nothing will be delivered to move the checkpoint past the skipped
+		// reminders, so move it now to avoid reconsidering them next start.
+		m.Status = StatusBar{Text: fmt.Sprintf("skipped %d missed reminder(s)", report.Skipped)}
+		m.checkpoint = scheduler.Checkpoint{LastTickAt: now.UTC()}
+		if err := m.saveCheckpoint(ctx, m.repo, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordReminderDelivery advances the checkpoint past ev and stamps the
+// reminder row as fired, atomically.
+func (m *Model) recordReminderDelivery(ev scheduler.ReminderEvent, now time.Time) error {
+	m.checkpoint = m.checkpoint.Advance(ev)
+	if m.repo == nil {
+		return nil
+	}
+	ctx := context.Background()
+	return m.repo.WithTx(ctx, func(tx storage.Repository) error {
+		if err := m.saveCheckpoint(ctx, tx, now); err != nil {
+			return err
+		}
+		rem, err := tx.GetReminder(ctx, ev.ID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("load reminder %s: %w", ev.ID, err)
+		}
+		fired := now.UTC()
+		rem.LastFired = &fired
+		if err := tx.UpdateReminder(ctx, rem); err != nil {
+			return fmt.Errorf("update reminder %s: %w", ev.ID, err)
+		}
+		return nil
+	})
+}
+
+func (m *Model) saveCheckpoint(ctx context.Context, repo storage.Repository, now time.Time) error {
+	state := storage.SchedulerState{CheckpointCursor: m.checkpoint.Cursor, UpdatedAt: now.UTC()}
+	if !m.checkpoint.LastTickAt.IsZero() {
+		tick := m.checkpoint.LastTickAt
+		state.LastTickAt = &tick
+	}
+	if err := repo.SaveSchedulerState(ctx, state); err != nil {
+		return fmt.Errorf("save scheduler checkpoint: %w", err)
+	}
+	return nil
+}
+
+func reminderEventFromStore(rem storage.Reminder) scheduler.ReminderEvent {
+	return scheduler.ReminderEvent{
+		ID:         rem.ID,
+		TaskID:     rem.TaskID,
+		Type:       rem.Type,
+		RepeatRule: rem.RepeatRule,
+		TriggerAt:  rem.TriggerAt.UTC(),
+	}
+}
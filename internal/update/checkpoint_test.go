@@ -0,0 +1,110 @@
+package update
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func seedStoreReminder(t *testing.T, repo storage.Repository, rem storage.Reminder) {
+	t.Helper()
+	if rem.Type == "" {
+		rem.Type = "Hard"
+	}
+	rem.Enabled = true
+	rem.CreatedAt = time.Now().UTC()
+	if err := repo.CreateReminder(context.Background(), rem); err != nil {
+		t.Fatalf("seed reminder %s: %v", rem.ID, err)
+	}
+}
+
+func TestRestoreSchedulerReplaysMissedAndAdvancesCheckpoint(t *testing.T) {
+	repo := setupStoreRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	lastTick := now.Add(-6 * time.Hour)
+	fired := now.Add(-3 * time.Hour)
+	seedStoreTask(t, repo, storage.Task{ID: "t1", Title: "water plants", State: "Planned"})
+	seedStoreReminder(t, repo, storage.Reminder{ID: "missed", TaskID: "t1", TriggerAt: now.Add(-2 * time.Hour)})
+	seedStoreReminder(t, repo, storage.Reminder{ID: "old", TaskID: "t1", TriggerAt: now.Add(-8 * time.Hour)})
+	seedStoreReminder(t, repo, storage.Reminder{ID: "fired", TaskID: "t1", TriggerAt: now.Add(-4 * time.Hour), LastFired: &fired})
+	seedStoreReminder(t, repo, storage.Reminder{ID: "future", TaskID: "t1", TriggerAt: now.Add(time.Hour)})
+	if err := repo.SaveSchedulerState(ctx, storage.SchedulerState{LastTickAt: &lastTick}); err != nil {
+		t.Fatalf("save checkpoint: %v", err)
+	}
+
+	engine := scheduler.NewEngine(8)
+	m := NewModelWithRepository(engine, nil, repo, storeTestConfig(t))
+	if m.Status.Text != "replaying 1 missed reminder(s)" {
+		t.Fatalf("unexpected restore status: %q", m.Status.Text)
+	}
+	engine.Start()
+	defer engine.Stop()
+
+	var ev scheduler.ReminderEvent
+	select {
+	case ev = <-engine.C():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replayed reminder")
+	}
+	if ev.ID != "missed" {
+		t.Fatalf("expected missed reminder replayed, got %s", ev.ID)
+	}
+	updated, _ := m.Update(ReminderDueMsg{Event: ev})
+	m = updated.(Model)
+	if strings.Contains(m.Status.Text, "persist") {
+		t.Fatalf("unexpected delivery error: %q", m.Status.Text)
+	}
+
+	state, err := repo.GetSchedulerState(ctx)
+	if err != nil {
+		t.Fatalf("load checkpoint: %v", err)
+	}
+	if state.LastTickAt == nil || !state.LastTickAt.Equal(ev.TriggerAt) || state.CheckpointCursor != "missed" {
+		t.Fatalf("expected checkpoint at delivered reminder, got %#v", state)
+	}
+	rem, err := repo.GetReminder(ctx, "missed")
+	if err != nil || rem.LastFired == nil {
+		t.Fatalf("expected reminder stamped as fired, got %#v, %v", rem, err)
+	}
+
+	restarted := NewModelWithRepository(scheduler.NewEngine(8), nil, repo, storeTestConfig(t))
+	if strings.Contains(restarted.Status.Text, "missed") {
+		t.Fatalf("expected nothing to replay after checkpoint, got %q", restarted.Status.Text)
+	}
+}
+
+func TestRestoreSchedulerSkipPolicyMovesCheckpoint(t *testing.T) {
+	repo := setupStoreRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	seedStoreTask(t, repo, storage.Task{ID: "t1", Title: "stretch", State: "Planned"})
+	seedStoreReminder(t, repo, storage.Reminder{ID: "missed", TaskID: "t1", TriggerAt: now.Add(-time.Hour)})
+
+	cfg := storeTestConfig(t)
+	cfg.CatchUpPolicy = scheduler.CatchUpSkip
+	engine := scheduler.NewEngine(8)
+	m := NewModelWithRepository(engine, nil, repo, cfg)
+	if m.Status.Text != "skipped 1 missed reminder(s)" {
+		t.Fatalf("unexpected restore status: %q", m.Status.Text)
+	}
+	engine.Start()
+	defer engine.Stop()
+	select {
+	case ev := <-engine.C():
+		t.Fatalf("expected skipped reminder not to fire, got %s", ev.ID)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	state, err := repo.GetSchedulerState(ctx)
+	if err != nil {
+		t.Fatalf("load checkpoint: %v", err)
+	}
+	if state.LastTickAt == nil || state.LastTickAt.Before(now) {
+		t.Fatalf("expected checkpoint moved to startup time, got %#v", state)
+	}
+}
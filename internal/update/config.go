@@ -1,9 +1,15 @@
 package update
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/syncer"
 )
 
 type RuntimeConfig struct {
@@ -13,6 +19,23 @@ type RuntimeConfig struct {
 	ProductivityAvailableMins int
 	SchedulerBuffer           int
 	CompletionStatePath       string
+	DatabasePath              string
+	CatchUpPolicy             scheduler.CatchUpPolicy
+	DeliveryPolicy            scheduler.DeliveryPolicy
+	// APIListen and APIToken configure `taskd serve`.
+	APIListen string
+	APIToken  string
+	// RefreshInterval is how often the TUI polls the database for writes by
+	// other taskd processes; 0 disables the polling.
+	RefreshInterval time.Duration
+	// SyncDir is the directory S syncs with; empty disables sync unless
+	// Syncer is set.
+	SyncDir string
+	// Syncer overrides the sync backend. It is not read from the environment.
+	Syncer syncer.Syncer
+	// Clock drives reminder behaviours, the focus timer and previews; nil
+	// means the system clock. It is not read from the environment.
+	Clock clock.Clock
 }
 
 func DefaultRuntimeConfig() RuntimeConfig {
@@ -23,10 +46,18 @@ func DefaultRuntimeConfig() RuntimeConfig {
 		ProductivityAvailableMins: 60,
 		SchedulerBuffer:           64,
 		CompletionStatePath:       ".taskd_state.json",
+		DatabasePath:              ".taskd.db",
+		CatchUpPolicy:             scheduler.CatchUpAll,
+		DeliveryPolicy:            scheduler.DeliveryBlock,
+		APIListen:                 "127.0.0.1:7777",
+		RefreshInterval:           2 * time.Second,
 	}
 }
 
-func RuntimeConfigFromEnv(base RuntimeConfig) RuntimeConfig {
+// RuntimeConfigFromEnv overrides base with the TASKD_* environment. Settings
+// that fail to parse keep base's value, except the delivery policy: falling
+// back there could silently drop reminders, so it is an error instead.
+func RuntimeConfigFromEnv(base RuntimeConfig) (RuntimeConfig, error) {
 	cfg := base
 	if v, ok := getEnvBool("TASKD_DESKTOP_NOTIFICATIONS"); ok {
 		cfg.DesktopNotifications = v
@@ -46,7 +77,36 @@ func RuntimeConfigFromEnv(base RuntimeConfig) RuntimeConfig {
 	if v, ok := getEnvString("TASKD_STATE_FILE"); ok {
 		cfg.CompletionStatePath = v
 	}
-	return cfg
+	if v, ok := getEnvString("TASKD_DB_PATH"); ok {
+		cfg.DatabasePath = v
+	}
+	if v, ok := getEnvString("TASKD_CATCH_UP_POLICY"); ok {
+		if policy, err := scheduler.ParseCatchUpPolicy(v); err == nil {
+			cfg.CatchUpPolicy = policy
+		}
+	}
+	if v, ok := getEnvString("TASKD_REMINDER_DELIVERY"); ok {
+		policy, err := scheduler.ParseDeliveryPolicy(v)
+		if err != nil {
+			return base, fmt.Errorf("TASKD_REMINDER_DELIVERY: %w", err)
+		}
+		cfg.DeliveryPolicy = policy
+	}
+	if v, ok := getEnvString("TASKD_API_LISTEN"); ok {
+		cfg.APIListen = v
+	}
+	if v, ok := getEnvString("TASKD_API_TOKEN"); ok {
+		cfg.APIToken = v
+	}
+	if v, ok := getEnvString("TASKD_SYNC_DIR"); ok {
+		cfg.SyncDir = v
+	}
+	if v, ok := getEnvString("TASKD_REFRESH_INTERVAL"); ok {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.RefreshInterval = d
+		}
+	}
+	return cfg, nil
 }
 
 func getEnvString(name string) (string, bool) {
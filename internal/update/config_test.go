@@ -1,6 +1,12 @@
 package update
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+)
 
 func TestRuntimeConfigDefaults(t *testing.T) {
 	cfg := DefaultRuntimeConfig()
@@ -13,6 +19,21 @@ func TestRuntimeConfigDefaults(t *testing.T) {
 	if cfg.CompletionStatePath != ".taskd_state.json" {
 		t.Fatalf("unexpected completion state default: %+v", cfg)
 	}
+	if cfg.DatabasePath != ".taskd.db" {
+		t.Fatalf("unexpected database path default: %+v", cfg)
+	}
+	if cfg.CatchUpPolicy != scheduler.CatchUpAll {
+		t.Fatalf("unexpected catch-up policy default: %+v", cfg)
+	}
+	if cfg.DeliveryPolicy != scheduler.DeliveryBlock {
+		t.Fatalf("unexpected delivery policy default: %+v", cfg)
+	}
+	if cfg.APIListen != "127.0.0.1:7777" || cfg.APIToken != "" {
+		t.Fatalf("unexpected API defaults: %+v", cfg)
+	}
+	if cfg.RefreshInterval != 2*time.Second {
+		t.Fatalf("unexpected refresh interval default: %s", cfg.RefreshInterval)
+	}
 }
 
 func TestRuntimeConfigFromEnv(t *testing.T) {
@@ -22,8 +43,18 @@ func TestRuntimeConfigFromEnv(t *testing.T) {
 	t.Setenv("TASKD_PRODUCTIVITY_AVAILABLE_MINUTES", "45")
 	t.Setenv("TASKD_SCHEDULER_BUFFER", "128")
 	t.Setenv("TASKD_STATE_FILE", "state/custom.json")
+	t.Setenv("TASKD_DB_PATH", "state/custom.db")
+	t.Setenv("TASKD_CATCH_UP_POLICY", "latest")
+	t.Setenv("TASKD_REMINDER_DELIVERY", "requeue")
+	t.Setenv("TASKD_API_LISTEN", "127.0.0.1:9000")
+	t.Setenv("TASKD_API_TOKEN", "s3cret")
+	t.Setenv("TASKD_REFRESH_INTERVAL", "500ms")
+	t.Setenv("TASKD_SYNC_DIR", "/srv/taskd-sync")
 
-	cfg := RuntimeConfigFromEnv(DefaultRuntimeConfig())
+	cfg, err := RuntimeConfigFromEnv(DefaultRuntimeConfig())
+	if err != nil {
+		t.Fatalf("config from env: %v", err)
+	}
 	if !cfg.DesktopNotifications {
 		t.Fatal("expected desktop notifications true from env")
 	}
@@ -36,4 +67,29 @@ func TestRuntimeConfigFromEnv(t *testing.T) {
 	if cfg.CompletionStatePath != "state/custom.json" {
 		t.Fatalf("unexpected completion path override: %+v", cfg)
 	}
+	if cfg.DatabasePath != "state/custom.db" {
+		t.Fatalf("unexpected database path override: %+v", cfg)
+	}
+	if cfg.CatchUpPolicy != scheduler.CatchUpLatest {
+		t.Fatalf("unexpected catch-up policy override: %+v", cfg)
+	}
+	if cfg.DeliveryPolicy != scheduler.DeliveryRequeue {
+		t.Fatalf("unexpected delivery policy override: %+v", cfg)
+	}
+	if cfg.APIListen != "127.0.0.1:9000" || cfg.APIToken != "s3cret" {
+		t.Fatalf("unexpected API overrides: %+v", cfg)
+	}
+	if cfg.RefreshInterval != 500*time.Millisecond {
+		t.Fatalf("unexpected refresh interval override: %s", cfg.RefreshInterval)
+	}
+	if cfg.SyncDir != "/srv/taskd-sync" {
+		t.Fatalf("unexpected sync dir override: %q", cfg.SyncDir)
+	}
+}
+
+func TestRuntimeConfigFromEnvRejectsUnknownDeliveryPolicy(t *testing.T) {
+	t.Setenv("TASKD_REMINDER_DELIVERY", "blcok")
+	if _, err := RuntimeConfigFromEnv(DefaultRuntimeConfig()); !errors.Is(err, scheduler.ErrInvalidDeliveryPolicy) {
+		t.Fatalf("expected ErrInvalidDeliveryPolicy, got %v", err)
+	}
 }
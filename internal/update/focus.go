@@ -5,6 +5,7 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandeepkv93/taskd/internal/clock"
 )
 
 func (m Model) handleFocusKey(msg tea.KeyMsg) (Model, tea.Cmd) {
@@ -20,7 +21,7 @@ func (m Model) handleFocusKey(msg tea.KeyMsg) (Model, tea.Cmd) {
 		}
 		m.Focus.Running = true
 		m.Status = StatusBar{Text: "focus running", IsError: false}
-		return m, focusTickCmd()
+		return m, focusTickCmd(m.clock)
 	case "r":
 		m.Focus.Running = false
 		m.Focus.RemainingSec = m.currentFocusTotal()
@@ -49,7 +50,7 @@ func (m Model) onFocusTick() (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	}
-	return m, focusTickCmd()
+	return m, focusTickCmd(m.clock)
 }
 
 func (m *Model) bootstrapFocusTask() {
@@ -71,11 +72,37 @@ func (m *Model) completeFocusPhase() {
 	if m.Focus.Phase == FocusPhaseWork {
 		m.Focus.CompletedPomodoros++
 		if m.Focus.TaskID != "" {
+			step := m.beginUndo(m.Focus.TaskID)
 			m.CompletedTasks[m.Focus.TaskID] = true
 			if err := m.persistCompletedTaskState(); err != nil {
 				m.Status = StatusBar{Text: fmt.Sprintf("persist completion state failed: %v", err), IsError: true}
 				return
 			}
+			next, err := m.completeStoredTask(m.Focus.TaskID, m.now())
+			if err != nil {
+				m.Status = StatusBar{Text: fmt.Sprintf("persist task completion failed: %v", err), IsError: true}
+				return
+			}
+			var created []string
+			if next != nil {
+				created = append(created, next.ID)
+			}
+			label := m.Focus.TaskTitle
+			if label == "" {
+				label = m.Focus.TaskID
+			}
+			m.commitUndo(step, fmt.Sprintf("complete %q", label), created...)
+			m.cancelTaskReminders(m.Focus.TaskID)
+			if next != nil {
+				if err := m.armTaskReminders(next.ID); err != nil {
+					m.Status = StatusBar{Text: fmt.Sprintf("schedule next occurrence failed: %v", err), IsError: true}
+					return
+				}
+				if err := m.reloadKeepingCursors(m.now()); err != nil {
+					m.Status = StatusBar{Text: fmt.Sprintf("reload after completion failed: %v", err), IsError: true}
+					return
+				}
+			}
 		}
 		m.Focus.Phase = FocusPhaseBreak
 		m.Focus.RemainingSec = m.Focus.BreakDurationSec
@@ -96,6 +123,10 @@ func (m Model) currentFocusTotal() int {
 	return m.Focus.WorkDurationSec
 }
 
-func focusTickCmd() tea.Cmd {
-	return tea.Tick(time.Second, func(time.Time) tea.Msg { return FocusTickMsg{} })
+func focusTickCmd(c clock.Clock) tea.Cmd {
+	after := clock.OrReal(c).After(time.Second)
+	return func() tea.Msg {
+		<-after
+		return FocusTickMsg{}
+	}
 }
@@ -33,9 +33,12 @@ func (m Model) renderHelpView() string {
 	for _, kb := range m.viewBindings() {
 		plain = append(plain, fmt.Sprintf("- %s: %s", kb.Key, kb.Action))
 	}
+	undo, redo := m.undoLines()
 	return views.RenderHelpPanel(views.HelpPanelData{
 		CurrentView: string(m.CurrentView),
 		Bindings:    plain,
+		Undo:        undo,
+		Redo:        redo,
 		HelpView: m.helpModel.View(helpKeyMap{
 			short: bindings,
 			full:  [][]key.Binding{bindings},
@@ -50,6 +53,8 @@ func (m Model) globalBindings() []KeyBinding {
 		{Key: m.Keys.Calendar, Action: "switch to Calendar"},
 		{Key: m.Keys.Focus, Action: "switch to Focus"},
 		{Key: "/", Action: "open command palette"},
+		{Key: m.Keys.Undo, Action: "undo last action"},
+		{Key: m.Keys.Redo, Action: "redo undone action"},
 		{Key: "D", Action: "cycle density"},
 		{Key: m.Keys.Help, Action: "toggle help panel"},
 		{Key: m.Keys.Quit, Action: "quit app"},
@@ -63,7 +68,7 @@ func (m Model) viewBindings() []KeyBinding {
 			{Key: "enter", Action: "capture inbox item"},
 			{Key: "j/k", Action: "move cursor"},
 			{Key: "space", Action: "toggle select"},
-			{Key: "x/u", Action: "select all / clear selection"},
+			{Key: "x/X", Action: "select all / clear selection"},
 			{Key: "s/g", Action: "bulk schedule / bulk tag"},
 		}
 	case ViewToday:
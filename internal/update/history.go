@@ -0,0 +1,82 @@
+package update
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// historyLimit is how many recent events the Today metadata pane lists.
+const historyLimit = 8
+
+// taskHistory caches the history pane for one task as of the change-log
+// sequence last seen by the change poll, so it is only re-read when the
+// selection moves or the poll reports new writes.
+type taskHistory struct {
+	TaskID string
+	Seq    int64
+	Events []storage.TaskEvent
+}
+
+func (m *Model) refreshHistory() {
+	if m.repo == nil {
+		return
+	}
+	item, ok := m.currentTodayItem()
+	if !ok {
+		m.history = taskHistory{}
+		return
+	}
+	if item.ID == m.history.TaskID && m.changeSeq == m.history.Seq {
+		return
+	}
+	events, err := m.repo.ListTaskEvents(context.Background(), storage.TaskEventFilter{TaskID: item.ID, NewestFirst: true, Limit: historyLimit})
+	if err != nil {
+		// Keyed like a success, so the read is retried on the next selection
+		// change or change poll rather than on every message.
+		m.history = taskHistory{TaskID: item.ID, Seq: m.changeSeq}
+		m.Status = StatusBar{Text: fmt.Sprintf("load history failed: %v", err), IsError: true}
+		return
+	}
+	m.history = taskHistory{TaskID: item.ID, Seq: m.changeSeq, Events: events}
+}
+
+// historyLines renders the cached history of taskID, or nil when there is no
+// repository to read it from.
+func (m Model) historyLines(taskID string) []string {
+	if m.repo == nil {
+		return nil
+	}
+	lines := make([]string, 0, len(m.history.Events))
+	if m.history.TaskID != taskID {
+		return lines
+	}
+	for _, ev := range m.history.Events {
+		lines = append(lines, fmt.Sprintf("%s %-4s %s", ev.OccurredAt.In(m.now().Location()).Format("01-02 15:04"), ev.Source, describeEvent(ev)))
+	}
+	return lines
+}
+
+func describeEvent(ev storage.TaskEvent) string {
+	switch {
+	case ev.Field == storage.EventCreated:
+		return fmt.Sprintf("created %q", ev.NewValue)
+	case ev.Field == storage.EventDeleted:
+		return fmt.Sprintf("deleted %q", ev.OldValue)
+	case ev.OldValue == "" && isRuleEvent(ev.Field):
+		return fmt.Sprintf("%s added: %s", ev.Field, ev.NewValue)
+	case ev.NewValue == "" && isRuleEvent(ev.Field):
+		return fmt.Sprintf("%s removed: %s", ev.Field, ev.OldValue)
+	case ev.OldValue == "":
+		return fmt.Sprintf("%s set to %s", ev.Field, ev.NewValue)
+	case ev.NewValue == "":
+		return fmt.Sprintf("%s cleared (was %s)", ev.Field, ev.OldValue)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", ev.Field, ev.OldValue, ev.NewValue)
+	}
+}
+
+func isRuleEvent(field string) bool {
+	return field == storage.EventReminder || field == storage.EventRecurrence
+}
@@ -0,0 +1,105 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func TestHistoryPaneAndSnoozesFollowTaskEvents(t *testing.T) {
+	repo := setupStoreRepo(t)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	seedStoreTask(t, repo, storage.Task{ID: "late", Title: "pay invoice", State: "Planned", DueAt: &yesterday})
+	seedStoreTask(t, repo, storage.Task{ID: "calm", Title: "stretch", State: "Planned"})
+
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.CurrentView = ViewToday
+	if !strings.Contains(m.View(), "history:") || !strings.Contains(m.View(), "system created \"") {
+		t.Fatalf("expected the seeded creation in the history pane:\n%s", m.View())
+	}
+
+	m.Palette.Input = "snooze overdue 2 days"
+	m = m.executePaletteCommand()
+	if m.Status.IsError {
+		t.Fatalf("unexpected snooze error: %q", m.Status.Text)
+	}
+	if err := m.reloadFromRepository(m.now()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	var late TodayItem
+	for i, item := range m.Today.Items {
+		if item.ID == "late" {
+			late = item
+			m.Today.Cursor = i
+		}
+	}
+	if late.Snoozes != 1 {
+		t.Fatalf("expected one recorded snooze, got %+v", late)
+	}
+
+	// Moving the selection reads the history of the newly selected task.
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+	view := m.View()
+	for _, want := range []string{"tui  state: Planned -> Snoozed", "tui  scheduled_at set to"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the history pane:\n%s", want, view)
+		}
+	}
+
+	// Other messages reuse the cached history until the change poll reports
+	// new writes.
+	task, _ := repo.GetTask(context.Background(), "late")
+	task.Priority = "High"
+	if err := repo.UpdateTask(context.Background(), task); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(Model)
+	if strings.Contains(m.View(), "priority: Medium -> High") {
+		t.Fatalf("expected the history to wait for the change poll:\n%s", m.View())
+	}
+	updated, _ = m.Update(readExternalChanges(repo, m.changeSeq))
+	m = updated.(Model)
+	if !strings.Contains(m.View(), "priority: Medium -> High") {
+		t.Fatalf("expected the change poll to refresh the history:\n%s", m.View())
+	}
+
+	events, err := repo.ListTaskEvents(context.Background(), storage.TaskEventFilter{TaskID: "late", Field: "state"})
+	if err != nil || len(events) != 1 || events[0].Source != storage.SourceTUI {
+		t.Fatalf("expected a tui state event, got %+v, %v", events, err)
+	}
+}
+
+// failingHistoryRepo fails every task event read.
+type failingHistoryRepo struct{ storage.Repository }
+
+func (failingHistoryRepo) ListTaskEvents(context.Context, storage.TaskEventFilter) ([]storage.TaskEvent, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestHistoryReadErrorClearsThePane(t *testing.T) {
+	repo := setupStoreRepo(t)
+	seedStoreTask(t, repo, storage.Task{ID: "calm", Title: "stretch", State: "Planned"})
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.CurrentView = ViewToday
+	if len(m.historyLines("calm")) == 0 {
+		t.Fatal("expected the creation in the history pane")
+	}
+
+	m.repo = failingHistoryRepo{repo}
+	m.changeSeq++
+	m.refreshHistory()
+	if !m.Status.IsError || !strings.Contains(m.Status.Text, "database is locked") {
+		t.Fatalf("expected the read error in the status line, got %+v", m.Status)
+	}
+	if lines := m.historyLines("calm"); len(lines) != 0 {
+		t.Fatalf("expected the stale history cleared, got %v", lines)
+	}
+}
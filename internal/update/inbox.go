@@ -3,8 +3,12 @@ package update
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandeepkv93/taskd/internal/commands"
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/when"
 )
 
 func (m Model) handleInboxKey(msg tea.KeyMsg) Model {
@@ -71,21 +75,67 @@ func (m Model) handleInboxKey(msg tea.KeyMsg) Model {
 	return m
 }
 
-func (m *Model) addInboxItem(title string) {
+// addInboxItem captures quick-add text, extracting inline metadata such as
+// #tag, !high, @deep, due:, at: and every: into the new task.
+func (m *Model) addInboxItem(text string) {
 	m.ensureInboxState()
-	trimmed := strings.TrimSpace(title)
-	if trimmed == "" {
+	if strings.TrimSpace(text) == "" {
 		return
 	}
+	args, err := commands.ParseQuickAdd(text)
+	if err != nil {
+		m.Status = StatusBar{Text: err.Error(), IsError: true}
+		return
+	}
+	if err := m.addInboxTask(args); err != nil {
+		m.Status = StatusBar{Text: err.Error(), IsError: true}
+	}
+}
+
+func (m *Model) addInboxTask(args commands.AddArgs) error {
+	m.ensureInboxState()
+	now := m.now()
+	draft, err := args.Resolve(now)
+	if err != nil {
+		return err
+	}
+	step := m.beginUndo()
+	task, err := m.createStoredTask(draft, now)
+	if err != nil {
+		return fmt.Errorf("persist inbox item failed: %w", err)
+	}
+	defer m.commitUndo(step, fmt.Sprintf("capture %q", task.Title), task.ID)
 	item := InboxItem{
-		ID:    fmt.Sprintf("inbox-%d", m.Inbox.NextID),
-		Title: trimmed,
+		ID:    task.ID,
+		Title: task.Title,
+		Tags:  task.Tags,
+	}
+	if draft.ScheduledAt != nil {
+		item.ScheduledFor = draft.ScheduledAt.Format("2006-01-02 15:04")
 	}
-	m.Inbox.NextID++
 	m.Inbox.Items = append(m.Inbox.Items, item)
 	m.Inbox.Input = ""
 	m.Inbox.Cursor = len(m.Inbox.Items) - 1
 	m.Status = StatusBar{Text: "inbox item captured", IsError: false}
+	return nil
+}
+
+// quickAddPreview parses the in-progress quick-add input for display under
+// the add> prompt.
+func (m Model) quickAddPreview() string {
+	input := strings.TrimSpace(m.Inbox.Input)
+	if input == "" {
+		return ""
+	}
+	args, err := commands.ParseQuickAdd(input)
+	if err != nil {
+		return "! " + err.Error()
+	}
+	draft, err := args.Resolve(m.now())
+	if err != nil {
+		return "! " + err.Error()
+	}
+	return draft.Preview()
 }
 
 func (m *Model) toggleSelectedAtCursor() {
@@ -110,31 +160,86 @@ func (m *Model) selectAllInboxItems() {
 	}
 }
 
+// selectedInboxIDs returns the selected inbox items in list order.
+func (m Model) selectedInboxIDs() []string {
+	ids := make([]string, 0, len(m.Inbox.Selected))
+	for _, item := range m.Inbox.Items {
+		if m.Inbox.Selected[item.ID] {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
 func (m *Model) clearInboxSelection() {
 	m.Inbox.Selected = make(map[string]bool)
 }
 
-func (m *Model) bulkScheduleInbox(when string) {
+func (m *Model) bulkScheduleInbox(phrase string) {
 	m.ensureInboxState()
-	applied := 0
-	for i := range m.Inbox.Items {
-		item := m.Inbox.Items[i]
-		if m.Inbox.Selected[item.ID] {
-			m.Inbox.Items[i].ScheduledFor = when
-			applied++
-		}
+	at, err := when.Parse(phrase, m.now())
+	if err != nil {
+		m.Status = StatusBar{Text: err.Error(), IsError: true}
+		return
+	}
+	applied, err := m.scheduleSelectedInbox(at, false)
+	if err != nil {
+		m.Status = StatusBar{Text: fmt.Sprintf("persist schedule failed: %v", err), IsError: true}
+		return
 	}
 	if applied > 0 {
 		m.Status = StatusBar{Text: fmt.Sprintf("scheduled %d inbox items", applied), IsError: false}
 	}
 }
 
+// scheduleSelectedInbox writes at as the scheduled time (or due date) of
+// every selected inbox item and moves the stored task to Planned.
+func (m *Model) scheduleSelectedInbox(at time.Time, due bool) (applied int, err error) {
+	stamp := at.UTC()
+	step := m.beginUndo(m.selectedInboxIDs()...)
+	defer func() {
+		verb := "schedule"
+		if due {
+			verb = "set due on"
+		}
+		m.commitUndo(step, fmt.Sprintf("%s %d inbox item(s)", verb, applied))
+	}()
+	for i := range m.Inbox.Items {
+		item := m.Inbox.Items[i]
+		if !m.Inbox.Selected[item.ID] {
+			continue
+		}
+		err = m.updateStoredTask(item.ID, func(task *storage.Task) {
+			task.State = "Planned"
+			if due {
+				task.DueAt = &stamp
+			} else {
+				task.ScheduledAt = &stamp
+			}
+		})
+		if err != nil {
+			return applied, err
+		}
+		if !due {
+			m.Inbox.Items[i].ScheduledFor = at.Format("2006-01-02 15:04")
+		}
+		applied++
+	}
+	return applied, nil
+}
+
 func (m *Model) bulkTagInbox(tag string) {
 	m.ensureInboxState()
 	applied := 0
+	step := m.beginUndo(m.selectedInboxIDs()...)
+	defer func() { m.commitUndo(step, fmt.Sprintf("tag %d inbox item(s) #%s", applied, tag)) }()
 	for i := range m.Inbox.Items {
 		item := m.Inbox.Items[i]
 		if m.Inbox.Selected[item.ID] {
+			if err := m.attachStoredTag(item.ID, tag); err != nil {
+				m.Status = StatusBar{Text: fmt.Sprintf("persist tag failed: %v", err), IsError: true}
+				return
+			}
 			if !contains(item.Tags, tag) {
 				m.Inbox.Items[i].Tags = append(m.Inbox.Items[i].Tags, tag)
 			}
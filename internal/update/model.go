@@ -1,6 +1,7 @@
 package update
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"runtime"
@@ -15,7 +16,12 @@ import (
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandeepkv93/taskd/internal/clock"
+	domainmodel "github.com/sandeepkv93/taskd/internal/model"
 	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/syncer"
 )
 
 type View string
@@ -52,6 +58,8 @@ type GlobalKeyMap struct {
 	Focus    string
 	Help     string
 	Quit     string
+	Undo     string
+	Redo     string
 }
 
 type Model struct {
@@ -66,6 +74,7 @@ type Model struct {
 	Scheduler      *scheduler.Engine
 	ReminderLog    []scheduler.ReminderEvent
 	ReminderAck    map[string]bool
+	MissedCount    int
 	SoftFollowedUp map[string]bool
 	CompletedTasks map[string]bool
 	Palette        CommandPaletteState
@@ -73,11 +82,22 @@ type Model struct {
 	Notifications  []Notification
 	DesktopEnabled bool
 	notifier       DesktopNotifier
+	repo           storage.Repository
+	checkpoint     scheduler.Checkpoint
+	catchUp        scheduler.CatchUpPolicy
+	clock          clock.Clock
 	Productivity   ProductivityState
 	Status         StatusBar
 	Keys           GlobalKeyMap
 	Quitting       bool
 	LastError      error
+	// ConflictTaskID is the selected task another process last changed
+	// underneath this one.
+	ConflictTaskID  string
+	history         taskHistory
+	undo            undoHistory
+	changeSeq       int64
+	refreshInterval time.Duration
 	// Bubble components used for rich TUI controls
 	inboxList     list.Model
 	todayList     list.Model
@@ -90,6 +110,9 @@ type Model struct {
 	helpModel     help.Model
 	metaViewport  viewport.Model
 	spinnerActive bool
+	syncer        syncer.Syncer
+	syncEvents    chan tea.Msg
+	syncProgress  syncer.Progress
 	stateFilePath string
 	// Recurrence editor (first-pass UI)
 	recurrenceEditor RecurrenceEditorState
@@ -105,11 +128,10 @@ type InboxItem struct {
 }
 
 type InboxState struct {
-	Input    string
-	Items    []InboxItem
-	Cursor   int
-	Selected map[string]bool
-	NextID   int
+	Input       string
+	Items       []InboxItem
+	Cursor      int
+	Selected    map[string]bool
 	CaptureMode bool
 }
 
@@ -130,6 +152,8 @@ type TodayItem struct {
 	Priority    string
 	Tags        []string
 	Notes       string
+	// Snoozes counts the times the task's history shows it being snoozed.
+	Snoozes int
 }
 
 type TodayState struct {
@@ -183,12 +207,36 @@ type CommandPaletteState struct {
 	Input  string
 }
 
+// RecurrenceField is the recurrence editor line that typed keys go to.
+type RecurrenceField string
+
+const (
+	RecurrenceFieldRule      RecurrenceField = "rule"
+	RecurrenceFieldEnds      RecurrenceField = "ends"
+	RecurrenceFieldException RecurrenceField = "exception"
+)
+
 type RecurrenceEditorState struct {
-	Active       bool
+	Active bool
+	// TaskID is the Today task being edited and RuleID its rule, empty until
+	// the first save creates one.
+	TaskID       string
+	RuleID       string
 	RuleType     string
 	IntervalText string
-	Preview      []string
-	Err          string
+	// RRuleText is the rule typed while RuleType is "rrule".
+	RRuleText string
+	// EndsText is "never", "until <day>" or "after <n>".
+	EndsText string
+	// ExceptionText is a skip, move or pause line not yet added.
+	ExceptionText string
+	Exceptions    []domainmodel.RecurrenceException
+	Field         RecurrenceField
+	Preview       []string
+	Err           string
+	// base keeps what the editor does not show, such as the anchor and
+	// weekdays of a loaded rule.
+	base domainmodel.RecurrenceRule
 }
 
 type listItem struct {
@@ -289,6 +337,11 @@ type ReminderDueMsg struct {
 	Event scheduler.ReminderEvent
 }
 
+// DeadLetterMsg reports a reminder the scheduler gave up delivering.
+type DeadLetterMsg struct {
+	Letter scheduler.DeadLetter
+}
+
 type AcknowledgeReminderMsg struct {
 	ID string
 }
@@ -299,46 +352,10 @@ func NewModel() Model {
 		Sort:        SortCreatedDesc,
 		Inbox: InboxState{
 			Selected: make(map[string]bool),
-			NextID:   1,
-		},
-		Today: TodayState{
-			Items: []TodayItem{
-				{
-					ID:          "today-1",
-					Title:       "Daily standup",
-					Bucket:      TodayBucketScheduled,
-					ScheduledAt: "09:30",
-					Priority:    "High",
-					Tags:        []string{"team"},
-					Notes:       "Share blockers and plan.",
-				},
-				{
-					ID:       "today-2",
-					Title:    "Review pull request",
-					Bucket:   TodayBucketAnytime,
-					Priority: "Medium",
-					Tags:     []string{"code"},
-					Notes:    "Check tests and architecture changes.",
-				},
-				{
-					ID:       "today-3",
-					Title:    "Submit tax docs",
-					Bucket:   TodayBucketOverdue,
-					DueAt:    "Yesterday",
-					Priority: "Critical",
-					Tags:     []string{"finance"},
-					Notes:    "Overdue since yesterday evening.",
-				},
-			},
 		},
 		Calendar: CalendarState{
 			Mode:      CalendarModeWeek,
-			FocusDate: time.Date(2026, 2, 9, 0, 0, 0, 0, time.UTC),
-			Items: []AgendaItem{
-				{ID: "ag-1", Title: "Design review", Date: "2026-02-09", Time: "11:00", Kind: "event"},
-				{ID: "ag-2", Title: "Write migration docs", Date: "2026-02-09", Time: "14:00", Kind: "task"},
-				{ID: "ag-3", Title: "Gym", Date: "2026-02-10", Time: "18:30", Kind: "event"},
-			},
+			FocusDate: startOfLocalDay(time.Now()),
 		},
 		Focus: FocusState{
 			WorkDurationSec:  25 * 60,
@@ -351,6 +368,7 @@ func NewModel() Model {
 		CompletedTasks: make(map[string]bool),
 		DesktopEnabled: false,
 		notifier:       NoopDesktopNotifier{},
+		clock:          clock.Real{},
 		Productivity: ProductivityState{
 			AvailableMinutes: 60,
 		},
@@ -361,10 +379,14 @@ func NewModel() Model {
 			Focus:    "4",
 			Help:     "?",
 			Quit:     "q",
+			Undo:     "ctrl+z",
+			Redo:     "ctrl+r",
 		},
 		recurrenceEditor: RecurrenceEditorState{
 			RuleType:     "every_n_days",
 			IntervalText: "1",
+			EndsText:     "never",
+			Field:        RecurrenceFieldRule,
 		},
 		todayCollapsed: map[TodayBucket]bool{
 			TodayBucketScheduled: false,
@@ -394,6 +416,11 @@ func NewModelWithRuntime(engine *scheduler.Engine, desktopEnabled bool, notifier
 func NewModelWithConfig(engine *scheduler.Engine, notifier DesktopNotifier, cfg RuntimeConfig) Model {
 	m := NewModel()
 	m.Scheduler = engine
+	m.catchUp = cfg.CatchUpPolicy
+	m.clock = clock.OrReal(cfg.Clock)
+	m.refreshInterval = cfg.RefreshInterval
+	m.syncer = cfg.Syncer
+	m.Calendar.FocusDate = startOfLocalDay(m.now())
 	m.DesktopEnabled = cfg.DesktopNotifications
 	m.stateFilePath = strings.TrimSpace(cfg.CompletionStatePath)
 	if notifier != nil {
@@ -417,3 +444,29 @@ func NewModelWithConfig(engine *scheduler.Engine, notifier DesktopNotifier, cfg
 	m.refreshProductivitySignals()
 	return m
 }
+
+// NewModelWithRepository builds a configured model whose Inbox, Today and
+// Calendar views are loaded from repo and whose mutations are written back to it.
+func NewModelWithRepository(engine *scheduler.Engine, notifier DesktopNotifier, repo storage.Repository, cfg RuntimeConfig) Model {
+	m := NewModelWithConfig(engine, notifier, cfg)
+	m.repo = repo.WithSource(storage.SourceTUI)
+	if m.syncer == nil && cfg.SyncDir != "" {
+		m.syncer = syncer.NewDir(m.repo, cfg.SyncDir, m.clock)
+	}
+	now := m.now()
+	// Read the sequence first so writes racing the load are picked up by
+	// the next poll rather than lost.
+	if seq, err := repo.LatestChangeSeq(context.Background()); err == nil {
+		m.changeSeq = seq
+	}
+	if err := m.reloadFromRepository(now); err != nil {
+		m.LastError = err
+		m.Status = StatusBar{Text: err.Error(), IsError: true}
+	} else if err := m.restoreScheduler(now); err != nil {
+		m.LastError = err
+		m.Status = StatusBar{Text: err.Error(), IsError: true}
+	}
+	m.refreshHistory()
+	m.syncBubbleData()
+	return m
+}
@@ -6,6 +6,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/sandeepkv93/taskd/internal/commands"
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/when"
 )
 
 func (m Model) handlePaletteKey(msg tea.KeyMsg) Model {
@@ -46,26 +48,53 @@ func (m Model) executePaletteCommand() Model {
 	res, err := commands.Execute(cmd, commands.Handlers{
 		Add: func(a commands.AddArgs) (commands.Result, error) {
 			m.CurrentView = ViewInbox
-			m.addInboxItem(a.Title)
+			if err := m.addInboxTask(a); err != nil {
+				return commands.Result{}, err
+			}
 			return commands.Result{Message: fmt.Sprintf("added inbox task: %s", a.Title)}, nil
 		},
 		Snooze: func(s commands.SnoozeArgs) (commands.Result, error) {
+			until, err := when.Parse(s.For, m.now())
+			if err != nil {
+				return commands.Result{}, &commands.CommandError{Code: commands.ErrCodeInvalidArgument, Message: err.Error()}
+			}
+			label := until.Format("2006-01-02 15:04")
 			applied := 0
+			var ids []string
+			for _, item := range m.Today.Items {
+				if strings.EqualFold(s.Target, "overdue") && item.Bucket == TodayBucketOverdue {
+					ids = append(ids, item.ID)
+				}
+			}
+			step := m.beginUndo(ids...)
+			defer func() { m.commitUndo(step, fmt.Sprintf("snooze %d task(s)", applied)) }()
 			for i := range m.Today.Items {
 				if strings.EqualFold(s.Target, "overdue") && m.Today.Items[i].Bucket == TodayBucketOverdue {
+					err := m.updateStoredTask(m.Today.Items[i].ID, func(task *storage.Task) {
+						at := until.UTC()
+						task.State = "Snoozed"
+						task.ScheduledAt = &at
+					})
+					if err != nil {
+						return commands.Result{}, err
+					}
 					m.Today.Items[i].Bucket = TodayBucketAnytime
-					m.Today.Items[i].Notes = strings.TrimSpace(m.Today.Items[i].Notes + " | snoozed " + s.For)
+					m.Today.Items[i].ScheduledAt = until.Format("15:04")
+					m.Today.Items[i].Snoozes++
 					applied++
 				}
 			}
 			if applied == 0 {
 				return commands.Result{}, &commands.CommandError{Code: commands.ErrCodeInvalidArgument, Message: "no matching items for snooze target"}
 			}
-			return commands.Result{Message: fmt.Sprintf("snoozed %d task(s) for %s", applied, s.For)}, nil
+			return commands.Result{Message: fmt.Sprintf("snoozed %d task(s) until %s", applied, label)}, nil
 		},
 		Show: func(s commands.ShowArgs) (commands.Result, error) {
+			m.Filter.Tag = s.Tag
+			if err := m.reloadFromRepository(m.now()); err != nil {
+				return commands.Result{}, err
+			}
 			if s.Tag != "" {
-				m.Filter.Tag = s.Tag
 				return commands.Result{Message: fmt.Sprintf("show filter applied: tag=%s", s.Tag)}, nil
 			}
 			return commands.Result{Message: fmt.Sprintf("show %s", s.Subject)}, nil
@@ -74,18 +103,42 @@ func (m Model) executePaletteCommand() Model {
 			if r.Target != "selected" {
 				return commands.Result{}, &commands.CommandError{Code: commands.ErrCodeInvalidArgument, Message: "reschedule currently supports target: selected"}
 			}
-			applied := 0
-			for i := range m.Inbox.Items {
-				item := m.Inbox.Items[i]
-				if m.Inbox.Selected[item.ID] {
-					m.Inbox.Items[i].ScheduledFor = r.When
-					applied++
-				}
+			at, err := when.Parse(r.When, m.now())
+			if err != nil {
+				return commands.Result{}, &commands.CommandError{Code: commands.ErrCodeInvalidArgument, Message: err.Error()}
+			}
+			applied, err := m.scheduleSelectedInbox(at, r.Due)
+			if err != nil {
+				return commands.Result{}, err
 			}
 			if applied == 0 {
 				return commands.Result{}, &commands.CommandError{Code: commands.ErrCodeInvalidArgument, Message: "no selected inbox items to reschedule"}
 			}
-			return commands.Result{Message: fmt.Sprintf("rescheduled %d selected item(s) to %s", applied, r.When)}, nil
+			if r.Due {
+				return commands.Result{Message: fmt.Sprintf("set %d selected item(s) due %s", applied, at.Format("Mon 2006-01-02 15:04"))}, nil
+			}
+			return commands.Result{Message: fmt.Sprintf("rescheduled %d selected item(s) to %s", applied, at.Format("Mon 2006-01-02 15:04"))}, nil
+		},
+		Find: func(f commands.FindArgs) (commands.Result, error) {
+			if m.repo == nil {
+				return commands.Result{}, &commands.CommandError{Code: commands.ErrCodeInvalidArgument, Message: "find requires a task database"}
+			}
+			hit, ok, err := m.jumpToSearchHit(f.Query)
+			if err != nil {
+				return commands.Result{}, err
+			}
+			if !ok {
+				return commands.Result{}, &commands.CommandError{Code: commands.ErrCodeInvalidArgument, Message: fmt.Sprintf("no open task matches %q", f.Query)}
+			}
+			return commands.Result{Message: fmt.Sprintf("found %s: %s", hit.Task.Title, hit.Snippet)}, nil
+		},
+		Undo: func() (commands.Result, error) {
+			msg, err := m.undoLast()
+			return commands.Result{Message: msg}, err
+		},
+		Redo: func() (commands.Result, error) {
+			msg, err := m.redoLast()
+			return commands.Result{Message: msg}, err
 		},
 	})
 	if err != nil {
@@ -2,7 +2,6 @@ package update
 
 import (
 	"strings"
-	"time"
 
 	"github.com/sandeepkv93/taskd/internal/views"
 )
@@ -50,16 +49,26 @@ func (m Model) renderTodayMetadataPane() string {
 		Tags:             selected.Tags,
 		NotesEditorView:  m.notesArea.View(),
 		MarkdownMetaView: m.metaViewport.View(),
+		History:          m.historyLines(selected.ID),
 	})
 }
 
 func (m Model) renderRecurrenceEditorIfVisible() string {
+	exceptions := make([]string, 0, len(m.recurrenceEditor.Exceptions))
+	for _, ex := range m.recurrenceEditor.Exceptions {
+		exceptions = append(exceptions, describeRecurrenceException(ex))
+	}
 	return views.RenderRecurrenceEditor(views.RecurrenceEditorData{
-		Active:       m.recurrenceEditor.Active,
-		RuleType:     m.recurrenceEditor.RuleType,
-		IntervalText: m.recurrenceEditor.IntervalText,
-		ErrorText:    m.recurrenceEditor.Err,
-		Preview:      m.recurrenceEditor.Preview,
+		Active:        m.recurrenceEditor.Active,
+		RuleType:      m.recurrenceEditor.RuleType,
+		IntervalText:  m.recurrenceEditor.IntervalText,
+		RRuleText:     m.recurrenceEditor.RRuleText,
+		EndsText:      m.recurrenceEditor.EndsText,
+		ExceptionText: m.recurrenceEditor.ExceptionText,
+		Exceptions:    exceptions,
+		Field:         string(m.recurrenceEditor.Field),
+		ErrorText:     m.recurrenceEditor.Err,
+		Preview:       m.recurrenceEditor.Preview,
 	})
 }
 
@@ -71,7 +80,7 @@ func (m *Model) notify(title, body, level string) {
 		Title: title,
 		Body:  body,
 		Level: level,
-		At:    time.Now().UTC(),
+		At:    m.now().UTC(),
 	}
 	m.Notifications = append(m.Notifications, n)
 	if len(m.Notifications) > 40 {
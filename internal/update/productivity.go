@@ -25,8 +25,7 @@ func (m Model) computeTemporalDebtScore() int {
 		if item.Bucket == TodayBucketOverdue {
 			score += 2
 		}
-		notes := strings.ToLower(item.Notes)
-		if strings.Contains(notes, "snoozed") {
+		if item.Snoozes > 0 {
 			score++
 		}
 		if item.Priority == "Critical" && item.Bucket == TodayBucketOverdue {
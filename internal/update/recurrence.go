@@ -1,12 +1,16 @@
 package update
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	domainmodel "github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/when"
 )
 
 func (m Model) handleRecurrenceEditorKey(msg tea.KeyMsg) Model {
@@ -25,36 +29,169 @@ func (m Model) handleRecurrenceEditorKey(msg tea.KeyMsg) Model {
 			m.recurrenceEditor.RuleType = "last_day_of_month"
 		case "last_day_of_month":
 			m.recurrenceEditor.RuleType = "after_completion"
+		case "after_completion":
+			m.recurrenceEditor.RuleType = "rrule"
 		default:
 			m.recurrenceEditor.RuleType = "every_weekday"
 		}
+	case "down":
+		switch m.recurrenceEditor.Field {
+		case RecurrenceFieldRule:
+			m.recurrenceEditor.Field = RecurrenceFieldEnds
+		case RecurrenceFieldEnds:
+			m.recurrenceEditor.Field = RecurrenceFieldException
+		default:
+			m.recurrenceEditor.Field = RecurrenceFieldRule
+		}
+	case "up":
+		switch m.recurrenceEditor.Field {
+		case RecurrenceFieldException:
+			m.recurrenceEditor.Field = RecurrenceFieldEnds
+		case RecurrenceFieldEnds:
+			m.recurrenceEditor.Field = RecurrenceFieldRule
+		default:
+			m.recurrenceEditor.Field = RecurrenceFieldException
+		}
 	case "enter":
+		if m.recurrenceEditor.Field == RecurrenceFieldException && strings.TrimSpace(m.recurrenceEditor.ExceptionText) != "" {
+			if !m.addRecurrenceException() {
+				return m
+			}
+		}
 		m.computeRecurrencePreview()
+	case "ctrl+d":
+		if n := len(m.recurrenceEditor.Exceptions); n > 0 {
+			m.recurrenceEditor.Exceptions = m.recurrenceEditor.Exceptions[:n-1]
+			m.computeRecurrencePreview()
+		}
+	case "ctrl+s":
+		m.saveRecurrence()
 	case "backspace":
-		if len(m.recurrenceEditor.IntervalText) > 0 {
-			m.recurrenceEditor.IntervalText = m.recurrenceEditor.IntervalText[:len(m.recurrenceEditor.IntervalText)-1]
+		field := m.recurrenceEditor.editedField()
+		if len(*field) > 0 {
+			*field = (*field)[:len(*field)-1]
 		}
 	default:
-		if msg.Type == tea.KeyRunes {
-			m.recurrenceEditor.IntervalText += string(msg.Runes)
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			*m.recurrenceEditor.editedField() += string(msg.Runes)
 		}
 	}
 	return m
 }
 
-func (m *Model) computeRecurrencePreview() {
-	interval := 1
-	if v := strings.TrimSpace(m.recurrenceEditor.IntervalText); v != "" {
+// editedField is the text typed keys go to: the ends or exception line when
+// selected, else the rule text for an rrule and the interval otherwise.
+func (e *RecurrenceEditorState) editedField() *string {
+	switch e.Field {
+	case RecurrenceFieldEnds:
+		return &e.EndsText
+	case RecurrenceFieldException:
+		return &e.ExceptionText
+	}
+	if e.RuleType == string(domainmodel.RecurrenceRRule) {
+		return &e.RRuleText
+	}
+	return &e.IntervalText
+}
+
+// openRecurrenceEditor shows the editor for the selected Today task, loaded
+// with the task's enabled rule when it has one. A new rule is anchored at
+// the task's scheduled or due time, else now.
+func (m *Model) openRecurrenceEditor() {
+	now := m.now()
+	e := RecurrenceEditorState{
+		Active:       true,
+		RuleType:     "every_n_days",
+		IntervalText: "1",
+		EndsText:     "never",
+		Field:        RecurrenceFieldRule,
+		base:         domainmodel.RecurrenceRule{Anchor: now},
+	}
+	item, ok := m.currentTodayItem()
+	if !ok || m.repo == nil {
+		m.recurrenceEditor = e
+		return
+	}
+	e.TaskID = item.ID
+	ctx := context.Background()
+	task, err := m.repo.GetTask(ctx, item.ID)
+	if err != nil {
+		e.Err = fmt.Sprintf("load task %s: %v", item.ID, err)
+		m.recurrenceEditor = e
+		return
+	}
+	switch {
+	case task.ScheduledAt != nil:
+		e.base.Anchor = task.ScheduledAt.In(now.Location())
+	case task.DueAt != nil:
+		e.base.Anchor = task.DueAt.In(now.Location())
+	}
+	enabled := true
+	rules, err := m.repo.ListRecurrences(ctx, storage.RecurrenceListFilter{TaskID: item.ID, Enabled: &enabled})
+	if err != nil {
+		e.Err = fmt.Sprintf("load recurrence: %v", err)
+		m.recurrenceEditor = e
+		return
+	}
+	if len(rules) == 0 {
+		m.recurrenceEditor = e
+		return
+	}
+	rule, err := storage.RecurrenceToModel(rules[0])
+	if err != nil {
+		e.Err = fmt.Sprintf("load recurrence %s: %v", rules[0].ID, err)
+		m.recurrenceEditor = e
+		return
+	}
+	e.RuleID = rules[0].ID
+	e.base = rule
+	e.RuleType = string(rule.Type)
+	e.IntervalText = strconv.Itoa(rule.Interval)
+	e.RRuleText = rule.RRule
+	e.EndsText = formatRecurrenceEnds(rule)
+	e.Exceptions = append([]domainmodel.RecurrenceException(nil), rule.Exceptions...)
+	m.recurrenceEditor = e
+	m.computeRecurrencePreview()
+}
+
+// editedRecurrence is the rule the editor describes: the loaded rule, or a
+// new one, with the type, interval, rule text, end and exceptions typed in.
+func (m *Model) editedRecurrence() (domainmodel.RecurrenceRule, error) {
+	e := &m.recurrenceEditor
+	rule := e.base
+	if rule.Anchor.IsZero() {
+		rule.Anchor = m.now()
+	}
+	if string(rule.Type) != e.RuleType {
+		rule.Weekdays, rule.AfterCompleteIn = nil, 0
+	}
+	rule.Type = domainmodel.RecurrenceType(e.RuleType)
+	rule.Interval, rule.RRule = 1, ""
+	if v := strings.TrimSpace(e.IntervalText); v != "" {
 		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
-			interval = parsed
+			rule.Interval = parsed
 		}
 	}
-	rule := domainmodel.RecurrenceRule{
-		Type:     domainmodel.RecurrenceType(m.recurrenceEditor.RuleType),
-		Interval: interval,
-		Anchor:   time.Now().UTC(),
+	if rule.Type == domainmodel.RecurrenceRRule {
+		rule.Interval, rule.RRule = 1, strings.TrimSpace(e.RRuleText)
+	}
+	var err error
+	rule.Until, rule.Count, err = parseRecurrenceEnds(e.EndsText, m.now().In(rule.Anchor.Location()))
+	if err != nil {
+		return domainmodel.RecurrenceRule{}, err
+	}
+	rule.Exceptions = e.Exceptions
+	return rule, rule.Validate()
+}
+
+func (m *Model) computeRecurrencePreview() {
+	rule, err := m.editedRecurrence()
+	if err != nil {
+		m.recurrenceEditor.Err = err.Error()
+		m.recurrenceEditor.Preview = nil
+		return
 	}
-	preview, err := rule.Preview(time.Now().UTC(), nil, 5)
+	preview, err := rule.Preview(m.now(), nil, 5)
 	if err != nil {
 		m.recurrenceEditor.Err = err.Error()
 		m.recurrenceEditor.Preview = nil
@@ -66,3 +203,196 @@ func (m *Model) computeRecurrencePreview() {
 		m.recurrenceEditor.Preview = append(m.recurrenceEditor.Preview, item.Format("2006-01-02 15:04"))
 	}
 }
+
+// addRecurrenceException parses the exception line into the edited rule's
+// exceptions and clears it. It reports false, with the error shown, when
+// the line or the resulting rule is invalid.
+func (m *Model) addRecurrenceException() bool {
+	e := &m.recurrenceEditor
+	rule, err := m.editedRecurrence()
+	if err == nil {
+		var ex domainmodel.RecurrenceException
+		if ex, err = parseRecurrenceException(e.ExceptionText, rule, m.now()); err == nil {
+			rule.Exceptions = append(append([]domainmodel.RecurrenceException(nil), e.Exceptions...), ex)
+			if err = rule.Validate(); err == nil {
+				e.Exceptions = rule.Exceptions
+				e.ExceptionText = ""
+				return true
+			}
+		}
+	}
+	e.Err = err.Error()
+	e.Preview = nil
+	return false
+}
+
+// saveRecurrence stores the edited rule on the editor's task, creating it
+// when the task has none. When the change drops the occurrence the task
+// stands for, the task and its reminders move to the next one.
+func (m *Model) saveRecurrence() {
+	e := &m.recurrenceEditor
+	if m.repo == nil || e.TaskID == "" {
+		e.Err = "select a task in Today to save its recurrence"
+		return
+	}
+	rule, err := m.editedRecurrence()
+	if err != nil {
+		e.Err = err.Error()
+		return
+	}
+	now := m.now()
+	ctx := context.Background()
+	ruleID := e.RuleID
+	var moved *storage.Task
+	step := m.beginUndo(e.TaskID)
+	err = m.repo.WithTx(ctx, func(tx storage.Repository) error {
+		if ruleID == "" {
+			ruleID = domainmodel.NewID("rec")
+			return tx.CreateRecurrence(ctx, storage.RecurrenceFromModel(ruleID, e.TaskID, rule, now))
+		}
+		current, err := tx.GetRecurrence(ctx, ruleID)
+		if err != nil {
+			return fmt.Errorf("load recurrence %s: %w", ruleID, err)
+		}
+		next := storage.RecurrenceFromModel(ruleID, current.TaskID, rule, current.CreatedAt)
+		next.NextAt, next.Enabled = current.NextAt, current.Enabled
+		if next.Exceptions == nil {
+			// An empty list clears the stored exceptions; nil would keep them.
+			next.Exceptions = []storage.RecurrenceException{}
+		}
+		if err := tx.UpdateRecurrence(ctx, next); err != nil {
+			return fmt.Errorf("update recurrence %s: %w", ruleID, err)
+		}
+		moved, err = storage.ReplanOccurrence(ctx, tx, current, next)
+		return err
+	})
+	if err != nil {
+		e.Err = fmt.Sprintf("save recurrence failed: %v", err)
+		return
+	}
+	e.RuleID = ruleID
+	e.Active = false
+	m.commitUndo(step, "edit recurrence")
+
+	status := "recurrence saved"
+	if moved != nil {
+		m.cancelTaskReminders(moved.ID)
+		if err := m.armTaskReminders(moved.ID); err != nil {
+			m.Status = StatusBar{Text: fmt.Sprintf("schedule moved occurrence failed: %v", err), IsError: true}
+			return
+		}
+		at := moved.ScheduledAt
+		if at == nil {
+			at = moved.DueAt
+		}
+		status = fmt.Sprintf("recurrence saved; task moved to %s", at.In(now.Location()).Format("Mon 2006-01-02 15:04"))
+	}
+	if err := m.reloadKeepingCursors(now); err != nil {
+		m.Status = StatusBar{Text: fmt.Sprintf("reload after recurrence change failed: %v", err), IsError: true}
+		return
+	}
+	m.Status = StatusBar{Text: status, IsError: false}
+}
+
+// parseRecurrenceEnds reads the editor's ends line: "never" (or nothing),
+// "until <day>", which keeps every occurrence on that day, or "after <n>"
+// occurrences.
+func parseRecurrenceEnds(text string, now time.Time) (until time.Time, count int, err error) {
+	keyword, rest, _ := strings.Cut(strings.TrimSpace(text), " ")
+	rest = strings.TrimSpace(rest)
+	switch {
+	case keyword == "" || (strings.EqualFold(keyword, "never") && rest == ""):
+		return time.Time{}, 0, nil
+	case strings.EqualFold(keyword, "until") && rest != "":
+		day, err := when.Parse(rest, now)
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		return startOfLocalDay(day).AddDate(0, 0, 1).Add(-time.Nanosecond), 0, nil
+	case strings.EqualFold(keyword, "after") && rest != "":
+		n, err := strconv.Atoi(strings.Fields(rest)[0])
+		if err != nil || n <= 0 {
+			return time.Time{}, 0, fmt.Errorf("%w: %q is not a number of occurrences", domainmodel.ErrInvalidRecurrenceEnd, rest)
+		}
+		return time.Time{}, n, nil
+	}
+	return time.Time{}, 0, fmt.Errorf("%w: %q (use never, until <day> or after <n>)", domainmodel.ErrInvalidRecurrenceEnd, text)
+}
+
+func formatRecurrenceEnds(rule domainmodel.RecurrenceRule) string {
+	switch {
+	case !rule.Until.IsZero():
+		return "until " + rule.Until.In(rule.Anchor.Location()).Format("2006-01-02")
+	case rule.Count > 0:
+		return fmt.Sprintf("after %d", rule.Count)
+	}
+	return "never"
+}
+
+// parseRecurrenceException reads "skip <day>", "move <day> to <when>" or
+// "pause <day> to <day>". Days are resolved in the rule's timezone and name
+// the occurrence the schedule puts on them; a move's target is read
+// relative to that occurrence, so "move fri to 15:00" stays on Friday, and
+// a pause covers both days.
+func parseRecurrenceException(text string, rule domainmodel.RecurrenceRule, now time.Time) (domainmodel.RecurrenceException, error) {
+	verb, rest, _ := strings.Cut(strings.TrimSpace(text), " ")
+	from, to, hasTo := strings.Cut(rest, " to ")
+	now = now.In(rule.Anchor.Location())
+	occurrence := func(phrase string) (time.Time, error) {
+		day, err := when.Parse(phrase, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return rule.OccurrenceOn(day)
+	}
+	switch strings.ToLower(verb) {
+	case string(domainmodel.ExceptionSkip):
+		if hasTo {
+			break
+		}
+		at, err := occurrence(rest)
+		if err != nil {
+			return domainmodel.RecurrenceException{}, err
+		}
+		return domainmodel.RecurrenceException{Kind: domainmodel.ExceptionSkip, At: at}, nil
+	case string(domainmodel.ExceptionMove):
+		if !hasTo {
+			break
+		}
+		at, err := occurrence(from)
+		if err != nil {
+			return domainmodel.RecurrenceException{}, err
+		}
+		target, err := when.Parse(to, at)
+		if err != nil {
+			return domainmodel.RecurrenceException{}, err
+		}
+		return domainmodel.RecurrenceException{Kind: domainmodel.ExceptionMove, At: at, To: target}, nil
+	case string(domainmodel.ExceptionPause):
+		if !hasTo {
+			break
+		}
+		first, err := when.Parse(from, now)
+		if err != nil {
+			return domainmodel.RecurrenceException{}, err
+		}
+		last, err := when.Parse(to, now)
+		if err != nil {
+			return domainmodel.RecurrenceException{}, err
+		}
+		return domainmodel.RecurrenceException{Kind: domainmodel.ExceptionPause, At: startOfLocalDay(first), To: startOfLocalDay(last).AddDate(0, 0, 1)}, nil
+	}
+	return domainmodel.RecurrenceException{}, fmt.Errorf("%w: %q (use skip <day>, move <day> to <when> or pause <day> to <day>)", domainmodel.ErrInvalidException, text)
+}
+
+// describeRecurrenceException is how the editor lists an exception.
+func describeRecurrenceException(ex domainmodel.RecurrenceException) string {
+	const layout = "Mon 2006-01-02 15:04"
+	switch ex.Kind {
+	case domainmodel.ExceptionMove:
+		return fmt.Sprintf("move %s to %s", ex.At.Format(layout), ex.To.Format(layout))
+	case domainmodel.ExceptionPause:
+		return fmt.Sprintf("pause %s to %s", ex.At.Format("Mon 2006-01-02"), ex.To.AddDate(0, 0, -1).Format("Mon 2006-01-02"))
+	}
+	return fmt.Sprintf("%s %s", ex.Kind, ex.At.Format(layout))
+}
@@ -0,0 +1,173 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// ExternalChangesMsg reports the change-log rows written by other taskd
+// processes since the last poll. Seq is the newest row seen, ours included.
+type ExternalChangesMsg struct {
+	Seq     int64
+	Changes []storage.Change
+	Err     error
+}
+
+// pollChangesCmd waits one refresh interval and then reads the change log
+// after since. The timer is armed when the command is built so a fake clock
+// sees it straight away.
+func pollChangesCmd(repo storage.Repository, c clock.Clock, interval time.Duration, since int64) tea.Cmd {
+	if repo == nil || interval <= 0 {
+		return nil
+	}
+	after := clock.OrReal(c).After(interval)
+	return func() tea.Msg {
+		<-after
+		return readExternalChanges(repo, since)
+	}
+}
+
+func readExternalChanges(repo storage.Repository, since int64) ExternalChangesMsg {
+	changes, err := repo.ChangesSince(context.Background(), since)
+	if err != nil {
+		return ExternalChangesMsg{Seq: since, Err: err}
+	}
+	msg := ExternalChangesMsg{Seq: since}
+	origin := repo.Origin()
+	for _, change := range changes {
+		msg.Seq = change.Seq
+		if change.Origin != origin {
+			msg.Changes = append(msg.Changes, change)
+		}
+	}
+	return msg
+}
+
+func (m Model) onExternalChanges(msg ExternalChangesMsg) (tea.Model, tea.Cmd) {
+	next := pollChangesCmd(m.repo, m.clock, m.refreshInterval, msg.Seq)
+	if msg.Err != nil {
+		m.Status = StatusBar{Text: fmt.Sprintf("check for changes: %v", msg.Err), IsError: true}
+		return m, next
+	}
+	m.changeSeq = msg.Seq
+	if len(msg.Changes) == 0 {
+		return m, next
+	}
+
+	selected := m.SelectedTaskID
+	var conflict *storage.Change
+	for i := range msg.Changes {
+		change := msg.Changes[i]
+		if selected != "" && change.Entity == storage.ChangeTask && change.EntityID == selected {
+			conflict = &change
+		}
+	}
+	if err := m.reloadKeepingCursors(m.now()); err != nil {
+		m.LastError = err
+		m.Status = StatusBar{Text: err.Error(), IsError: true}
+		return m, next
+	}
+	switch {
+	case conflict != nil && conflict.Op == storage.ChangeDelete:
+		m.ConflictTaskID = conflict.EntityID
+		m.Status = StatusBar{Text: fmt.Sprintf("conflict: %s was deleted by another taskd", conflict.EntityID), IsError: true}
+	case conflict != nil:
+		m.ConflictTaskID = conflict.EntityID
+		m.Status = StatusBar{Text: fmt.Sprintf("conflict: %s was changed by another taskd; showing its latest version", conflict.EntityID), IsError: true}
+	default:
+		m.Status = StatusBar{Text: fmt.Sprintf("refreshed: %d change(s) from another taskd", len(msg.Changes))}
+	}
+	return m, next
+}
+
+// reloadKeepingCursors reloads from the repository and puts each view's cursor
+// back on the item it was on, falling back to the same position when that
+// item is gone.
+func (m *Model) reloadKeepingCursors(now time.Time) error {
+	selected := m.SelectedTaskID
+	inboxID := ""
+	if m.Inbox.Cursor >= 0 && m.Inbox.Cursor < len(m.Inbox.Items) {
+		inboxID = m.Inbox.Items[m.Inbox.Cursor].ID
+	}
+	todayID, calendarID := "", ""
+	if item, ok := m.currentTodayItem(); ok {
+		todayID = item.ID
+	}
+	if item, ok := m.currentAgendaItem(); ok {
+		calendarID = item.ID
+	}
+	inboxCursor, todayCursor, calendarCursor := m.Inbox.Cursor, m.Today.Cursor, m.Calendar.Cursor
+
+	if err := m.reloadFromRepository(now); err != nil {
+		return err
+	}
+
+	present := make(map[string]bool)
+	m.Inbox.Cursor = inboxCursor
+	for i, item := range m.Inbox.Items {
+		present[item.ID] = true
+		if item.ID == inboxID {
+			m.Inbox.Cursor = i
+		}
+	}
+	m.Inbox.Cursor = clampCursor(m.Inbox.Cursor, len(m.Inbox.Items))
+	m.Today.Cursor = todayCursorFor(m.Today.Items, todayID, todayCursor)
+	m.Calendar.Cursor = calendarCursorFor(m.Calendar.Items, calendarID, calendarCursor)
+	for _, item := range m.Today.Items {
+		present[item.ID] = true
+	}
+	for _, item := range m.Calendar.Items {
+		present[item.ID] = true
+	}
+	for id := range m.Inbox.Selected {
+		if !present[id] {
+			delete(m.Inbox.Selected, id)
+		}
+	}
+
+	switch {
+	case present[selected]:
+		m.SelectedTaskID = selected
+	case m.CurrentView == ViewCalendar:
+		m.syncSelectedTaskToCalendarCursor()
+	case m.CurrentView == ViewInbox && len(m.Inbox.Items) > 0:
+		m.SelectedTaskID = m.Inbox.Items[m.Inbox.Cursor].ID
+	default:
+		m.syncSelectedTaskToTodayCursor()
+	}
+	return nil
+}
+
+func todayCursorFor(items []TodayItem, id string, fallback int) int {
+	for i, item := range items {
+		if id != "" && item.ID == id {
+			return i
+		}
+	}
+	return clampCursor(fallback, len(items))
+}
+
+func calendarCursorFor(items []AgendaItem, id string, fallback int) int {
+	for i, item := range items {
+		if id != "" && item.ID == id {
+			return i
+		}
+	}
+	return clampCursor(fallback, len(items))
+}
+
+func clampCursor(cursor, n int) int {
+	if cursor >= n {
+		cursor = n - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
@@ -0,0 +1,123 @@
+package update
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// openSharedRepos opens two repositories on one database file, standing in
+// for two taskd processes.
+func openSharedRepos(t *testing.T) (*storage.SQLiteRepository, *storage.SQLiteRepository) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "taskd-shared.db")
+	open := func() *storage.SQLiteRepository {
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+		if err := storage.MigrateUp(db); err != nil {
+			t.Fatalf("migrate up: %v", err)
+		}
+		repo, err := storage.NewSQLiteRepository(db)
+		if err != nil {
+			t.Fatalf("new repo: %v", err)
+		}
+		return repo
+	}
+	return open(), open()
+}
+
+// pollOnce arms a poll, lets the refresh interval pass and feeds the result
+// back into the model.
+func pollOnce(t *testing.T, m Model, fake *clock.Fake) Model {
+	t.Helper()
+	cmd := pollChangesCmd(m.repo, fake, m.refreshInterval, m.changeSeq)
+	if cmd == nil {
+		t.Fatalf("expected a poll command")
+	}
+	fake.Advance(m.refreshInterval)
+	updated, next := m.Update(cmd())
+	if next == nil {
+		t.Fatalf("expected the poll to be re-armed")
+	}
+	return updated.(Model)
+}
+
+func TestExternalChangesRefreshKeepingCursorAndFlagConflicts(t *testing.T) {
+	repo, other := openSharedRepos(t)
+	ctx := context.Background()
+	start := time.Date(2026, 2, 11, 14, 0, 0, 0, time.Local)
+	for i, id := range []string{"a", "b", "c"} {
+		seedStoreTask(t, repo, storage.Task{ID: id, Title: "task " + id, State: "Planned", CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	fake := clock.NewFake(start)
+	cfg := storeTestConfig(t)
+	cfg.Clock = fake
+	cfg.RefreshInterval = 2 * time.Second
+	m := NewModelWithRepository(nil, nil, repo, cfg)
+	m.CurrentView = ViewToday
+	m.Today.Cursor = 1
+	m.syncSelectedTaskToTodayCursor()
+	if m.SelectedTaskID != "b" {
+		t.Fatalf("expected b selected, got %q", m.SelectedTaskID)
+	}
+
+	// A task created earlier by the other process lands above the cursor.
+	seedStoreTask(t, other, storage.Task{ID: "first", Title: "from elsewhere", State: "Planned", CreatedAt: start.Add(-time.Hour)})
+	m = pollOnce(t, m, fake)
+	if item, _ := m.currentTodayItem(); item.ID != "b" || m.Today.Cursor != 2 {
+		t.Fatalf("expected cursor to stay on b, got %+v at %d", item, m.Today.Cursor)
+	}
+	if len(m.Today.Items) != 4 || m.Status.IsError || !strings.Contains(m.Status.Text, "1 change(s)") {
+		t.Fatalf("unexpected refresh: %d items, status %+v", len(m.Today.Items), m.Status)
+	}
+
+	// Our own writes are not reported back to us.
+	m.Status = StatusBar{}
+	if err := repo.DeleteTask(ctx, "c"); err != nil {
+		t.Fatalf("delete c: %v", err)
+	}
+	m = pollOnce(t, m, fake)
+	if m.Status.Text != "" {
+		t.Fatalf("expected own write to be ignored, got %+v", m.Status)
+	}
+
+	task, err := other.GetTask(ctx, "b")
+	if err != nil {
+		t.Fatalf("get b: %v", err)
+	}
+	task.Title = "renamed elsewhere"
+	if err := other.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("update b: %v", err)
+	}
+	m = pollOnce(t, m, fake)
+	if item, _ := m.currentTodayItem(); item.ID != "b" || item.Title != "renamed elsewhere" {
+		t.Fatalf("expected the latest b under the cursor, got %+v", item)
+	}
+	if m.ConflictTaskID != "b" || !m.Status.IsError || !strings.Contains(m.Status.Text, "conflict: b was changed") {
+		t.Fatalf("expected a conflict on b, got %q %+v", m.ConflictTaskID, m.Status)
+	}
+	if !strings.Contains(m.View(), "[conflict: b changed elsewhere]") {
+		t.Fatalf("expected the conflict in the status line")
+	}
+
+	if err := other.DeleteTask(ctx, "b"); err != nil {
+		t.Fatalf("delete b: %v", err)
+	}
+	m = pollOnce(t, m, fake)
+	if !strings.Contains(m.Status.Text, "conflict: b was deleted") {
+		t.Fatalf("expected a delete conflict, got %+v", m.Status)
+	}
+	if item, _ := m.currentTodayItem(); m.SelectedTaskID != item.ID || item.ID == "b" {
+		t.Fatalf("expected selection to move off b, got %q on %+v", m.SelectedTaskID, item)
+	}
+}
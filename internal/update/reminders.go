@@ -1,11 +1,13 @@
 package update
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
 )
 
 func (m *Model) applyReminderBehavior(ev scheduler.ReminderEvent, now time.Time) {
@@ -48,6 +50,40 @@ func (m *Model) rescheduleReminder(ev scheduler.ReminderEvent, next time.Time) {
 	}
 }
 
+// cancelTaskReminders drops every queued reminder for taskID, including
+// pending soft and nagging follow-ups.
+func (m *Model) cancelTaskReminders(taskID string) int {
+	if m.Scheduler == nil || taskID == "" {
+		return 0
+	}
+	cancelled := 0
+	for _, ev := range m.Scheduler.Pending() {
+		if ev.TaskID == taskID && m.Scheduler.Cancel(ev.ID) {
+			cancelled++
+		}
+	}
+	return cancelled
+}
+
+// armTaskReminders queues the stored, enabled reminders of taskID, such as
+// the ones carried over to a recurring task's next occurrence.
+func (m *Model) armTaskReminders(taskID string) error {
+	if m.repo == nil || m.Scheduler == nil {
+		return nil
+	}
+	enabled := true
+	reminders, err := m.repo.ListReminders(context.Background(), storage.ReminderListFilter{TaskID: taskID, Enabled: &enabled})
+	if err != nil {
+		return fmt.Errorf("load reminders for %s: %w", taskID, err)
+	}
+	for _, rem := range reminders {
+		if err := m.Scheduler.Schedule(reminderEventFromStore(rem)); err != nil {
+			return fmt.Errorf("schedule reminder %s: %w", rem.ID, err)
+		}
+	}
+	return nil
+}
+
 func inContextualWindowForRule(now time.Time, rule string) bool {
 	cfg := parseContextualRule(rule)
 	if !cfg.allowsWeekday(now.Weekday()) {
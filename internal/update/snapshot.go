@@ -0,0 +1,28 @@
+package update
+
+import (
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// Snapshot is what the Today view and productivity panel would show at one
+// instant, computed exactly as the TUI computes them.
+type Snapshot struct {
+	Today   []TodayItem
+	Signals ProductivitySignals
+}
+
+// LoadSnapshot buckets the tasks in repo as of now and scores them, without
+// starting the TUI. Suggestions are limited to the availableMinutes window.
+func LoadSnapshot(repo storage.Repository, now time.Time, availableMinutes, suggestionLimit int) (Snapshot, error) {
+	m := NewModel()
+	m.repo = repo
+	m.Productivity.AvailableMinutes = availableMinutes
+	if err := m.reloadFromRepository(now); err != nil {
+		return Snapshot{}, err
+	}
+	signals := m.Productivity.Signals
+	signals.Suggestions = m.computeEnergySuggestions(availableMinutes, suggestionLimit)
+	return Snapshot{Today: m.Today.Items, Signals: signals}, nil
+}
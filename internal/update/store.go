@@ -0,0 +1,314 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/commands"
+	domainmodel "github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// reloadFromRepository replaces the Inbox, Today and Calendar items with the
+// rows currently stored in the repository. The Inbox and Today queries honor
+// the active tag/priority filter; the Calendar always shows everything.
+func (m *Model) reloadFromRepository(now time.Time) error {
+	if m.repo == nil {
+		return nil
+	}
+	ctx := context.Background()
+	startOfDay := startOfLocalDay(now)
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+	list := func(label string, filter storage.TaskListFilter) ([]storage.Task, error) {
+		tasks, err := m.repo.ListTasks(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("load %s tasks: %w", label, err)
+		}
+		return tasks, nil
+	}
+
+	inboxTasks, err := list("inbox", m.taskFilter(storage.TaskListFilter{
+		States: []string{"Inbox"},
+		Sort:   storage.TaskSortCreatedAsc,
+	}))
+	if err != nil {
+		return err
+	}
+	scheduled, err := list("scheduled", m.taskFilter(storage.TaskListFilter{
+		States:    []string{"Planned"},
+		Scheduled: storage.TimeRange{From: &startOfDay, To: &endOfDay},
+		Sort:      storage.TaskSortScheduledAsc,
+	}))
+	if err != nil {
+		return err
+	}
+	pastDue, err := list("overdue", m.taskFilter(storage.TaskListFilter{
+		States: []string{"Planned"},
+		Due:    storage.TimeRange{To: &now},
+		Sort:   storage.TaskSortDueAsc,
+	}))
+	if err != nil {
+		return err
+	}
+	missed, err := list("overdue", m.taskFilter(storage.TaskListFilter{
+		States:    []string{"Planned"},
+		Scheduled: storage.TimeRange{To: &startOfDay},
+		Sort:      storage.TaskSortScheduledAsc,
+	}))
+	if err != nil {
+		return err
+	}
+	open, err := list("open", m.taskFilter(storage.TaskListFilter{
+		States: []string{"Planned", "Snoozed"},
+		Sort:   storage.TaskSortCreatedAsc,
+	}))
+	if err != nil {
+		return err
+	}
+	all, err := list("calendar", storage.TaskListFilter{Sort: storage.TaskSortScheduledAsc})
+	if err != nil {
+		return err
+	}
+	enabled := true
+	reminders, err := m.repo.ListReminders(ctx, storage.ReminderListFilter{Enabled: &enabled})
+	if err != nil {
+		return fmt.Errorf("load reminders: %w", err)
+	}
+	inbox := make([]InboxItem, 0, len(inboxTasks))
+	for _, task := range inboxTasks {
+		inbox = append(inbox, inboxItemFromTask(task, now))
+	}
+
+	// A task that is past due wins over being scheduled today; whatever is
+	// left without a schedule (or snoozed) is Anytime.
+	placed := make(map[string]bool)
+	overdue := make([]TodayItem, 0)
+	for _, task := range append(pastDue, missed...) {
+		if placed[task.ID] {
+			continue
+		}
+		placed[task.ID] = true
+		overdue = append(overdue, todayItemFromTask(task, TodayBucketOverdue, now))
+	}
+	today := make([]TodayItem, 0, len(open))
+	for _, task := range scheduled {
+		if placed[task.ID] {
+			continue
+		}
+		placed[task.ID] = true
+		today = append(today, todayItemFromTask(task, TodayBucketScheduled, now))
+	}
+	for _, task := range open {
+		if placed[task.ID] || (task.State != "Snoozed" && task.ScheduledAt != nil) {
+			continue
+		}
+		today = append(today, todayItemFromTask(task, TodayBucketAnytime, now))
+	}
+	today = append(today, overdue...)
+	todayIDs := make([]string, 0, len(today))
+	for _, item := range today {
+		todayIDs = append(todayIDs, item.ID)
+	}
+	snoozes, err := m.repo.CountSnoozes(ctx, todayIDs)
+	if err != nil {
+		return fmt.Errorf("load snooze counts: %w", err)
+	}
+	for i := range today {
+		today[i].Snoozes = snoozes[today[i].ID]
+	}
+
+	for _, task := range all {
+		if task.State == "Done" {
+			m.CompletedTasks[task.ID] = true
+		}
+	}
+
+	m.Inbox.Items = inbox
+	m.Inbox.Cursor = 0
+	m.Today.Items = today
+	m.Today.Cursor = 0
+	m.Calendar.Items = agendaItemsFromStore(all, reminders, now)
+	m.Calendar.Cursor = 0
+	m.SelectedTaskID = ""
+	m.syncSelectedTaskToTodayCursor()
+	m.refreshProductivitySignals()
+	return nil
+}
+
+// taskFilter narrows base by the tag and priority set through the palette.
+func (m *Model) taskFilter(base storage.TaskListFilter) storage.TaskListFilter {
+	base.Tag = m.Filter.Tag
+	if m.Filter.Priority != "" {
+		base.Priorities = []string{m.Filter.Priority}
+	}
+	return base
+}
+
+func inboxItemFromTask(task storage.Task, now time.Time) InboxItem {
+	item := InboxItem{ID: task.ID, Title: task.Title, Tags: task.Tags}
+	if task.ScheduledAt != nil {
+		item.ScheduledFor = task.ScheduledAt.In(now.Location()).Format("2006-01-02 15:04")
+	}
+	return item
+}
+
+func todayItemFromTask(task storage.Task, bucket TodayBucket, now time.Time) TodayItem {
+	loc := now.Location()
+	item := TodayItem{
+		ID:       task.ID,
+		Title:    task.Title,
+		Bucket:   bucket,
+		Priority: task.Priority,
+		Tags:     task.Tags,
+		Notes:    task.Description,
+	}
+	if task.DueAt != nil {
+		item.DueAt = formatRelativeDay(task.DueAt.In(loc), startOfLocalDay(now))
+	}
+	if task.ScheduledAt != nil {
+		item.ScheduledAt = task.ScheduledAt.In(loc).Format("15:04")
+	}
+	return item
+}
+
+func agendaItemsFromStore(tasks []storage.Task, reminders []storage.Reminder, now time.Time) []AgendaItem {
+	loc := now.Location()
+	titles := make(map[string]string, len(tasks))
+	out := make([]AgendaItem, 0)
+	for _, task := range tasks {
+		titles[task.ID] = task.Title
+		if task.State == "Done" || task.ScheduledAt == nil {
+			continue
+		}
+		at := task.ScheduledAt.In(loc)
+		out = append(out, AgendaItem{
+			ID:    task.ID,
+			Title: task.Title,
+			Date:  at.Format("2006-01-02"),
+			Time:  at.Format("15:04"),
+			Kind:  "task",
+		})
+	}
+	for _, rem := range reminders {
+		title, ok := titles[rem.TaskID]
+		if !ok {
+			continue
+		}
+		at := rem.TriggerAt.In(loc)
+		out = append(out, AgendaItem{
+			ID:    rem.ID,
+			Title: title,
+			Date:  at.Format("2006-01-02"),
+			Time:  at.Format("15:04"),
+			Kind:  "reminder",
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		return out[i].Time < out[j].Time
+	})
+	return out
+}
+
+func startOfLocalDay(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+}
+
+func formatRelativeDay(at time.Time, startOfDay time.Time) string {
+	switch {
+	case at.Before(startOfDay) && !at.Before(startOfDay.AddDate(0, 0, -1)):
+		return "Yesterday"
+	case !at.Before(startOfDay) && at.Before(startOfDay.AddDate(0, 0, 1)):
+		return at.Format("15:04")
+	default:
+		return at.Format("2006-01-02")
+	}
+}
+
+// jumpToSearchHit moves the Today or Inbox cursor to the best search hit that
+// is currently visible, expanding its Today section if it was collapsed.
+func (m *Model) jumpToSearchHit(query string) (storage.TaskSearchResult, bool, error) {
+	results, err := m.repo.SearchTasks(context.Background(), query)
+	if err != nil {
+		return storage.TaskSearchResult{}, false, fmt.Errorf("search tasks: %w", err)
+	}
+	for _, hit := range results {
+		for i, item := range m.Today.Items {
+			if item.ID == hit.Task.ID {
+				m.CurrentView = ViewToday
+				m.Today.Cursor = i
+				m.todayCollapsed[item.Bucket] = false
+				m.syncSelectedTaskToTodayCursor()
+				return hit, true, nil
+			}
+		}
+		for i, item := range m.Inbox.Items {
+			if item.ID == hit.Task.ID {
+				m.CurrentView = ViewInbox
+				m.Inbox.Cursor = i
+				return hit, true, nil
+			}
+		}
+	}
+	return storage.TaskSearchResult{}, false, nil
+}
+
+// createStoredTask inserts a new Inbox task, with its recurrence rule when
+// the quick-add line had every:, and returns the row. Without a repository
+// the ID is still generated so in-memory captures stay unique.
+func (m *Model) createStoredTask(draft commands.Draft, now time.Time) (storage.Task, error) {
+	if m.repo == nil {
+		return storage.TaskFromModel(draft.Task(domainmodel.NewID("task"), now)), nil
+	}
+	return storage.CreateTaskWithRecurrence(context.Background(), m.repo, draft.Task("", now), draft.Recurrence, now)
+}
+
+// updateStoredTask loads a task, applies mutate and writes it back. It is a
+// no-op when no repository is attached.
+func (m *Model) updateStoredTask(id string, mutate func(*storage.Task)) error {
+	if m.repo == nil {
+		return nil
+	}
+	ctx := context.Background()
+	task, err := m.repo.GetTask(ctx, id)
+	if err != nil {
+		return fmt.Errorf("load task %s: %w", id, err)
+	}
+	mutate(&task)
+	if err := m.repo.UpdateTask(ctx, task); err != nil {
+		return fmt.Errorf("update task %s: %w", id, err)
+	}
+	return nil
+}
+
+// attachStoredTag attaches the tag called name to a stored task; the tag row
+// is created on demand by the repository.
+func (m *Model) attachStoredTag(id string, name string) error {
+	if m.repo == nil {
+		return nil
+	}
+	if err := m.repo.AttachTaskTag(context.Background(), id, name); err != nil {
+		return fmt.Errorf("tag task %s: %w", id, err)
+	}
+	return nil
+}
+
+// completeStoredTask marks a task Done and returns the next occurrence it
+// created, if the task recurs. Tasks unknown to the repository (for example a
+// focus block started without a selection) are ignored.
+func (m *Model) completeStoredTask(id string, now time.Time) (*storage.Task, error) {
+	if m.repo == nil {
+		return nil, nil
+	}
+	_, next, err := storage.CompleteTask(context.Background(), m.repo, id, now)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	return next, err
+}
@@ -0,0 +1,450 @@
+package update
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandeepkv93/taskd/internal/clock"
+	domainmodel "github.com/sandeepkv93/taskd/internal/model"
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func setupStoreRepo(t *testing.T) *storage.SQLiteRepository {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "taskd-update.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := storage.MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo, err := storage.NewSQLiteRepository(db)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	return repo
+}
+
+func storeTestConfig(t *testing.T) RuntimeConfig {
+	t.Helper()
+	cfg := DefaultRuntimeConfig()
+	cfg.CompletionStatePath = filepath.Join(t.TempDir(), "state.json")
+	return cfg
+}
+
+func seedStoreTask(t *testing.T, repo storage.Repository, task storage.Task) {
+	t.Helper()
+	if task.Priority == "" {
+		task.Priority = "Medium"
+	}
+	if task.Energy == "" {
+		task.Energy = "Light"
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now().UTC()
+	}
+	if err := repo.CreateTask(context.Background(), task); err != nil {
+		t.Fatalf("seed task %s: %v", task.ID, err)
+	}
+}
+
+func TestRepositoryLoadBucketsTasksIntoViews(t *testing.T) {
+	repo := setupStoreRepo(t)
+	now := time.Now()
+	later := now.Add(time.Minute)
+	if startOfLocalDay(later) != startOfLocalDay(now) {
+		later = now
+	}
+	yesterday := now.AddDate(0, 0, -1)
+	nextWeek := now.AddDate(0, 0, 7)
+
+	seedStoreTask(t, repo, storage.Task{ID: "in-1", Title: "capture", State: "Inbox"})
+	seedStoreTask(t, repo, storage.Task{ID: "sched-1", Title: "standup", State: "Planned", ScheduledAt: &later})
+	seedStoreTask(t, repo, storage.Task{ID: "any-1", Title: "review", State: "Planned"})
+	seedStoreTask(t, repo, storage.Task{ID: "late-1", Title: "taxes", State: "Planned", DueAt: &yesterday})
+	seedStoreTask(t, repo, storage.Task{ID: "future-1", Title: "offsite", State: "Planned", ScheduledAt: &nextWeek})
+	seedStoreTask(t, repo, storage.Task{ID: "done-1", Title: "shipped", State: "Done", CompletedAt: &yesterday})
+	if err := repo.CreateReminder(context.Background(), storage.Reminder{
+		ID: "rem-1", TaskID: "future-1", TriggerAt: nextWeek, Type: "Hard", Enabled: true, CreatedAt: now,
+	}); err != nil {
+		t.Fatalf("seed reminder: %v", err)
+	}
+
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	if m.Status.IsError {
+		t.Fatalf("unexpected load error: %q", m.Status.Text)
+	}
+	if len(m.Inbox.Items) != 1 || m.Inbox.Items[0].ID != "in-1" {
+		t.Fatalf("unexpected inbox items: %#v", m.Inbox.Items)
+	}
+
+	buckets := make(map[string]TodayBucket)
+	for _, item := range m.Today.Items {
+		buckets[item.ID] = item.Bucket
+	}
+	want := map[string]TodayBucket{
+		"sched-1": TodayBucketScheduled,
+		"any-1":   TodayBucketAnytime,
+		"late-1":  TodayBucketOverdue,
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("unexpected today items: %#v", m.Today.Items)
+	}
+	for id, bucket := range want {
+		if buckets[id] != bucket {
+			t.Fatalf("task %s bucket = %q, want %q", id, buckets[id], bucket)
+		}
+	}
+	if !m.CompletedTasks["done-1"] {
+		t.Fatal("expected done task to be marked completed")
+	}
+
+	kinds := make(map[string]string)
+	for _, item := range m.Calendar.Items {
+		kinds[item.ID] = item.Kind
+	}
+	if kinds["future-1"] != "task" || kinds["rem-1"] != "reminder" {
+		t.Fatalf("unexpected calendar items: %#v", m.Calendar.Items)
+	}
+}
+
+func TestRepositoryQuickAddAndBulkSchedulePersist(t *testing.T) {
+	repo := setupStoreRepo(t)
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.CurrentView = ViewInbox
+	m.addInboxItem("write release notes")
+	if len(m.Inbox.Items) != 1 {
+		t.Fatalf("expected captured item, got %#v", m.Inbox.Items)
+	}
+	id := m.Inbox.Items[0].ID
+
+	stored, err := repo.GetTask(context.Background(), id)
+	if err != nil {
+		t.Fatalf("expected persisted task: %v", err)
+	}
+	if stored.Title != "write release notes" || stored.State != "Inbox" {
+		t.Fatalf("unexpected persisted task: %#v", stored)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	next := updated.(Model)
+	when := time.Now().AddDate(0, 0, -1).Format("2006-01-02 15:04")
+	updated, _ = next.Update(BulkScheduleInboxMsg{When: when})
+	next = updated.(Model)
+	if next.Status.IsError {
+		t.Fatalf("unexpected schedule error: %q", next.Status.Text)
+	}
+
+	stored, err = repo.GetTask(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get scheduled task: %v", err)
+	}
+	if stored.State != "Planned" || stored.ScheduledAt == nil {
+		t.Fatalf("expected planned task with schedule, got %#v", stored)
+	}
+	if got := stored.ScheduledAt.In(time.Local).Format("2006-01-02 15:04"); got != when {
+		t.Fatalf("unexpected scheduled_at: %s", got)
+	}
+
+	next.Update(BulkTagInboxMsg{Tag: "triage"})
+	stored, err = repo.GetTask(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get tagged task: %v", err)
+	}
+	if len(stored.Tags) != 1 || stored.Tags[0] != "triage" {
+		t.Fatalf("expected triage tag persisted, got %#v", stored.Tags)
+	}
+
+	reloaded := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	if len(reloaded.Today.Items) != 1 || !contains(reloaded.Today.Items[0].Tags, "triage") {
+		t.Fatalf("expected tag to reload into today view, got %#v", reloaded.Today.Items)
+	}
+}
+
+func TestRepositorySnoozeAndFocusCompletionPersist(t *testing.T) {
+	repo := setupStoreRepo(t)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	seedStoreTask(t, repo, storage.Task{ID: "late", Title: "pay invoice", State: "Planned", DueAt: &yesterday})
+	seedStoreTask(t, repo, storage.Task{ID: "deep", Title: "refactor", State: "Planned"})
+
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.Palette.Input = "snooze overdue 2 days"
+	m = m.executePaletteCommand()
+	if m.Status.IsError {
+		t.Fatalf("unexpected snooze error: %q", m.Status.Text)
+	}
+	stored, err := repo.GetTask(context.Background(), "late")
+	if err != nil {
+		t.Fatalf("get snoozed task: %v", err)
+	}
+	if stored.State != "Snoozed" || stored.Description != "" {
+		t.Fatalf("expected snoozed task persisted, got %#v", stored)
+	}
+	if stored.ScheduledAt == nil || stored.ScheduledAt.Sub(time.Now()) < 47*time.Hour {
+		t.Fatalf("expected snooze to schedule the task two days out, got %v", stored.ScheduledAt)
+	}
+
+	m.Focus.TaskID = "deep"
+	m.Focus.Phase = FocusPhaseWork
+	m.completeFocusPhase()
+	stored, err = repo.GetTask(context.Background(), "deep")
+	if err != nil {
+		t.Fatalf("get completed task: %v", err)
+	}
+	if stored.State != "Done" || stored.CompletedAt == nil {
+		t.Fatalf("expected completed task persisted, got %#v", stored)
+	}
+}
+
+func TestFocusCompletionCreatesNextOccurrence(t *testing.T) {
+	repo := setupStoreRepo(t)
+	ctx := context.Background()
+	scheduled := time.Now().Add(-time.Hour).Truncate(time.Minute).UTC()
+	seedStoreTask(t, repo, storage.Task{ID: "walk", Title: "walk the dog", State: "Planned", ScheduledAt: &scheduled, Tags: []string{"home"}})
+	if err := repo.CreateRecurrence(ctx, storage.RecurrenceRule{ID: "r1", TaskID: "walk", RuleType: "every_n_days", IntervalValue: 1, Timezone: "UTC", StartAt: scheduled, Enabled: true, CreatedAt: scheduled}); err != nil {
+		t.Fatalf("create recurrence: %v", err)
+	}
+	if err := repo.CreateReminder(ctx, storage.Reminder{ID: "m1", TaskID: "walk", TriggerAt: scheduled.Add(-10 * time.Minute), Type: "Hard", Enabled: true, CreatedAt: scheduled}); err != nil {
+		t.Fatalf("create reminder: %v", err)
+	}
+
+	engine := scheduler.NewEngine(4)
+	m := NewModelWithRepository(engine, nil, repo, storeTestConfig(t))
+	m.Focus.TaskID = "walk"
+	m.Focus.Phase = FocusPhaseWork
+	m.completeFocusPhase()
+	if m.Status.IsError {
+		t.Fatalf("unexpected completion error: %q", m.Status.Text)
+	}
+
+	rules, err := repo.ListRecurrences(ctx, storage.RecurrenceListFilter{})
+	if err != nil || len(rules) != 1 || rules[0].TaskID == "walk" {
+		t.Fatalf("expected the rule on the next occurrence, got %+v, %v", rules, err)
+	}
+	next, err := repo.GetTask(ctx, rules[0].TaskID)
+	wantAt := scheduled.AddDate(0, 0, 1)
+	if err != nil || next.State != "Planned" || next.ScheduledAt == nil || !next.ScheduledAt.Equal(wantAt) {
+		t.Fatalf("expected the next walk at %s, got %#v, %v", wantAt, next, err)
+	}
+	pending := engine.Pending()
+	if len(pending) != 1 || pending[0].TaskID != next.ID || !pending[0].TriggerAt.Equal(wantAt.Add(-10*time.Minute)) {
+		t.Fatalf("expected the carried reminder armed, got %+v", pending)
+	}
+}
+
+func TestRepositoryQuickAddInlineMetadataPersists(t *testing.T) {
+	repo := setupStoreRepo(t)
+	cfg := storeTestConfig(t)
+	cfg.Clock = clock.NewFake(time.Date(2026, 2, 11, 14, 0, 0, 0, time.Local))
+	m := NewModelWithRepository(nil, nil, repo, cfg)
+	m.CurrentView = ViewInbox
+
+	m.Inbox.Input = "standup notes #team !high @social due:fri at:9am every:weekday"
+	preview := m.quickAddPreview()
+	for _, want := range []string{"title: standup notes", "tags: team", "priority: High", "energy: Social", "due: Fri 2026-02-13 09:00", "at: Thu 2026-02-12 09:00", "every: every_weekday"} {
+		if !strings.Contains(preview, want) {
+			t.Fatalf("preview %q missing %q", preview, want)
+		}
+	}
+	m.Inbox.Input = "standup notes due:3/4"
+	if preview := m.quickAddPreview(); !strings.Contains(preview, "ambiguous") {
+		t.Fatalf("expected ambiguity in preview, got %q", preview)
+	}
+
+	m.addInboxItem("standup notes #team !high @social due:fri at:9am every:weekday")
+	if m.Status.IsError || len(m.Inbox.Items) != 1 {
+		t.Fatalf("unexpected quick add result: %+v %#v", m.Status, m.Inbox.Items)
+	}
+	stored, err := repo.GetTask(context.Background(), m.Inbox.Items[0].ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if stored.Title != "standup notes" || stored.Priority != "High" || stored.Energy != "Social" || !contains(stored.Tags, "team") {
+		t.Fatalf("unexpected stored task: %#v", stored)
+	}
+	if stored.DueAt == nil || !stored.DueAt.Equal(time.Date(2026, 2, 13, 9, 0, 0, 0, time.Local)) {
+		t.Fatalf("unexpected due_at: %v", stored.DueAt)
+	}
+	if stored.ScheduledAt == nil || !stored.ScheduledAt.Equal(time.Date(2026, 2, 12, 9, 0, 0, 0, time.Local)) {
+		t.Fatalf("unexpected scheduled_at: %v", stored.ScheduledAt)
+	}
+	rules, err := repo.ListRecurrences(context.Background(), storage.RecurrenceListFilter{TaskID: stored.ID})
+	if err != nil || len(rules) != 1 || rules[0].RuleType != "every_weekday" {
+		t.Fatalf("expected weekday recurrence, got %#v, %v", rules, err)
+	}
+
+	m.Palette.Input = "add file taxes !critical"
+	m = m.executePaletteCommand()
+	if m.Status.IsError || m.Inbox.Items[len(m.Inbox.Items)-1].Title != "file taxes" {
+		t.Fatalf("unexpected /add result: %+v", m.Status)
+	}
+}
+
+func TestRepositoryRescheduleWritesResolvedTimes(t *testing.T) {
+	repo := setupStoreRepo(t)
+	seedStoreTask(t, repo, storage.Task{ID: "draft", Title: "draft memo", State: "Inbox"})
+	cfg := storeTestConfig(t)
+	cfg.Clock = clock.NewFake(time.Date(2026, 2, 11, 14, 0, 0, 0, time.Local))
+	m := NewModelWithRepository(nil, nil, repo, cfg)
+	m.Inbox.Selected["draft"] = true
+
+	m.Palette.Input = "reschedule selected tomorrow 9am"
+	m = m.executePaletteCommand()
+	m.Palette.Input = "reschedule selected due fri 17:30"
+	m = m.executePaletteCommand()
+	if m.Status.IsError {
+		t.Fatalf("unexpected reschedule error: %q", m.Status.Text)
+	}
+	stored, err := repo.GetTask(context.Background(), "draft")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if stored.ScheduledAt == nil || !stored.ScheduledAt.Equal(time.Date(2026, 2, 12, 9, 0, 0, 0, time.Local)) {
+		t.Fatalf("unexpected scheduled_at: %v", stored.ScheduledAt)
+	}
+	if stored.DueAt == nil || !stored.DueAt.Equal(time.Date(2026, 2, 13, 17, 30, 0, 0, time.Local)) {
+		t.Fatalf("unexpected due_at: %v", stored.DueAt)
+	}
+
+	m.Palette.Input = "reschedule selected next fri"
+	m = m.executePaletteCommand()
+	if !m.Status.IsError || !strings.Contains(m.Status.Text, "ambiguous") {
+		t.Fatalf("expected ambiguity error, got %+v", m.Status)
+	}
+}
+
+func TestRepositoryShowTagFiltersViews(t *testing.T) {
+	repo := setupStoreRepo(t)
+	seedStoreTask(t, repo, storage.Task{ID: "bills", Title: "pay bills", State: "Planned", Tags: []string{"finance"}})
+	seedStoreTask(t, repo, storage.Task{ID: "gym", Title: "gym", State: "Planned", Tags: []string{"health"}})
+	seedStoreTask(t, repo, storage.Task{ID: "receipts", Title: "file receipts", State: "Inbox", Tags: []string{"finance"}})
+	seedStoreTask(t, repo, storage.Task{ID: "idea", Title: "idea", State: "Inbox"})
+
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	if len(m.Today.Items) != 2 || len(m.Inbox.Items) != 2 {
+		t.Fatalf("expected unfiltered views, got today=%d inbox=%d", len(m.Today.Items), len(m.Inbox.Items))
+	}
+
+	m.Palette.Input = "show tasks tag:finance"
+	m = m.executePaletteCommand()
+	if m.Status.IsError {
+		t.Fatalf("unexpected show error: %q", m.Status.Text)
+	}
+	if len(m.Today.Items) != 1 || m.Today.Items[0].ID != "bills" {
+		t.Fatalf("expected only finance task in today, got %#v", m.Today.Items)
+	}
+	if len(m.Inbox.Items) != 1 || m.Inbox.Items[0].ID != "receipts" {
+		t.Fatalf("expected only finance task in inbox, got %#v", m.Inbox.Items)
+	}
+
+	m.Palette.Input = "show tasks"
+	m = m.executePaletteCommand()
+	if m.Filter.Tag != "" || len(m.Today.Items) != 2 {
+		t.Fatalf("expected filter cleared, got tag=%q today=%d", m.Filter.Tag, len(m.Today.Items))
+	}
+}
+
+func TestRepositoryFindJumpsCursorToHit(t *testing.T) {
+	repo := setupStoreRepo(t)
+	seedStoreTask(t, repo, storage.Task{ID: "gym", Title: "gym", State: "Planned"})
+	seedStoreTask(t, repo, storage.Task{ID: "invoice", Title: "send invoice", Description: "acme quarterly billing", State: "Planned"})
+	seedStoreTask(t, repo, storage.Task{ID: "idea", Title: "idea", Description: "quarterly offsite agenda", State: "Inbox"})
+
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.Palette.Input = "find billing"
+	m = m.executePaletteCommand()
+	if m.Status.IsError {
+		t.Fatalf("unexpected find error: %q", m.Status.Text)
+	}
+	if m.CurrentView != ViewToday || m.SelectedTaskID != "invoice" || m.Today.Items[m.Today.Cursor].ID != "invoice" {
+		t.Fatalf("expected cursor on invoice, got view=%v selected=%q", m.CurrentView, m.SelectedTaskID)
+	}
+	if !strings.Contains(m.Status.Text, "[billing]") {
+		t.Fatalf("expected highlighted snippet in status, got %q", m.Status.Text)
+	}
+
+	m.Palette.Input = "find offsite"
+	m = m.executePaletteCommand()
+	if m.CurrentView != ViewInbox || m.Inbox.Items[m.Inbox.Cursor].ID != "idea" {
+		t.Fatalf("expected inbox cursor on idea, got view=%v cursor=%d", m.CurrentView, m.Inbox.Cursor)
+	}
+
+	m.Palette.Input = "find nothing-like-this"
+	m = m.executePaletteCommand()
+	if !m.Status.IsError {
+		t.Fatalf("expected miss to be reported, got %q", m.Status.Text)
+	}
+}
+
+func TestRecurrenceEditorSkipsAnOccurrenceAndSaves(t *testing.T) {
+	repo := setupStoreRepo(t)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.Local)
+	scheduled := start.Add(time.Hour)
+	seedStoreTask(t, repo, storage.Task{ID: "walk", Title: "walk the dog", State: "Planned", ScheduledAt: &scheduled})
+	if err := repo.CreateRecurrence(ctx, storage.RecurrenceFromModel("r1", "walk", domainmodel.RecurrenceRule{Type: domainmodel.RecurrenceEveryNDays, Interval: 1, Anchor: scheduled}, start)); err != nil {
+		t.Fatalf("create recurrence: %v", err)
+	}
+	if err := repo.CreateReminder(ctx, storage.Reminder{ID: "m1", TaskID: "walk", TriggerAt: scheduled.Add(-10 * time.Minute), Type: "Hard", Enabled: true, CreatedAt: start}); err != nil {
+		t.Fatalf("create reminder: %v", err)
+	}
+
+	fake := clock.NewFake(start)
+	engine := scheduler.NewEngine(4, scheduler.WithClock(fake))
+	cfg := storeTestConfig(t)
+	cfg.Clock = fake
+	m := NewModelWithRepository(engine, nil, repo, cfg)
+	m = pressKey(t, m, runeKey("R"))
+	if m.recurrenceEditor.RuleID != "r1" || len(m.recurrenceEditor.Preview) != 5 || m.recurrenceEditor.Preview[0] != "2026-03-02 09:00" {
+		t.Fatalf("expected the task's rule loaded, got %+v", m.recurrenceEditor)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = pressKey(t, m, runeKey("skip someday"))
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.recurrenceEditor.Err == "" || len(m.recurrenceEditor.Exceptions) != 0 {
+		t.Fatalf("expected an unreadable exception rejected, got %+v", m.recurrenceEditor)
+	}
+	m.recurrenceEditor.ExceptionText = ""
+	m = pressKey(t, m, runeKey("skip 2026-03-02"))
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.recurrenceEditor.Exceptions) != 1 || m.recurrenceEditor.Preview[0] != "2026-03-03 09:00" {
+		t.Fatalf("expected today's walk skipped, got %+v", m.recurrenceEditor)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyUp})
+	m.recurrenceEditor.EndsText = ""
+	m = pressKey(t, m, runeKey("after 3"))
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.recurrenceEditor.Preview; len(got) != 2 || got[1] != "2026-03-04 09:00" {
+		t.Fatalf("expected the skipped walk to count towards three, got %v (%s)", got, m.recurrenceEditor.Err)
+	}
+	if view := m.renderRecurrenceEditorIfVisible(); !strings.Contains(view, "> ends: after 3") || !strings.Contains(view, "- skip Mon 2026-03-02 09:00") {
+		t.Fatalf("expected the end and the skip shown:\n%s", view)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if m.recurrenceEditor.Active || m.Status.IsError {
+		t.Fatalf("expected the editor saved and closed, got %q (%s)", m.Status.Text, m.recurrenceEditor.Err)
+	}
+	rule, err := repo.GetRecurrence(ctx, "r1")
+	if err != nil || rule.Count != 3 || len(rule.Exceptions) != 1 || rule.Exceptions[0].Kind != "skip" {
+		t.Fatalf("expected the count and skip stored, got %+v, %v", rule, err)
+	}
+	task, err := repo.GetTask(ctx, "walk")
+	wantAt := scheduled.AddDate(0, 0, 1)
+	if err != nil || task.ScheduledAt == nil || !task.ScheduledAt.Equal(wantAt) {
+		t.Fatalf("expected the walk moved to %s, got %v, %v", wantAt, task.ScheduledAt, err)
+	}
+	pending := engine.Pending()
+	if len(pending) != 1 || !pending[0].TriggerAt.Equal(wantAt.Add(-10*time.Minute)) {
+		t.Fatalf("expected the reminder re-armed with the walk, got %+v", pending)
+	}
+}
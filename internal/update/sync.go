@@ -0,0 +1,119 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sandeepkv93/taskd/internal/syncer"
+)
+
+// errNoSyncer is reported when S is pressed without a sync target.
+var errNoSyncer = errors.New("sync: no sync target configured (set TASKD_SYNC_DIR)")
+
+// syncEventBuffer bounds queued progress updates; extra ones are dropped,
+// the final SyncDoneMsg never is.
+const syncEventBuffer = 64
+
+type SyncProgressMsg struct {
+	Progress syncer.Progress
+}
+
+type SyncDoneMsg struct {
+	Result syncer.Result
+	Err    error
+}
+
+// startSyncCmd runs s in the background and returns its first event; later
+// events are read with waitForSyncCmd.
+func startSyncCmd(s syncer.Syncer, events chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			res, err := s.Sync(context.Background(), func(p syncer.Progress) {
+				select {
+				case events <- SyncProgressMsg{Progress: p}:
+				default:
+				}
+			})
+			events <- SyncDoneMsg{Result: res, Err: err}
+		}()
+		return <-events
+	}
+}
+
+func waitForSyncCmd(events <-chan tea.Msg) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+func (m Model) startSync() (Model, tea.Cmd) {
+	if m.syncer == nil {
+		m.Status = StatusBar{Text: errNoSyncer.Error(), IsError: true}
+		return m, nil
+	}
+	if m.spinnerActive {
+		m.Status = StatusBar{Text: "sync already running"}
+		return m, nil
+	}
+	m.spinnerActive = true
+	m.syncProgress = syncer.Progress{}
+	m.syncEvents = make(chan tea.Msg, syncEventBuffer)
+	m.Status = StatusBar{Text: "sync started: " + m.syncer.Target()}
+	return m, tea.Batch(m.syncSpinner.Tick, startSyncCmd(m.syncer, m.syncEvents))
+}
+
+func (m Model) onSyncProgress(msg SyncProgressMsg) (tea.Model, tea.Cmd) {
+	p := msg.Progress
+	m.syncProgress = p
+	m.Status = StatusBar{Text: fmt.Sprintf("sync: %ss %d/%d", p.Entity, p.Done, p.Total)}
+	return m, waitForSyncCmd(m.syncEvents)
+}
+
+func (m Model) onSyncDone(msg SyncDoneMsg) (tea.Model, tea.Cmd) {
+	m.spinnerActive = false
+	m.syncEvents = nil
+	if msg.Err != nil {
+		m.LastError = msg.Err
+		m.Status = StatusBar{Text: fmt.Sprintf("sync failed: %v", msg.Err), IsError: true}
+		m.notify("Sync", m.Status.Text, levelFromError(true))
+		return m, nil
+	}
+
+	res := msg.Result
+	if res.Pulled > 0 {
+		if err := m.reloadKeepingCursors(m.now()); err != nil {
+			m.LastError = err
+		}
+	}
+	for _, c := range res.Conflicts {
+		m.notify("Sync conflict", describeConflict(c), levelFromError(false))
+	}
+	summary := fmt.Sprintf("pushed %d, pulled %d", res.Pushed, res.Pulled)
+	if n := len(res.Conflicts); n > 0 {
+		summary += fmt.Sprintf(", %d conflict(s) (%s)", n, describeConflict(res.Conflicts[0]))
+	}
+	if n := len(res.Errors); n > 0 {
+		for _, err := range res.Errors {
+			m.notify("Sync", err.Error(), levelFromError(true))
+		}
+		m.Status = StatusBar{Text: fmt.Sprintf("sync finished with %d error(s): %s; %v", n, summary, res.Errors[0]), IsError: true}
+		return m, nil
+	}
+	m.Status = StatusBar{Text: "sync complete: " + summary}
+	return m, nil
+}
+
+func describeConflict(c syncer.Conflict) string {
+	kept := "local edit"
+	if c.Winner == syncer.Remote {
+		kept = "remote edit"
+	}
+	return fmt.Sprintf("%s %s %s: kept %s", c.Entity, c.ID, strings.ReplaceAll(c.Field, "_", " "), kept)
+}
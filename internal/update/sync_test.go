@@ -0,0 +1,115 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+	"github.com/sandeepkv93/taskd/internal/syncer"
+)
+
+type stubSyncer struct {
+	steps  []syncer.Progress
+	result syncer.Result
+	err    error
+}
+
+func (s stubSyncer) Target() string { return "stub" }
+
+func (s stubSyncer) Sync(_ context.Context, progress func(syncer.Progress)) (syncer.Result, error) {
+	for _, p := range s.steps {
+		progress(p)
+	}
+	return s.result, s.err
+}
+
+// runSync presses S and feeds every sync event back into the model until the
+// sync finishes, returning the statuses shown along the way.
+func runSync(t *testing.T, m Model) (Model, []string) {
+	t.Helper()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	m = updated.(Model)
+	if !m.spinnerActive {
+		t.Fatalf("expected sync to start, status %+v", m.Status)
+	}
+	statuses := []string{m.Status.Text}
+	cmd := startSyncCmd(m.syncer, m.syncEvents)
+	for i := 0; i < 100 && cmd != nil; i++ {
+		updated, cmd = m.Update(cmd())
+		m = updated.(Model)
+		statuses = append(statuses, m.Status.Text)
+		if !m.spinnerActive {
+			return m, statuses
+		}
+	}
+	t.Fatalf("sync did not finish: %v", statuses)
+	return m, nil
+}
+
+func TestSyncKeyWithoutTarget(t *testing.T) {
+	m := NewModel()
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	m = updated.(Model)
+	if cmd != nil || m.spinnerActive || !m.Status.IsError || m.Status.Text != errNoSyncer.Error() {
+		t.Fatalf("unexpected status %+v", m.Status)
+	}
+}
+
+func TestSyncKeyShowsProgressConflictsAndErrors(t *testing.T) {
+	cfg := DefaultRuntimeConfig()
+	cfg.CompletionStatePath = ""
+	cfg.Syncer = stubSyncer{
+		steps: []syncer.Progress{{Entity: storage.ChangeTask, Done: 1, Total: 2}, {Entity: storage.ChangeTask, Done: 2, Total: 2}},
+		result: syncer.Result{Pushed: 1, Conflicts: []syncer.Conflict{
+			{Entity: storage.ChangeTask, ID: "t1", Field: "title", Winner: syncer.Remote},
+		}},
+	}
+	m := NewModelWithConfig(nil, nil, cfg)
+	m, statuses := runSync(t, m)
+	if !strings.Contains(strings.Join(statuses, "|"), "sync: tasks 2/2") {
+		t.Fatalf("expected progress in the status line, got %v", statuses)
+	}
+	if m.Status.IsError || m.Status.Text != "sync complete: pushed 1, pulled 0, 1 conflict(s) (task t1 title: kept remote edit)" {
+		t.Fatalf("unexpected final status %+v", m.Status)
+	}
+	if n := m.Notifications[len(m.Notifications)-1]; n.Title != "Sync conflict" {
+		t.Fatalf("expected a conflict notification, got %+v", n)
+	}
+
+	m.syncer = stubSyncer{result: syncer.Result{Errors: []error{errors.New("tasks/x.json: bad")}}}
+	m, _ = runSync(t, m)
+	if !m.Status.IsError || !strings.Contains(m.Status.Text, "1 error(s)") || !strings.Contains(m.Status.Text, "tasks/x.json: bad") {
+		t.Fatalf("unexpected error status %+v", m.Status)
+	}
+
+	m.syncer = stubSyncer{err: errors.New("disk full")}
+	m, _ = runSync(t, m)
+	if !m.Status.IsError || m.Status.Text != "sync failed: disk full" {
+		t.Fatalf("unexpected failure status %+v", m.Status)
+	}
+}
+
+func TestSyncKeyPullsFromSyncDir(t *testing.T) {
+	dir := t.TempDir()
+	other := setupStoreRepo(t)
+	seedStoreTask(t, other, storage.Task{ID: "remote-1", Title: "from the laptop", State: "Planned"})
+	if _, err := syncer.NewDir(other, dir, nil).Sync(context.Background(), nil); err != nil {
+		t.Fatalf("seed sync dir: %v", err)
+	}
+
+	cfg := storeTestConfig(t)
+	cfg.SyncDir = dir
+	m := NewModelWithRepository(nil, nil, setupStoreRepo(t), cfg)
+	m.CurrentView = ViewToday
+	m, _ = runSync(t, m)
+	if m.Status.Text != "sync complete: pushed 0, pulled 1" {
+		t.Fatalf("unexpected status %+v", m.Status)
+	}
+	if len(m.Today.Items) != 1 || m.Today.Items[0].Title != "from the laptop" {
+		t.Fatalf("expected the pulled task in Today, got %+v", m.Today.Items)
+	}
+}
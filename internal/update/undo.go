@@ -0,0 +1,431 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+// undoLimit bounds how many actions can be undone; older ones are forgotten.
+const undoLimit = 20
+
+// errUndoConflict is returned when a task, its reminders or its rules were
+// changed after the action being undone or redone, so replaying it would
+// overwrite that change.
+var errUndoConflict = errors.New("task changed since")
+
+// undoStep is one reversible action: the tasks it touched, with their
+// reminders and recurrence rules, as they were before and after it, and the
+// Inbox and Today items restored when there is no repository to reload from.
+type undoStep struct {
+	Label       string
+	Before      []taskSnapshot
+	After       []taskSnapshot
+	viewsBefore viewSnapshot
+	viewsAfter  viewSnapshot
+}
+
+// taskSnapshot is a stored task with its rules and reminders, or its absence
+// when Task is nil.
+type taskSnapshot struct {
+	ID          string
+	Task        *storage.Task
+	Recurrences []storage.RecurrenceRule
+	Reminders   []storage.Reminder
+}
+
+type viewSnapshot struct {
+	Inbox []InboxItem
+	Today []TodayItem
+}
+
+type undoHistory struct {
+	done   []undoStep
+	undone []undoStep
+}
+
+// beginUndo snapshots the tasks ids before an action changes them. The step
+// is finished with commitUndo; a nil step records nothing.
+func (m *Model) beginUndo(ids ...string) *undoStep {
+	before, err := snapshotTasks(context.Background(), m.repo, ids)
+	if err != nil {
+		m.LastError = err
+		return nil
+	}
+	return &undoStep{Before: before, viewsBefore: m.snapshotViews()}
+}
+
+// commitUndo records step under label once the action is done. Tasks the
+// action created are passed as created. Steps that changed nothing are
+// dropped, and any new step clears the redo history.
+func (m *Model) commitUndo(step *undoStep, label string, created ...string) {
+	if step == nil {
+		return
+	}
+	for _, id := range created {
+		step.Before = append(step.Before, taskSnapshot{ID: id})
+	}
+	ids := make([]string, 0, len(step.Before))
+	for _, snap := range step.Before {
+		ids = append(ids, snap.ID)
+	}
+	after, err := snapshotTasks(context.Background(), m.repo, ids)
+	if err != nil {
+		m.LastError = err
+		return
+	}
+	step.Label = label
+	step.After = after
+	step.viewsAfter = m.snapshotViews()
+	if !step.changed() {
+		return
+	}
+	m.undo.done = append(m.undo.done, *step)
+	if len(m.undo.done) > undoLimit {
+		m.undo.done = m.undo.done[len(m.undo.done)-undoLimit:]
+	}
+	m.undo.undone = nil
+}
+
+func (s *undoStep) changed() bool {
+	for i := range s.Before {
+		if !sameSnapshot(s.Before[i], s.After[i]) {
+			return true
+		}
+	}
+	return !reflect.DeepEqual(s.viewsBefore, s.viewsAfter)
+}
+
+// undoLast reverts the latest action and moves it to the redo history.
+func (m *Model) undoLast() (string, error) {
+	n := len(m.undo.done)
+	if n == 0 {
+		return "", errors.New("nothing to undo")
+	}
+	step := m.undo.done[n-1]
+	if err := m.restoreSnapshots(step.After, step.Before, step.viewsBefore); err != nil {
+		if errors.Is(err, errUndoConflict) {
+			m.undo.done = m.undo.done[:n-1]
+		}
+		return "", fmt.Errorf("undo %s: %w", step.Label, err)
+	}
+	m.undo.done = m.undo.done[:n-1]
+	m.undo.undone = append(m.undo.undone, step)
+	return "undid " + step.Label, nil
+}
+
+// redoLast replays the latest undone action.
+func (m *Model) redoLast() (string, error) {
+	n := len(m.undo.undone)
+	if n == 0 {
+		return "", errors.New("nothing to redo")
+	}
+	step := m.undo.undone[n-1]
+	if err := m.restoreSnapshots(step.Before, step.After, step.viewsAfter); err != nil {
+		if errors.Is(err, errUndoConflict) {
+			m.undo.undone = m.undo.undone[:n-1]
+		}
+		return "", fmt.Errorf("redo %s: %w", step.Label, err)
+	}
+	m.undo.undone = m.undo.undone[:n-1]
+	m.undo.done = append(m.undo.done, step)
+	return "redid " + step.Label, nil
+}
+
+func (m *Model) showUndoResult(msg string, err error) {
+	if err != nil {
+		m.Status = StatusBar{Text: err.Error(), IsError: true}
+		return
+	}
+	m.Status = StatusBar{Text: msg}
+	m.refreshProductivitySignals()
+}
+
+// restoreSnapshots writes target back in one transaction after checking that
+// every task still matches expect. Without a repository only the views are
+// restored.
+func (m *Model) restoreSnapshots(expect, target []taskSnapshot, views viewSnapshot) error {
+	if m.repo == nil {
+		m.restoreViews(views)
+		return nil
+	}
+	ids := make([]string, 0, len(target))
+	for _, want := range target {
+		ids = append(ids, want.ID)
+	}
+	ctx := context.Background()
+	err := m.repo.WithTx(ctx, func(tx storage.Repository) error {
+		// Check every task before writing any: an action can move rules and
+		// reminders between its tasks, so restoring one changes another.
+		current, err := snapshotTasks(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		for i, have := range current {
+			if !sameSnapshot(have, expect[i]) {
+				return fmt.Errorf("%w: %s", errUndoConflict, have.ID)
+			}
+		}
+		for i, want := range target {
+			if err := restoreTask(ctx, tx, current[i], want); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.rearmRestoredReminders(target)
+	return m.reloadKeepingCursors(m.now())
+}
+
+func restoreTask(ctx context.Context, tx storage.Repository, have, want taskSnapshot) error {
+	switch {
+	case want.Task == nil:
+		if have.Task == nil {
+			return nil
+		}
+		return tx.DeleteTask(ctx, want.ID)
+	case have.Task == nil:
+		if err := tx.CreateTask(ctx, *want.Task); err != nil {
+			return err
+		}
+	case !sameTask(have.Task, want.Task):
+		task := *want.Task
+		if task.Tags == nil {
+			task.Tags = []string{}
+		}
+		if err := tx.UpdateTask(ctx, task); err != nil {
+			return err
+		}
+	}
+	if err := restoreRecurrences(ctx, tx, have.Recurrences, want.Recurrences); err != nil {
+		return err
+	}
+	return restoreReminders(ctx, tx, have.Reminders, want.Reminders)
+}
+
+// restoreRecurrences makes a task's rules match want. A wanted rule that now
+// belongs to another task, such as one moved on by a recurring completion, is
+// moved back.
+func restoreRecurrences(ctx context.Context, tx storage.Repository, have, want []storage.RecurrenceRule) error {
+	keep := make(map[string]bool, len(want))
+	for _, rule := range want {
+		keep[rule.ID] = true
+	}
+	for _, rule := range have {
+		if keep[rule.ID] {
+			continue
+		}
+		// Skip rules another restored task has already taken back.
+		current, err := tx.GetRecurrence(ctx, rule.ID)
+		if err == nil && current.TaskID == rule.TaskID {
+			err = tx.DeleteRecurrence(ctx, rule.ID)
+		}
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	for _, rule := range want {
+		current, err := tx.GetRecurrence(ctx, rule.ID)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			err = tx.CreateRecurrence(ctx, rule)
+		case err == nil && !reflect.DeepEqual(current, rule):
+			if rule.Exceptions == nil {
+				// An empty list clears the stored exceptions; nil would keep them.
+				rule.Exceptions = []storage.RecurrenceException{}
+			}
+			err = tx.UpdateRecurrence(ctx, rule)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreReminders makes a task's reminders match want, re-enabling the ones a
+// completion switched off.
+func restoreReminders(ctx context.Context, tx storage.Repository, have, want []storage.Reminder) error {
+	keep := make(map[string]bool, len(want))
+	for _, rem := range want {
+		keep[rem.ID] = true
+	}
+	for _, rem := range have {
+		if keep[rem.ID] {
+			continue
+		}
+		current, err := tx.GetReminder(ctx, rem.ID)
+		if err == nil && current.TaskID == rem.TaskID {
+			err = tx.DeleteReminder(ctx, rem.ID)
+		}
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	for _, rem := range want {
+		current, err := tx.GetReminder(ctx, rem.ID)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			err = tx.CreateReminder(ctx, rem)
+		case err == nil && !sameReminder(current, rem):
+			err = tx.UpdateReminder(ctx, rem)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rearmRestoredReminders replaces the queued reminders of the restored tasks
+// with their stored ones that are still ahead, and forgets completions the
+// restore reverted.
+func (m *Model) rearmRestoredReminders(target []taskSnapshot) {
+	now := m.now()
+	reverted := false
+	for _, want := range target {
+		m.cancelTaskReminders(want.ID)
+		if want.Task == nil || want.Task.State != "Done" {
+			if m.CompletedTasks[want.ID] {
+				delete(m.CompletedTasks, want.ID)
+				reverted = true
+			}
+		}
+		if want.Task == nil || want.Task.State == "Done" || m.Scheduler == nil {
+			continue
+		}
+		for _, rem := range want.Reminders {
+			if rem.Enabled && rem.TriggerAt.After(now) {
+				if err := m.Scheduler.Schedule(reminderEventFromStore(rem)); err != nil {
+					m.LastError = err
+				}
+			}
+		}
+	}
+	if reverted {
+		if err := m.persistCompletedTaskState(); err != nil {
+			m.LastError = err
+		}
+	}
+}
+
+func snapshotTasks(ctx context.Context, repo storage.Repository, ids []string) ([]taskSnapshot, error) {
+	out := make([]taskSnapshot, 0, len(ids))
+	if repo == nil {
+		for _, id := range ids {
+			out = append(out, taskSnapshot{ID: id})
+		}
+		return out, nil
+	}
+	for _, id := range ids {
+		snap := taskSnapshot{ID: id}
+		task, err := repo.GetTask(ctx, id)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			out = append(out, snap)
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("snapshot task %s: %w", id, err)
+		}
+		snap.Task = &task
+		if snap.Recurrences, err = repo.ListRecurrences(ctx, storage.RecurrenceListFilter{TaskID: id}); err != nil {
+			return nil, fmt.Errorf("snapshot task %s: %w", id, err)
+		}
+		if snap.Reminders, err = repo.ListReminders(ctx, storage.ReminderListFilter{TaskID: id}); err != nil {
+			return nil, fmt.Errorf("snapshot task %s: %w", id, err)
+		}
+		out = append(out, snap)
+	}
+	return out, nil
+}
+
+// sameSnapshot compares two snapshots of the same task, including its rules
+// and reminders.
+func sameSnapshot(a, b taskSnapshot) bool {
+	if !sameTask(a.Task, b.Task) || len(a.Recurrences) != len(b.Recurrences) || len(a.Reminders) != len(b.Reminders) {
+		return false
+	}
+	for i := range a.Recurrences {
+		if !reflect.DeepEqual(a.Recurrences[i], b.Recurrences[i]) {
+			return false
+		}
+	}
+	for i := range a.Reminders {
+		if !sameReminder(a.Reminders[i], b.Reminders[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameReminder compares stored reminders, ignoring when they last fired so a
+// firing does not block undoing the action that created them.
+func sameReminder(a, b storage.Reminder) bool {
+	a.LastFired, b.LastFired = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// sameTask compares stored tasks, ignoring tag order.
+func sameTask(a, b *storage.Task) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	x, y := *a, *b
+	x.Tags, y.Tags = sortedTags(x.Tags), sortedTags(y.Tags)
+	return reflect.DeepEqual(x, y)
+}
+
+func sortedTags(tags []string) []string {
+	out := append([]string{}, tags...)
+	sort.Strings(out)
+	return out
+}
+
+func (m Model) snapshotViews() viewSnapshot {
+	return viewSnapshot{Inbox: m.Inbox.Items, Today: m.Today.Items}.clone()
+}
+
+func (m *Model) restoreViews(snap viewSnapshot) {
+	snap = snap.clone()
+	m.Inbox.Items = snap.Inbox
+	m.Inbox.Cursor = clampCursor(m.Inbox.Cursor, len(m.Inbox.Items))
+	m.Today.Items = snap.Today
+	m.Today.Cursor = clampCursor(m.Today.Cursor, len(m.Today.Items))
+	m.syncSelectedTaskToTodayCursor()
+}
+
+// clone copies the items so later edits to the model leave the snapshot
+// alone.
+func (v viewSnapshot) clone() viewSnapshot {
+	out := viewSnapshot{
+		Inbox: make([]InboxItem, len(v.Inbox)),
+		Today: make([]TodayItem, len(v.Today)),
+	}
+	for i, item := range v.Inbox {
+		item.Tags = append([]string(nil), item.Tags...)
+		out.Inbox[i] = item
+	}
+	for i, item := range v.Today {
+		item.Tags = append([]string(nil), item.Tags...)
+		out.Today[i] = item
+	}
+	return out
+}
+
+// undoLines lists the undo history newest first, then what can be redone.
+func (m Model) undoLines() (undo []string, redo []string) {
+	for i := len(m.undo.done) - 1; i >= 0; i-- {
+		undo = append(undo, m.undo.done[i].Label)
+	}
+	for i := len(m.undo.undone) - 1; i >= 0; i-- {
+		redo = append(redo, m.undo.undone[i].Label)
+	}
+	return undo, redo
+}
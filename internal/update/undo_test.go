@@ -0,0 +1,257 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sandeepkv93/taskd/internal/scheduler"
+	"github.com/sandeepkv93/taskd/internal/storage"
+)
+
+func pressKey(t *testing.T, m Model, msg tea.KeyMsg) Model {
+	t.Helper()
+	updated, _ := m.Update(msg)
+	return updated.(Model)
+}
+
+func runeKey(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestUndoRedoRevertsInboxBulkActions(t *testing.T) {
+	repo := setupStoreRepo(t)
+	seedStoreTask(t, repo, storage.Task{ID: "a", Title: "call bank", State: "Inbox", Tags: []string{"money"}})
+	seedStoreTask(t, repo, storage.Task{ID: "b", Title: "book dentist", State: "Inbox"})
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.CurrentView = ViewInbox
+
+	for _, k := range []string{"x", "s", "g"} {
+		m = pressKey(t, m, runeKey(k))
+	}
+	task, _ := repo.GetTask(context.Background(), "a")
+	if task.State != "Planned" || task.ScheduledAt == nil || len(task.Tags) != 2 {
+		t.Fatalf("expected scheduled and tagged task, got %+v", task)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if m.Status.IsError || m.Status.Text != "undid tag 2 inbox item(s) #triage" {
+		t.Fatalf("unexpected status %+v", m.Status)
+	}
+	task, _ = repo.GetTask(context.Background(), "a")
+	if len(task.Tags) != 1 || task.Tags[0] != "money" || task.State != "Planned" {
+		t.Fatalf("expected only the tag reverted, got %+v", task)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlZ})
+	for _, id := range []string{"a", "b"} {
+		task, _ = repo.GetTask(context.Background(), id)
+		if task.State != "Inbox" || task.ScheduledAt != nil {
+			t.Fatalf("expected %s back in the inbox, got %+v", id, task)
+		}
+	}
+	if len(m.Inbox.Items) != 2 {
+		t.Fatalf("expected both items back in the Inbox view, got %+v", m.Inbox.Items)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if !m.Status.IsError || m.Status.Text != "nothing to undo" {
+		t.Fatalf("unexpected status %+v", m.Status)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	if m.Status.Text != "redid schedule 2 inbox item(s)" {
+		t.Fatalf("unexpected status %+v", m.Status)
+	}
+	task, _ = repo.GetTask(context.Background(), "b")
+	if task.State != "Planned" || task.ScheduledAt == nil {
+		t.Fatalf("expected redo to schedule again, got %+v", task)
+	}
+
+	m.HelpVisible = true
+	help := m.renderHelpView()
+	if !strings.Contains(help, "- schedule 2 inbox item(s)") || !strings.Contains(help, "- (undone) tag 2 inbox item(s) #triage") {
+		t.Fatalf("expected the undo history in the help panel:\n%s", help)
+	}
+
+	events, err := repo.ListTaskEvents(context.Background(), storage.TaskEventFilter{TaskID: "b", Field: "state"})
+	if err != nil || len(events) != 3 || events[1].NewValue != "Inbox" || events[1].Source != storage.SourceTUI {
+		t.Fatalf("expected undo to show in task history, got %+v, %v", events, err)
+	}
+}
+
+func TestUndoCaptureDeletesTaskAndRedoRestoresItsRule(t *testing.T) {
+	repo := setupStoreRepo(t)
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.addInboxItem("standup notes #team every:weekday")
+	id := m.Inbox.Items[0].ID
+
+	m.Palette.Input = "undo"
+	m = m.executePaletteCommand()
+	if m.Status.IsError {
+		t.Fatalf("unexpected undo error: %q", m.Status.Text)
+	}
+	if _, err := repo.GetTask(context.Background(), id); err != storage.ErrNotFound {
+		t.Fatalf("expected the captured task to be deleted, got %v", err)
+	}
+	if len(m.Inbox.Items) != 0 {
+		t.Fatalf("expected an empty Inbox, got %+v", m.Inbox.Items)
+	}
+
+	m.Palette.Input = "redo"
+	m = m.executePaletteCommand()
+	task, err := repo.GetTask(context.Background(), id)
+	if err != nil || task.Title != "standup notes" || len(task.Tags) != 1 {
+		t.Fatalf("expected the task back, got %+v, %v", task, err)
+	}
+	rules, err := repo.ListRecurrences(context.Background(), storage.RecurrenceListFilter{TaskID: id})
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("expected its recurrence back, got %+v, %v", rules, err)
+	}
+}
+
+func TestUndoRevertsRecurringFocusCompletion(t *testing.T) {
+	repo := setupStoreRepo(t)
+	ctx := context.Background()
+	scheduled := time.Now().Add(time.Hour).Truncate(time.Minute).UTC()
+	seedStoreTask(t, repo, storage.Task{ID: "walk", Title: "walk the dog", State: "Planned", ScheduledAt: &scheduled})
+	if err := repo.CreateRecurrence(ctx, storage.RecurrenceRule{ID: "r1", TaskID: "walk", RuleType: "every_n_days", IntervalValue: 1, Timezone: "UTC", StartAt: scheduled, Enabled: true, CreatedAt: scheduled}); err != nil {
+		t.Fatalf("create recurrence: %v", err)
+	}
+	if err := repo.CreateReminder(ctx, storage.Reminder{ID: "m1", TaskID: "walk", TriggerAt: scheduled.Add(-10 * time.Minute), Type: "Hard", Enabled: true, CreatedAt: scheduled}); err != nil {
+		t.Fatalf("create reminder: %v", err)
+	}
+
+	engine := scheduler.NewEngine(4)
+	m := NewModelWithRepository(engine, nil, repo, storeTestConfig(t))
+	m.Focus.TaskID, m.Focus.TaskTitle = "walk", "walk the dog"
+	m.Focus.Phase = FocusPhaseWork
+	m.completeFocusPhase()
+	rules, _ := repo.ListRecurrences(ctx, storage.RecurrenceListFilter{})
+	if len(rules) != 1 || rules[0].TaskID == "walk" {
+		t.Fatalf("expected the rule on the next occurrence, got %+v", rules)
+	}
+	next := rules[0].TaskID
+
+	m.Palette.Input = "undo"
+	m = m.executePaletteCommand()
+	if m.Status.IsError || m.Status.Text != `undid complete "walk the dog"` {
+		t.Fatalf("unexpected status %+v", m.Status)
+	}
+	task, err := repo.GetTask(ctx, "walk")
+	if err != nil || task.State != "Planned" || task.CompletedAt != nil {
+		t.Fatalf("expected the task open again, got %+v, %v", task, err)
+	}
+	if _, err := repo.GetTask(ctx, next); err != storage.ErrNotFound {
+		t.Fatalf("expected the next occurrence removed, got %v", err)
+	}
+	rules, _ = repo.ListRecurrences(ctx, storage.RecurrenceListFilter{})
+	if len(rules) != 1 || rules[0].TaskID != "walk" || rules[0].ID != "r1" {
+		t.Fatalf("expected the rule back on the task, got %+v", rules)
+	}
+	rem, err := repo.GetReminder(ctx, "m1")
+	if err != nil || !rem.Enabled {
+		t.Fatalf("expected the reminder enabled again, got %+v, %v", rem, err)
+	}
+	pending := engine.Pending()
+	if len(pending) != 1 || pending[0].ID != "m1" {
+		t.Fatalf("expected only the original reminder armed, got %+v", pending)
+	}
+	if m.CompletedTasks["walk"] {
+		t.Fatalf("expected the completion forgotten")
+	}
+
+	m.Palette.Input = "redo"
+	m = m.executePaletteCommand()
+	if m.Status.IsError {
+		t.Fatalf("unexpected redo error: %q", m.Status.Text)
+	}
+	if task, _ = repo.GetTask(ctx, "walk"); task.State != "Done" {
+		t.Fatalf("expected redo to complete the task again, got %+v", task)
+	}
+	rules, _ = repo.ListRecurrences(ctx, storage.RecurrenceListFilter{})
+	if len(rules) != 1 || rules[0].TaskID != next {
+		t.Fatalf("expected the rule back on the next occurrence, got %+v", rules)
+	}
+}
+
+func TestUndoRevertsRecurrenceEdit(t *testing.T) {
+	repo := setupStoreRepo(t)
+	ctx := context.Background()
+	scheduled := time.Now().Add(time.Hour).Truncate(time.Minute).UTC()
+	seedStoreTask(t, repo, storage.Task{ID: "walk", Title: "walk the dog", State: "Planned", ScheduledAt: &scheduled})
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.CurrentView = ViewToday
+	m.Today.Cursor = 0
+	m.syncSelectedTaskToTodayCursor()
+
+	m = pressKey(t, m, runeKey("R"))
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if rules, _ := repo.ListRecurrences(ctx, storage.RecurrenceListFilter{TaskID: "walk"}); len(rules) != 1 {
+		t.Fatalf("expected a rule saved, got %+v (status %+v)", rules, m.Status)
+	}
+
+	m.Palette.Input = "undo"
+	m = m.executePaletteCommand()
+	if m.Status.IsError || m.Status.Text != "undid edit recurrence" {
+		t.Fatalf("unexpected status %+v", m.Status)
+	}
+	if rules, _ := repo.ListRecurrences(ctx, storage.RecurrenceListFilter{TaskID: "walk"}); len(rules) != 0 {
+		t.Fatalf("expected the rule removed, got %+v", rules)
+	}
+}
+
+func TestUndoRefusesToOverwriteLaterChanges(t *testing.T) {
+	repo := setupStoreRepo(t)
+	seedStoreTask(t, repo, storage.Task{ID: "a", Title: "call bank", State: "Inbox"})
+	m := NewModelWithRepository(nil, nil, repo, storeTestConfig(t))
+	m.CurrentView = ViewInbox
+	m = pressKey(t, m, runeKey("x"))
+	m = pressKey(t, m, runeKey("s"))
+
+	task, _ := repo.GetTask(context.Background(), "a")
+	task.Title = "call the bank"
+	if err := repo.UpdateTask(context.Background(), task); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if !m.Status.IsError || !strings.Contains(m.Status.Text, "task changed since: a") {
+		t.Fatalf("expected a conflict, got %+v", m.Status)
+	}
+	if got, _ := repo.GetTask(context.Background(), "a"); got.Title != "call the bank" || got.State != "Planned" {
+		t.Fatalf("expected the later edit to survive, got %+v", got)
+	}
+	if undo, _ := m.undoLines(); len(undo) != 0 {
+		t.Fatalf("expected the stale step to be dropped, got %v", undo)
+	}
+}
+
+func TestUndoWithoutRepositoryRestoresViewsAndIsBounded(t *testing.T) {
+	m := NewModel()
+	m.CurrentView = ViewInbox
+	for i := 0; i < undoLimit+5; i++ {
+		m.addInboxItem(fmt.Sprintf("item %d", i))
+	}
+	if undo, _ := m.undoLines(); len(undo) != undoLimit || undo[0] != fmt.Sprintf("capture %q", fmt.Sprintf("item %d", undoLimit+4)) {
+		t.Fatalf("expected %d newest steps, got %v", undoLimit, undo)
+	}
+
+	m = pressKey(t, m, runeKey("x"))
+	m = pressKey(t, m, runeKey("s"))
+	if m.Inbox.Items[0].ScheduledFor == "" {
+		t.Fatalf("expected items to be scheduled")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if m.Inbox.Items[0].ScheduledFor != "" || len(m.Inbox.Items) != undoLimit+5 {
+		t.Fatalf("expected the schedule to be undone, got %+v", m.Inbox.Items[0])
+	}
+	m = pressKey(t, m, runeKey("u"))
+	if len(m.Inbox.Selected) != 0 || m.Inbox.CaptureMode {
+		t.Fatalf("expected u to clear the selection")
+	}
+}
@@ -3,7 +3,6 @@ package update
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,13 +10,23 @@ import (
 )
 
 func (m Model) Init() tea.Cmd {
+	cmds := []tea.Cmd{pollChangesCmd(m.repo, m.clock, m.refreshInterval, m.changeSeq)}
 	if m.Scheduler != nil {
-		return waitForReminderCmd(m.Scheduler.C())
+		cmds = append(cmds, waitForReminderCmd(m.Scheduler.C()), waitForDeadLetterCmd(m.Scheduler.DeadLetters()))
 	}
-	return nil
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if updated, ok := next.(Model); ok && updated.CurrentView == ViewToday {
+		updated.refreshHistory()
+		next = updated
+	}
+	return next, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	defer m.syncBubbleData()
 
 	switch typed := msg.(type) {
@@ -78,17 +87,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.Status = StatusBar{Text: "help hidden", IsError: false}
 			}
 			return m, nil
-		case "S":
-			if !m.spinnerActive {
-				m.spinnerActive = true
-				m.Status = StatusBar{Text: "sync started", IsError: false}
-				return m, tea.Batch(m.syncSpinner.Tick, tea.Tick(2*time.Second, func(time.Time) tea.Msg { return SetStatusMsg{Text: "sync complete", IsError: false} }))
-			}
+		case m.Keys.Undo:
+			m.showUndoResult(m.undoLast())
+			return m, nil
+		case m.Keys.Redo:
+			m.showUndoResult(m.redoLast())
 			return m, nil
+		case "S":
+			return m.startSync()
 		case "R":
 			if m.CurrentView == ViewToday {
-				m.recurrenceEditor.Active = true
-				m.recurrenceEditor.Err = ""
+				m.openRecurrenceEditor()
 				return m, nil
 			}
 		case "z":
@@ -136,9 +145,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case SetStatusMsg:
 		m.Status = StatusBar{Text: typed.Text, IsError: typed.IsError}
-		if strings.Contains(strings.ToLower(typed.Text), "sync complete") {
-			m.spinnerActive = false
-		}
 		m.notify("Status", typed.Text, levelFromError(typed.IsError))
 		return m, nil
 	case ClearStatusMsg:
@@ -161,16 +167,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.bulkTagInbox(typed.Tag)
 		return m, nil
 	case SetTodayItemsMsg:
+		current, _ := m.currentTodayItem()
 		m.Today.Items = typed.Items
-		m.Today.Cursor = 0
+		m.Today.Cursor = todayCursorFor(typed.Items, current.ID, 0)
 		m.syncSelectedTaskToTodayCursor()
 		m.refreshProductivitySignals()
 		return m, nil
 	case SetCalendarItemsMsg:
+		current, _ := m.currentAgendaItem()
 		m.Calendar.Items = typed.Items
-		m.Calendar.Cursor = 0
+		m.Calendar.Cursor = calendarCursorFor(typed.Items, current.ID, 0)
 		m.syncSelectedTaskToCalendarCursor()
 		return m, nil
+	case ExternalChangesMsg:
+		return m.onExternalChanges(typed)
+	case SyncProgressMsg:
+		return m.onSyncProgress(typed)
+	case SyncDoneMsg:
+		return m.onSyncDone(typed)
 	case FocusTickMsg:
 		return m.onFocusTick()
 	case ReminderDueMsg:
@@ -178,15 +192,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if len(m.ReminderLog) > 20 {
 			m.ReminderLog = m.ReminderLog[len(m.ReminderLog)-20:]
 		}
-		m.applyReminderBehavior(typed.Event, time.Now().UTC())
+		now := m.now().UTC()
+		m.applyReminderBehavior(typed.Event, now)
+		if err := m.recordReminderDelivery(typed.Event, now); err != nil {
+			m.Status = StatusBar{Text: fmt.Sprintf("persist reminder checkpoint failed: %v", err), IsError: true}
+		}
 		m.notify("Reminder", m.Status.Text, levelFromError(m.Status.IsError))
 		if m.Scheduler != nil {
 			return m, waitForReminderCmd(m.Scheduler.C())
 		}
 		return m, nil
+	case DeadLetterMsg:
+		m.MissedCount++
+		m.Status = StatusBar{
+			Text:    fmt.Sprintf("%d reminder(s) were missed (last: %s, %s)", m.MissedCount, typed.Letter.Event.ID, typed.Letter.Reason),
+			IsError: true,
+		}
+		m.notify("Reminder missed", m.Status.Text, levelFromError(true))
+		if m.Scheduler != nil {
+			return m, waitForDeadLetterCmd(m.Scheduler.DeadLetters())
+		}
+		return m, nil
 	case AcknowledgeReminderMsg:
 		if typed.ID != "" {
 			m.ReminderAck[typed.ID] = true
+			if m.Scheduler != nil {
+				m.Scheduler.Cancel(typed.ID)
+			}
 			m.Status = StatusBar{Text: fmt.Sprintf("reminder acknowledged: %s", typed.ID), IsError: false}
 		}
 		return m, nil
@@ -204,6 +236,9 @@ func (m Model) View() string {
 			status = fmt.Sprintf("status: %s", m.Status.Text)
 		}
 	}
+	if m.ConflictTaskID != "" && m.ConflictTaskID == m.SelectedTaskID {
+		status += fmt.Sprintf(" [conflict: %s changed elsewhere]", m.ConflictTaskID)
+	}
 	leftPane := ""
 	rightPane := ""
 	switch m.CurrentView {
@@ -227,7 +262,11 @@ func (m Model) View() string {
 	}
 	if m.spinnerActive {
 		spin := m.syncSpinner.View()
-		notificationView = strings.TrimSpace(strings.Join([]string{notificationView, "sync: " + spin + " running"}, "\n"))
+		progress := "running"
+		if p := m.syncProgress; p.Total > 0 {
+			progress = fmt.Sprintf("%ss %d/%d", p.Entity, p.Done, p.Total)
+		}
+		notificationView = strings.TrimSpace(strings.Join([]string{notificationView, "sync: " + spin + " " + progress}, "\n"))
 	}
 	notificationView = strings.TrimSpace(strings.Join([]string{
 		notificationView,
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/sandeepkv93/taskd/internal/clock"
 )
 
 func levelFromError(isErr bool) string {
@@ -53,3 +56,9 @@ func contains(items []string, target string) bool {
 	}
 	return false
 }
+
+// now reads the model clock, falling back to the system clock for models
+// built as zero values in tests.
+func (m Model) now() time.Time {
+	return clock.OrReal(m.clock).Now()
+}
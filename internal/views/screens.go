@@ -10,8 +10,10 @@ import (
 
 type InboxPanelData struct {
 	QuickAddView string
-	ListView     string
-	CaptureMode  bool
+	// Preview shows the metadata parsed from the quick-add input.
+	Preview     string
+	ListView    string
+	CaptureMode bool
 }
 
 type TodayItemData struct {
@@ -60,6 +62,10 @@ type HelpPanelData struct {
 	CurrentView string
 	Bindings    []string
 	HelpView    string
+	// Undo lists the actions that can be undone, newest first; Redo lists
+	// the ones that can be redone.
+	Undo []string
+	Redo []string
 }
 
 type SuggestionData struct {
@@ -81,14 +87,24 @@ type TodayMetadataData struct {
 	Tags             []string
 	NotesEditorView  string
 	MarkdownMetaView string
+	// History is the selected task's recent changes, newest first; nil hides
+	// the section.
+	History []string
 }
 
 type RecurrenceEditorData struct {
-	Active       bool
-	RuleType     string
-	IntervalText string
-	ErrorText    string
-	Preview      []string
+	Active        bool
+	RuleType      string
+	IntervalText  string
+	RRuleText     string
+	EndsText      string
+	ExceptionText string
+	// Exceptions are the rule's skips, moves and pauses, already described.
+	Exceptions []string
+	// Field is the line being typed into: "rule", "ends" or "exception".
+	Field     string
+	ErrorText string
+	Preview   []string
 }
 
 var (
@@ -103,6 +119,9 @@ func RenderInboxPanel(data InboxPanelData) string {
 	var b strings.Builder
 	b.WriteString(accentStyle.Render("inbox:") + "\n")
 	b.WriteString(data.QuickAddView + "\n")
+	if data.CaptureMode && data.Preview != "" {
+		b.WriteString("  " + data.Preview + "\n")
+	}
 	if data.CaptureMode {
 		b.WriteString("mode: capture | [enter] add task | [esc] list mode\n")
 	} else {
@@ -254,9 +273,20 @@ func RenderProductivityPanel(data ProductivityPanelData) string {
 }
 
 func RenderHelpPanel(data HelpPanelData) string {
-	left := cardStyle.Width(42).Render(fmt.Sprintf("help:\nglobal:\n%s view:\n%s",
+	history := "\n\nundo history:"
+	if len(data.Undo) == 0 && len(data.Redo) == 0 {
+		history += "\n(nothing to undo)"
+	}
+	for _, label := range data.Undo {
+		history += "\n- " + label
+	}
+	for _, label := range data.Redo {
+		history += "\n- (undone) " + label
+	}
+	left := cardStyle.Width(42).Render(fmt.Sprintf("help:\nglobal:\n%s view:\n%s%s",
 		strings.ToLower(data.CurrentView),
 		strings.Join(data.Bindings, "\n"),
+		history,
 	))
 	right := cardStyle.Width(42).Render(data.HelpView)
 	return strings.TrimSpace(left + "\n" + right)
@@ -267,13 +297,23 @@ func RenderTodayMetadataPane(data TodayMetadataData) string {
 		return "metadata:\n(no selection)"
 	}
 	tags := strings.Join(data.Tags, ",")
-	return fmt.Sprintf("metadata:\nid: %s\npriority: %s\ntags: %s\n\nnotes-editor:\n%s\n\nmarkdown-preview:\n%s",
+	out := fmt.Sprintf("metadata:\nid: %s\npriority: %s\ntags: %s\n\nnotes-editor:\n%s\n\nmarkdown-preview:\n%s",
 		data.SelectedID,
 		data.Priority,
 		tags,
 		data.NotesEditorView,
 		data.MarkdownMetaView,
 	)
+	if data.History != nil {
+		out += "\n\nhistory:"
+		if len(data.History) == 0 {
+			out += "\n(no changes recorded)"
+		}
+		for _, line := range data.History {
+			out += "\n" + line
+		}
+	}
+	return out
 }
 
 func RenderRecurrenceEditor(data RecurrenceEditorData) string {
@@ -282,9 +322,24 @@ func RenderRecurrenceEditor(data RecurrenceEditorData) string {
 	}
 	var b strings.Builder
 	b.WriteString("\nrecurrence-editor:\n")
-	b.WriteString("keys: [tab] field [enter] preview [esc] close\n")
+	b.WriteString("keys: [tab] type [up/down] line [enter] add/preview [ctrl+d] drop exception [ctrl+s] save [esc] close\n")
 	b.WriteString(fmt.Sprintf("type: %s\n", data.RuleType))
-	b.WriteString(fmt.Sprintf("interval: %s\n", data.IntervalText))
+	cursor := func(field string) string {
+		if data.Field == field {
+			return "> "
+		}
+		return "  "
+	}
+	if data.RuleType == "rrule" {
+		b.WriteString(fmt.Sprintf("%srule: %s\n", cursor("rule"), data.RRuleText))
+	} else {
+		b.WriteString(fmt.Sprintf("%sinterval: %s\n", cursor("rule"), data.IntervalText))
+	}
+	b.WriteString(fmt.Sprintf("%sends: %s\n", cursor("ends"), data.EndsText))
+	b.WriteString(fmt.Sprintf("%sexception: %s\n", cursor("exception"), data.ExceptionText))
+	for _, item := range data.Exceptions {
+		b.WriteString("  - " + item + "\n")
+	}
 	if data.ErrorText != "" {
 		b.WriteString("error: " + data.ErrorText + "\n")
 	}
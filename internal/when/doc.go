@@ -0,0 +1,2 @@
+// Package when turns natural-language schedule phrases into concrete times.
+package when
@@ -0,0 +1,432 @@
+package when
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEmpty        = errors.New("when: empty phrase")
+	ErrUnrecognized = errors.New("when: unrecognized phrase")
+	ErrAmbiguous    = errors.New("when: ambiguous phrase")
+)
+
+const (
+	// DefaultHour is the time of day used when a phrase names a day but no time.
+	DefaultHour = 9
+	// EndOfDayHour is what "eod" and "eow" resolve to.
+	EndOfDayHour = 17
+	// EveningHour is what "tonight" resolves to.
+	EveningHour = 20
+)
+
+var absoluteLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// Parse resolves phrase to an instant relative to now, in now's location.
+// It understands ISO dates and times, "today", "tomorrow", "tonight", "eod",
+// "eow", weekday names ("fri", "next monday"), clock times ("9am", "17:30",
+// "noon") and relative offsets ("in 3 days", "+2h", "2 days"). Day-only
+// phrases resolve to DefaultHour; time-only phrases to the next occurrence.
+func Parse(phrase string, now time.Time) (time.Time, error) {
+	trimmed := strings.TrimSpace(phrase)
+	if trimmed == "" {
+		return time.Time{}, ErrEmpty
+	}
+	loc := now.Location()
+	if at, err := time.Parse(time.RFC3339, trimmed); err == nil {
+		return at.In(loc), nil
+	}
+	for _, layout := range absoluteLayouts {
+		if at, err := time.ParseInLocation(layout, trimmed, loc); err == nil {
+			return at, nil
+		}
+	}
+
+	tokens := strings.Fields(strings.ToLower(trimmed))
+	if at, ok := parseRelative(tokens, now); ok {
+		return at, nil
+	}
+	p := phraseParser{phrase: trimmed, now: now}
+	if err := p.parse(tokens); err != nil {
+		return time.Time{}, err
+	}
+	return p.resolve()
+}
+
+// parseRelative handles "in 3 days", "+2h" and bare offsets like "2 days".
+func parseRelative(tokens []string, now time.Time) (time.Time, bool) {
+	if len(tokens) == 0 {
+		return time.Time{}, false
+	}
+	rest := tokens
+	if rest[0] == "in" {
+		rest = rest[1:]
+	}
+	joined := strings.TrimPrefix(strings.Join(normalizeArticles(rest), ""), "+")
+	if joined == "" {
+		return time.Time{}, false
+	}
+	days, d, ok := parseOffset(joined)
+	if !ok {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, days).Add(d), true
+}
+
+func normalizeArticles(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		switch tok {
+		case "a", "an":
+			out = append(out, "1")
+		case "and":
+		default:
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+var offsetPart = regexp.MustCompile(`^(\d+)([a-z]+)`)
+
+// parseOffset reads a compact offset such as "3days", "2h" or "1h30m".
+// Calendar units are returned as days so they follow wall-clock time across
+// DST changes.
+func parseOffset(s string) (int, time.Duration, bool) {
+	days := 0
+	var d time.Duration
+	for s != "" {
+		match := offsetPart.FindStringSubmatch(s)
+		if match == nil {
+			return 0, 0, false
+		}
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, 0, false
+		}
+		switch match[2] {
+		case "m", "min", "mins", "minute", "minutes":
+			d += time.Duration(n) * time.Minute
+		case "h", "hr", "hrs", "hour", "hours":
+			d += time.Duration(n) * time.Hour
+		case "d", "day", "days":
+			days += n
+		case "w", "wk", "wks", "week", "weeks":
+			days += 7 * n
+		default:
+			return 0, 0, false
+		}
+		s = s[len(match[0]):]
+	}
+	return days, d, true
+}
+
+type dayKind int
+
+const (
+	dayNone dayKind = iota
+	dayOffset
+	dayWeekday
+	dayDate
+)
+
+type phraseParser struct {
+	phrase string
+	now    time.Time
+
+	day     dayKind
+	offset  int
+	weekday time.Weekday
+	next    bool
+	date    time.Time
+	hasTime bool
+	hour    int
+	minute  int
+	// fallbackHour replaces DefaultHour for words that imply a time, such
+	// as "eod"; zero means none.
+	fallbackHour int
+}
+
+func (p *phraseParser) parse(tokens []string) error {
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok {
+		case "at", "on", "by":
+			continue
+		case "today":
+			if err := p.setDay(dayOffset, 0); err != nil {
+				return err
+			}
+			continue
+		case "tomorrow", "tmrw", "tmr":
+			if err := p.setDay(dayOffset, 1); err != nil {
+				return err
+			}
+			continue
+		case "tonight":
+			if err := p.setDay(dayOffset, 0); err != nil {
+				return err
+			}
+			p.fallbackHour = EveningHour
+			continue
+		case "eod":
+			if err := p.setDay(dayOffset, 0); err != nil {
+				return err
+			}
+			p.fallbackHour = EndOfDayHour
+			continue
+		case "eow":
+			if err := p.setWeekday(time.Friday, false); err != nil {
+				return err
+			}
+			p.fallbackHour = EndOfDayHour
+			continue
+		case "next", "this":
+			if i+1 >= len(tokens) {
+				return p.unrecognized()
+			}
+			if tokens[i+1] == "week" && tok == "next" {
+				if err := p.setWeekday(time.Monday, true); err != nil {
+					return err
+				}
+				i++
+				continue
+			}
+			wd, ok := weekdays[tokens[i+1]]
+			if !ok {
+				return p.unrecognized()
+			}
+			if err := p.setWeekday(wd, tok == "next"); err != nil {
+				return err
+			}
+			i++
+			continue
+		}
+		if wd, ok := weekdays[tok]; ok {
+			if err := p.setWeekday(wd, false); err != nil {
+				return err
+			}
+			continue
+		}
+		if date, ok, err := p.parseDate(tok); err != nil {
+			return err
+		} else if ok {
+			if p.day != dayNone {
+				return p.conflict()
+			}
+			p.day = dayDate
+			p.date = date
+			continue
+		}
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		hour, minute, used, err := p.parseClock(tok, next)
+		if err != nil {
+			return err
+		}
+		if p.hasTime {
+			return p.conflict()
+		}
+		p.hasTime, p.hour, p.minute = true, hour, minute
+		i += used - 1
+	}
+	if p.day == dayNone && !p.hasTime {
+		return p.unrecognized()
+	}
+	return nil
+}
+
+func (p *phraseParser) setDay(kind dayKind, offset int) error {
+	if p.day != dayNone {
+		return p.conflict()
+	}
+	p.day = kind
+	p.offset = offset
+	return nil
+}
+
+func (p *phraseParser) setWeekday(wd time.Weekday, next bool) error {
+	if err := p.setDay(dayWeekday, 0); err != nil {
+		return err
+	}
+	p.weekday = wd
+	p.next = next
+	return nil
+}
+
+var slashDate = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})(?:/(\d{4}))?$`)
+
+// parseDate reads "2006-01-02" and d/m or m/d dates. Slash dates are only
+// accepted when the order is unambiguous.
+func (p *phraseParser) parseDate(tok string) (time.Time, bool, error) {
+	loc := p.now.Location()
+	if at, err := time.ParseInLocation("2006-01-02", tok, loc); err == nil {
+		return at, true, nil
+	}
+	match := slashDate.FindStringSubmatch(tok)
+	if match == nil {
+		return time.Time{}, false, nil
+	}
+	a, _ := strconv.Atoi(match[1])
+	b, _ := strconv.Atoi(match[2])
+	year := p.now.Year()
+	if match[3] != "" {
+		year, _ = strconv.Atoi(match[3])
+	}
+	var month, day int
+	switch {
+	case a == b || (a <= 12 && b > 12):
+		month, day = a, b
+	case a > 12 && b <= 12:
+		month, day = b, a
+	case a <= 12 && b <= 12:
+		return time.Time{}, false, fmt.Errorf("%w: %q could be %s or %s; use YYYY-MM-DD", ErrAmbiguous, p.phrase,
+			time.Date(year, time.Month(a), b, 0, 0, 0, 0, loc).Format("Jan 2"),
+			time.Date(year, time.Month(b), a, 0, 0, 0, 0, loc).Format("Jan 2"))
+	default:
+		return time.Time{}, false, p.unrecognized()
+	}
+	at := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	if at.Month() != time.Month(month) || at.Day() != day {
+		return time.Time{}, false, p.unrecognized()
+	}
+	return at, true, nil
+}
+
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm|a|p)?$`)
+
+// parseClock reads a time of day from tok, consuming next as well when it is
+// a detached "am"/"pm". It returns how many tokens were used.
+func (p *phraseParser) parseClock(tok, next string) (int, int, int, error) {
+	switch tok {
+	case "noon":
+		return 12, 0, 1, nil
+	case "midnight":
+		return 0, 0, 1, nil
+	}
+	match := clockPattern.FindStringSubmatch(tok)
+	if match == nil {
+		return 0, 0, 0, p.unrecognized()
+	}
+	used := 1
+	suffix := match[3]
+	if suffix == "" && (next == "am" || next == "pm") {
+		suffix = next
+		used = 2
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	if minute > 59 {
+		return 0, 0, 0, p.unrecognized()
+	}
+	switch suffix {
+	case "":
+		// Only 1 to 12 could be either half of the day.
+		if match[2] == "" && hour >= 1 && hour <= 12 {
+			return 0, 0, 0, fmt.Errorf("%w: %q: write %s:00, %sam or %spm", ErrAmbiguous, p.phrase, match[1], match[1], match[1])
+		}
+		if hour > 23 {
+			return 0, 0, 0, p.unrecognized()
+		}
+	default:
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, p.unrecognized()
+		}
+		hour %= 12
+		if strings.HasPrefix(suffix, "p") {
+			hour += 12
+		}
+	}
+	return hour, minute, used, nil
+}
+
+func (p *phraseParser) resolve() (time.Time, error) {
+	loc := p.now.Location()
+	y, mo, d := p.now.Date()
+	today := time.Date(y, mo, d, 0, 0, 0, 0, loc)
+
+	hour, minute := DefaultHour, 0
+	if p.fallbackHour != 0 {
+		hour = p.fallbackHour
+	}
+	if p.hasTime {
+		hour, minute = p.hour, p.minute
+	}
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+	}
+
+	switch p.day {
+	case dayNone:
+		// A bare time means its next occurrence.
+		if candidate := at(today); candidate.After(p.now) {
+			return candidate, nil
+		}
+		return at(today.AddDate(0, 0, 1)), nil
+	case dayOffset:
+		candidate := at(today.AddDate(0, 0, p.offset))
+		if p.offset == 0 && !p.hasTime && p.fallbackHour != 0 && !candidate.After(p.now) {
+			// "eod"/"tonight" after the fact: the end of today.
+			return time.Date(y, mo, d, 23, 59, 0, 0, loc), nil
+		}
+		return candidate, nil
+	case dayDate:
+		return at(p.date), nil
+	}
+
+	delta := (int(p.weekday) - int(p.now.Weekday()) + 7) % 7
+	if p.next {
+		if isoIndex(p.weekday) > isoIndex(p.now.Weekday()) {
+			this := today.AddDate(0, 0, delta)
+			return time.Time{}, fmt.Errorf("%w: %q could be %s or %s", ErrAmbiguous, p.phrase,
+				this.Format("Mon Jan 2"), this.AddDate(0, 0, 7).Format("Mon Jan 2"))
+		}
+		if delta == 0 {
+			delta = 7
+		}
+		return at(today.AddDate(0, 0, delta)), nil
+	}
+	candidate := at(today.AddDate(0, 0, delta))
+	if !candidate.After(p.now) {
+		candidate = at(today.AddDate(0, 0, delta+7))
+	}
+	return candidate, nil
+}
+
+// isoIndex numbers weekdays from Monday so "next <day>" is judged against
+// the ISO week.
+func isoIndex(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
+
+func (p *phraseParser) unrecognized() error {
+	return fmt.Errorf("%w: %q", ErrUnrecognized, p.phrase)
+}
+
+func (p *phraseParser) conflict() error {
+	return fmt.Errorf("%w: %q names more than one day or time", ErrAmbiguous, p.phrase)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
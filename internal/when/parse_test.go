@@ -0,0 +1,110 @@
+package when
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseResolvesPhrases(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	// Wednesday afternoon.
+	now := time.Date(2026, 2, 11, 14, 30, 0, 0, loc)
+	on := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+
+	cases := []struct {
+		phrase string
+		want   time.Time
+	}{
+		{"tomorrow 9am", on(2, 12, 9, 0)},
+		{"Tomorrow at 9:30 PM", on(2, 12, 21, 30)},
+		{"9am tomorrow", on(2, 12, 9, 0)},
+		{"tomorrow", on(2, 12, DefaultHour, 0)},
+		{"today 16:00", on(2, 11, 16, 0)},
+		{"next monday", on(2, 16, DefaultHour, 0)},
+		{"next week", on(2, 16, DefaultHour, 0)},
+		{"next wed", on(2, 18, DefaultHour, 0)},
+		{"mon", on(2, 16, DefaultHour, 0)},
+		{"fri 17:30", on(2, 13, 17, 30)},
+		{"wed 15:00", on(2, 11, 15, 0)},
+		{"wed 9am", on(2, 18, 9, 0)},
+		{"this fri", on(2, 13, DefaultHour, 0)},
+		{"in 3 days", on(2, 14, 14, 30)},
+		{"in an hour", on(2, 11, 15, 30)},
+		{"2 days", on(2, 13, 14, 30)},
+		{"+2h", on(2, 11, 16, 30)},
+		{"+1h30m", on(2, 11, 16, 0)},
+		{"in 1 week", on(2, 18, 14, 30)},
+		{"eod", on(2, 11, EndOfDayHour, 0)},
+		{"eow", on(2, 13, EndOfDayHour, 0)},
+		{"tonight", on(2, 11, EveningHour, 0)},
+		{"noon", on(2, 12, 12, 0)},
+		{"17:30", on(2, 11, 17, 30)},
+		{"tomorrow 17", on(2, 12, 17, 0)},
+		{"fri at 23", on(2, 13, 23, 0)},
+		{"today 13", on(2, 11, 13, 0)},
+		{"2026-03-01", on(3, 1, DefaultHour, 0)},
+		{"2026-03-01 14:00", on(3, 1, 14, 0)},
+		{"2026-03-01 2pm", on(3, 1, 14, 0)},
+		{"2026-03-01T14:00", on(3, 1, 14, 0)},
+		{"2026-03-01T19:00:00Z", on(3, 1, 14, 0)},
+		{"25/12", on(12, 25, DefaultHour, 0)},
+		{"12/25/2026 8am", on(12, 25, 8, 0)},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.phrase, now)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.phrase, err)
+		}
+		if !got.Equal(tc.want) || got.Location() != loc {
+			t.Fatalf("Parse(%q) = %s, want %s", tc.phrase, got, tc.want)
+		}
+	}
+}
+
+func TestParseReportsAmbiguityAndUnknownPhrases(t *testing.T) {
+	now := time.Date(2026, 2, 11, 14, 30, 0, 0, time.UTC)
+	cases := []struct {
+		phrase string
+		want   error
+	}{
+		{"", ErrEmpty},
+		{"   ", ErrEmpty},
+		{"next fri", ErrAmbiguous},
+		{"3/4", ErrAmbiguous},
+		{"tomorrow 9", ErrAmbiguous},
+		{"tomorrow 12", ErrAmbiguous},
+		{"tomorrow fri", ErrAmbiguous},
+		{"9am 10am", ErrAmbiguous},
+		{"someday", ErrUnrecognized},
+		{"next", ErrUnrecognized},
+		{"in", ErrUnrecognized},
+		{"25:00", ErrUnrecognized},
+		{"tomorrow 24", ErrUnrecognized},
+		{"13pm", ErrUnrecognized},
+		{"2/30", ErrUnrecognized},
+	}
+	for _, tc := range cases {
+		if _, err := Parse(tc.phrase, now); !errors.Is(err, tc.want) {
+			t.Fatalf("Parse(%q) error = %v, want %v", tc.phrase, err, tc.want)
+		}
+	}
+}
+
+func TestParseEndOfDayAfterHoursAndNextSameWeekday(t *testing.T) {
+	evening := time.Date(2026, 2, 13, 18, 0, 0, 0, time.UTC) // Friday
+	if got, err := Parse("eod", evening); err != nil || !got.Equal(time.Date(2026, 2, 13, 23, 59, 0, 0, time.UTC)) {
+		t.Fatalf("eod after hours = %s, %v", got, err)
+	}
+	if got, err := Parse("next fri", evening); err != nil || !got.Equal(time.Date(2026, 2, 20, DefaultHour, 0, 0, 0, time.UTC)) {
+		t.Fatalf("next fri on a friday = %s, %v", got, err)
+	}
+	if got, err := Parse("fri", evening); err != nil || !got.Equal(time.Date(2026, 2, 20, DefaultHour, 0, 0, 0, time.UTC)) {
+		t.Fatalf("fri after its default hour = %s, %v", got, err)
+	}
+}